@@ -68,7 +68,7 @@ Write-Host ""
 Write-Host "Next steps:"
 Write-Host "  1. Update src\config\config-development.yml with your database settings"
 Write-Host "  2. Update src\docs\docs.go with your API info"
-Write-Host "  3. Run 'make run' or 'cd src; go run ./cmd/main.go' to start the server"
+Write-Host "  3. Run 'make run' or 'cd src; go run ./cmd' to start the server"
 Write-Host ""
 Write-Host "Useful commands:"
 Write-Host "  make run        - Run the application"
@@ -73,7 +73,7 @@ echo ""
 echo "Next steps:"
 echo "  1. Update src/config/config-development.yml with your database settings"
 echo "  2. Update src/docs/docs.go with your API info"
-echo "  3. Run 'make run' or 'cd src && go run ./cmd/main.go' to start the server"
+echo "  3. Run 'make run' or 'cd src && go run ./cmd' to start the server"
 echo ""
 echo "Useful commands:"
 echo "  make run        - Run the application"
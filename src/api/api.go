@@ -9,12 +9,15 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/gofiber/fiber/v2/middleware/monitor"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"github.com/minisource/go-common/http/middleware"
 	"github.com/minisource/go-common/logging"
 	"github.com/minisource/go-common/metrics"
 	validation "github.com/minisource/go-common/validations"
+	apimiddleware "github.com/minisource/template_go/api/middleware"
 	"github.com/minisource/template_go/api/router"
 	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/constant"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	swagger "github.com/swaggo/fiber-swagger"
@@ -24,16 +27,22 @@ import (
 
 var logger = logging.NewLogger(&config.GetConfig().Logger)
 
+// multipartOverhead leaves room for form fields and boundaries around an uploaded file
+const multipartOverhead = 1 << 20
+
 func InitServer(cfg *config.Config) {
 	// Create Fiber instance
 	app := fiber.New(fiber.Config{
 		ErrorHandler: middleware.ErrorHandler,
+		// Routes enforce their own upload policy, this only caps the request body
+		BodyLimit: int(cfg.Upload.MaxSize()) + multipartOverhead,
 	})
 
 	RegisterValidators()
 	RegisterPrometheus()
 
 	// Middlewares
+	app.Use(requestid.New()) // X-Request-ID, copied into the principal of authenticated requests
 	app.Use(middleware.DefaultStructuredLogger(&cfg.Logger)) // basic logger
 	app.Use(middleware.Prometheus())
 	app.Use(middleware.Cors(cfg.Cors.AllowOrigins))
@@ -68,18 +77,25 @@ func RegisterRoutes(app *fiber.App, cfg *config.Config) {
 	health := v1.Group("/health")
 	router.Health(health)
 
-	// Test (add middleware later)
-	test := v1.Group("/test")
+	// Test
+	test := v1.Group("/test", apimiddleware.Authentication(cfg), apimiddleware.RequireRoles(constant.AdminRoleName))
 	router.TestRouter(test)
 
 	// Users
 	users := v1.Group("/auth")
 	router.User(users, cfg)
 
-	// files := v1.Group("/files")
-	// router.File(files, cfg)
+	// Profiles
+	profiles := v1.Group("/users", apimiddleware.Authentication(cfg))
+	router.UserProfile(profiles, cfg)
+
+	// Files
+	files := v1.Group("/files", apimiddleware.Authentication(cfg))
+	router.File(files, cfg)
 
-	app.Static("/static", "./uploads")
+	// Audit
+	audit := v1.Group("/audit", apimiddleware.Authentication(cfg), apimiddleware.RequireRoles(constant.AdminRoleName))
+	router.Audit(audit, cfg)
 
 	app.Get("/metrics", adaptor.HTTPHandler(promhttp.Handler()))
 }
@@ -0,0 +1,36 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/minisource/template_go/usecase/dto"
+)
+
+type AuditLogResponse struct {
+	Id        int             `json:"id"`
+	Action    string          `json:"action"`
+	Entity    string          `json:"entity"`
+	EntityId  int             `json:"entityId"`
+	UserId    int             `json:"userId"`
+	Username  string          `json:"username"`
+	Ip        string          `json:"ip"`
+	RequestId string          `json:"requestId"`
+	At        time.Time       `json:"at"`
+	Changes   json.RawMessage `json:"changes,omitempty" swaggertype:"object"` // Field name to {"before", "after"}, updates only
+}
+
+func ToAuditLogResponse(from dto.AuditLog) AuditLogResponse {
+	return AuditLogResponse{
+		Id:        from.Id,
+		Action:    from.Action,
+		Entity:    from.Entity,
+		EntityId:  from.EntityId,
+		UserId:    from.CreatedBy,
+		Username:  from.Username,
+		Ip:        from.Ip,
+		RequestId: from.RequestId,
+		At:        from.CreatedAt,
+		Changes:   from.Changes,
+	}
+}
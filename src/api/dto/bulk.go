@@ -0,0 +1,30 @@
+package dto
+
+import "encoding/json"
+
+// BulkRequest is the body of the bulk endpoints, mode is atomic (the default) or bestEffort
+type BulkRequest[T any] struct {
+	Mode  string `json:"mode"`
+	Items []T    `json:"items"`
+}
+
+// BulkPatchRequest is one item of PATCH /bulk, version 0 skips the check like a missing If-Match
+type BulkPatchRequest struct {
+	Id      int             `json:"id"`
+	Version int             `json:"version"`
+	Patch   json.RawMessage `json:"patch" swaggertype:"object"`
+}
+
+// BulkItemResponse is the outcome of the item at index of the request
+type BulkItemResponse[T any] struct {
+	Index   int    `json:"index"`
+	Success bool   `json:"success"`
+	Result  *T     `json:"result,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ImportRowError explains why a row of an import file was rejected, rows count from 1 after the header
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
@@ -2,6 +2,7 @@ package dto
 
 import (
 	"mime/multipart"
+	"time"
 
 	"github.com/minisource/template_go/usecase/dto"
 )
@@ -13,13 +14,14 @@ type FileFormRequest struct {
 type UploadFileRequest struct {
 	FileFormRequest
 	Description string `json:"description" form:"description" binding:"required"`
+	Checksum    string `json:"checksum" form:"checksum"` // Optional hex SHA-256 of the content, verified on upload
 }
 
 type CreateFileRequest struct {
-	Name        string `json:"name"`
-	Directory   string `json:"directory"`
+	Name        string `json:"name" binding:"required"`
+	Directory   string `json:"directory" binding:"required"`
 	Description string `json:"description"`
-	MimeType    string `json:"mimeType"`
+	MimeType    string `json:"mimeType" binding:"required"`
 }
 
 type UpdateFileRequest struct {
@@ -27,21 +29,66 @@ type UpdateFileRequest struct {
 }
 
 type FileResponse struct {
-	Id          int    `json:"id"`
-	Name        string `json:"name"`
-	Directory   string `json:"directory"`
-	Description string `json:"description"`
-	MimeType    string `json:"mimeType"`
+	Id            int                   `json:"id"`
+	Version       int                   `json:"version"`
+	Name          string                `json:"name"`
+	OriginalName  string                `json:"originalName"`
+	Directory     string                `json:"directory"`
+	Description   string                `json:"description"`
+	MimeType      string                `json:"mimeType"`
+	Size          int64                 `json:"size"`
+	Checksum      string                `json:"checksum"`
+	ParentId      *int                  `json:"parentId,omitempty"`
+	Variant       string                `json:"variant,omitempty"`
+	VariantStatus string                `json:"variantStatus,omitempty"`
+	Variants      []FileVariantResponse `json:"variants,omitempty"`
+	DeletedAt     *time.Time            `json:"deletedAt,omitempty"`
+	Rank          float64               `json:"rank,omitempty"`       // Search relevance, set when listed with q
+	Highlights    map[string]string     `json:"highlights,omitempty"` // Snippets of the searched fields by column
+}
+
+type FileVariantResponse struct {
+	Id       int    `json:"id"`
+	Variant  string `json:"variant"`
+	MimeType string `json:"mimeType"`
+	Size     int64  `json:"size"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
 }
 
 func ToFileResponse(from dto.File) FileResponse {
-	return FileResponse{
-		Id:          from.Id,
-		Name:        from.Name,
-		Directory:   from.Directory,
-		Description: from.Description,
-		MimeType:    from.MimeType,
+	variants := make([]FileVariantResponse, 0, len(from.Variants))
+	for _, variant := range from.Variants {
+		variants = append(variants, FileVariantResponse{
+			Id:       variant.Id,
+			Variant:  variant.Variant,
+			MimeType: variant.MimeType,
+			Size:     variant.Size,
+			Width:    variant.Width,
+			Height:   variant.Height,
+		})
+	}
+	response := FileResponse{
+		Id:            from.Id,
+		Version:       from.Version,
+		Name:          from.Name,
+		OriginalName:  from.OriginalName,
+		Directory:     from.Directory,
+		Description:   from.Description,
+		MimeType:      from.MimeType,
+		Size:          from.Size,
+		Checksum:      from.Checksum,
+		ParentId:      from.ParentId,
+		Variant:       from.Variant,
+		VariantStatus: from.VariantStatus,
+		Variants:      variants,
+		Rank:          from.SearchRank,
+		Highlights:    from.SearchHighlights,
+	}
+	if from.DeletedAt.Valid {
+		response.DeletedAt = &from.DeletedAt.Time
 	}
+	return response
 }
 
 func ToCreateFile(from CreateFileRequest) dto.CreateFile {
@@ -0,0 +1,51 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/minisource/template_go/usecase/dto"
+)
+
+type CreateUploadSessionRequest struct {
+	FileName    string `json:"fileName" binding:"required"`
+	Description string `json:"description"`
+	TotalSize   int64  `json:"totalSize" binding:"required"`
+	Checksum    string `json:"checksum"` // Optional hex SHA-256 of the whole file, verified on completion
+}
+
+type UploadSessionResponse struct {
+	UploadId      string    `json:"uploadId"`
+	FileName      string    `json:"fileName"`
+	Description   string    `json:"description"`
+	TotalSize     int64     `json:"totalSize"`
+	ChunkSize     int64     `json:"chunkSize"`
+	TotalParts    int       `json:"totalParts"`
+	ReceivedParts []int     `json:"receivedParts"`
+	Status        string    `json:"status"`
+	ExpiresAt     time.Time `json:"expiresAt"`
+	FileId        *int      `json:"fileId,omitempty"`
+}
+
+func ToUploadSessionResponse(from dto.UploadSession) UploadSessionResponse {
+	return UploadSessionResponse{
+		UploadId:      from.Key,
+		FileName:      from.FileName,
+		Description:   from.Description,
+		TotalSize:     from.TotalSize,
+		ChunkSize:     from.ChunkSize,
+		TotalParts:    from.TotalParts,
+		ReceivedParts: from.ReceivedParts,
+		Status:        from.Status,
+		ExpiresAt:     from.ExpiresAt,
+		FileId:        from.FileId,
+	}
+}
+
+func ToCreateUploadSession(from CreateUploadSessionRequest) dto.CreateUploadSession {
+	return dto.CreateUploadSession{
+		FileName:    from.FileName,
+		Description: from.Description,
+		TotalSize:   from.TotalSize,
+		Checksum:    from.Checksum,
+	}
+}
@@ -1,12 +1,81 @@
 package dto
 
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/minisource/template_go/usecase/dto"
+)
+
 type GetOtpRequest struct {
-	CountryCode string `json:"countryCode"`
-	MobileNumber string `json:"mobileNumber" binding:"required,mobile,min=1`
+	CountryCode  string `json:"countryCode"`
+	MobileNumber string `json:"mobileNumber" binding:"required,mobile,min=1"`
 }
 
 type RegisterLoginByMobileRequest struct {
-	CountryCode string `json:"countryCode"`
+	CountryCode  string `json:"countryCode"`
 	MobileNumber string `json:"mobileNumber" binding:"required,mobile"`
 	Otp          string `json:"otp" binding:"required"`
 }
+
+// UpdateProfileRequest changes only the fields present in the body
+type UpdateProfileRequest struct {
+	DisplayName *string         `json:"displayName"`
+	AvatarId    *int            `json:"avatarId"` // Id of an uploaded image, 0 removes the avatar
+	Locale      *string         `json:"locale"`   // BCP 47 tag, e.g. en-US
+	Timezone    *string         `json:"timezone"` // IANA name, e.g. Europe/Berlin
+	Preferences json.RawMessage `json:"preferences" swaggertype:"object"`
+}
+
+type ProfileResponse struct {
+	Id          int             `json:"id"`
+	Version     int             `json:"version"`
+	UserId      string          `json:"userId"`
+	Username    string          `json:"username"`
+	DisplayName string          `json:"displayName"`
+	Email       string          `json:"email"`
+	Phone       string          `json:"phone"`
+	AvatarId    *int            `json:"avatarId,omitempty"`
+	Avatar      *FileResponse   `json:"avatar,omitempty"`
+	Locale      string          `json:"locale"`
+	Timezone    string          `json:"timezone"`
+	Preferences json.RawMessage `json:"preferences" swaggertype:"object"`
+	SyncedAt    *time.Time      `json:"syncedAt,omitempty"`
+}
+
+func ToUpdateUserProfile(from UpdateProfileRequest) dto.UpdateUserProfile {
+	return dto.UpdateUserProfile{
+		DisplayName: from.DisplayName,
+		AvatarId:    from.AvatarId,
+		Locale:      from.Locale,
+		Timezone:    from.Timezone,
+		Preferences: from.Preferences,
+	}
+}
+
+func ToProfileResponse(from dto.UserProfile) ProfileResponse {
+	response := ProfileResponse{
+		Id:          from.Id,
+		Version:     from.Version,
+		UserId:      from.UserId,
+		Username:    from.Username,
+		DisplayName: from.DisplayName,
+		Email:       from.Email,
+		Phone:       from.Phone,
+		AvatarId:    from.AvatarId,
+		Locale:      from.Locale,
+		Timezone:    from.Timezone,
+		Preferences: from.Preferences,
+	}
+	if from.Avatar != nil {
+		avatar := ToFileResponse(*from.Avatar)
+		response.Avatar = &avatar
+	}
+	if len(response.Preferences) == 0 {
+		response.Preferences = json.RawMessage("{}")
+	}
+	if from.SyncedAt.Valid {
+		response.SyncedAt = &from.SyncedAt.Time
+	}
+	return response
+}
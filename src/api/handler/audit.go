@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/minisource/template_go/api/dto"
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/dependency"
+	"github.com/minisource/template_go/usecase"
+)
+
+type AuditHandler struct {
+	usecase *usecase.AuditUsecase
+}
+
+func NewAuditHandler(cfg *config.Config) *AuditHandler {
+	return &AuditHandler{
+		usecase: usecase.NewAuditUsecase(cfg, dependency.GetAuditLogRepository(cfg)),
+	}
+}
+
+// GetAuditLogs godoc
+// @Summary Get audit log
+// @Description List the changes made through the API, who made them, when, from where and the changed fields, for administrators
+// @Tags Audit
+// @Accept json
+// @produces json
+// @Param Request body filter.PaginationInputWithFilter true "Request, e.g. filter by entity, entityId, action or createdBy"
+// @Param fields query string false "Comma separated fields of dto.AuditLogResponse to return, all by default"
+// @Success 200 {object} helper.BaseHttpResponse{result=filter.PagedList[dto.AuditLogResponse]} "Audit log response"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Failure 403 {object} helper.BaseHttpResponse "Forbidden"
+// @Router /v1/audit/get-by-filter [post]
+// @Security AuthBearer
+func (h *AuditHandler) GetByFilter(c *fiber.Ctx) error {
+	return GetByFilter(c, dto.ToAuditLogResponse, h.usecase.GetByFilter)
+}
+
+// GetAuditLogsByCursor godoc
+// @Summary Get audit log by cursor
+// @Description List the audit log a page at a time with the nextCursor or prevCursor of the previous response, for administrators
+// @Tags Audit
+// @Accept json
+// @produces json
+// @Param Request body keyset.Request true "Request, an empty cursor reads the first page"
+// @Param fields query string false "Comma separated fields of dto.AuditLogResponse to return, all by default"
+// @Success 200 {object} helper.BaseHttpResponse{result=keyset.Page[dto.AuditLogResponse]} "Audit log response"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Failure 403 {object} helper.BaseHttpResponse "Forbidden"
+// @Router /v1/audit/get-by-cursor [post]
+// @Security AuthBearer
+func (h *AuditHandler) GetByCursor(c *fiber.Ctx) error {
+	return GetByCursor(c, dto.ToAuditLogResponse, h.usecase.GetByCursor)
+}
+
+// ExportAuditLogs godoc
+// @Summary Export audit log
+// @Description Download every audit log entry matching the filter as CSV, JSON Lines or XLSX, for administrators
+// @Tags Audit
+// @Accept json
+// @produces text/csv,application/jsonl,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param Request body keyset.Request false "Filter and sort, the cursor and page size are ignored"
+// @Param format query string false "csv (default), jsonl or xlsx"
+// @Param columns query string false "Comma separated fields of dto.AuditLogResponse, all by default"
+// @Success 200 {file} file "The entries, one per row"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Failure 403 {object} helper.BaseHttpResponse "Forbidden"
+// @Router /v1/audit/export [post]
+// @Security AuthBearer
+func (h *AuditHandler) Export(c *fiber.Ctx) error {
+	return Export(c, "audit", dto.ToAuditLogResponse, h.usecase.GetByCursor)
+}
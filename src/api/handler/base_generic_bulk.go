@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/minisource/template_go/api/dto"
+	"github.com/minisource/template_go/pkg/patch"
+	"github.com/minisource/template_go/usecase"
+	"github.com/gofiber/fiber/v2"
+	"github.com/minisource/go-common/http/helper"
+)
+
+// maxBulkItems bounds the transaction a single request can hold open
+const maxBulkItems int = 100
+
+var errBulkSize = fmt.Errorf("a bulk request takes 1 to %d items", maxBulkItems)
+var errBulkMode = errors.New("mode must be " + usecase.BulkAtomic + " or " + usecase.BulkBestEffort)
+
+// Create entities in one transaction
+// TRequest: Http request body of one item
+// TUInput: Usecase method input that mapped from TRequest with TUInput := mapper(TRequest)
+// TUOutput: Usecase function output
+// TResponse: Http response body of one item that mapped from TUOutput with TResponse := mapper(TUOutput)
+// requestMapper: this function map endpoint input to usecase input
+// responseMapper: this function map usecase output to endpoint output
+// usecaseBulkCreate: usecase BulkCreate method
+func BulkCreate[TRequest any, TUInput any, TUOutput any, TResponse any](
+	c *fiber.Ctx,
+	requestMapper func(req TRequest) TUInput,
+	responseMapper func(req TUOutput) TResponse,
+	usecaseBulkCreate func(ctx context.Context, atomic bool, items []TUInput) ([]usecase.BulkResult[TUOutput], error),
+) error {
+	request := new(dto.BulkRequest[TRequest])
+	atomic, err := bindBulk(c, request)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err),
+		)
+	}
+
+	items := make([]TUInput, 0, len(request.Items))
+	for _, item := range request.Items {
+		items = append(items, requestMapper(item))
+	}
+
+	results, err := usecaseBulkCreate(c.Context(), atomic, items)
+	return bulkResponse(c, fiber.StatusCreated, atomic, results, err, responseMapper)
+}
+
+// Patch entities in one transaction, each item is a JSON Merge Patch as accepted by Patch
+// TRequest: Http request body of one patch, every member must match one of its fields
+// TUInput: Use case method input that mapped from TRequest with TUInput := mapper(TRequest)
+// TUOutput: Use case function output
+// TResponse: Http response body of one item that mapped from TUOutput with TResponse := mapper(TUOutput)
+// requestMapper: this function map endpoint input to usecase input, TUInput must keep the field names of TRequest
+// responseMapper: this function map usecase output to endpoint output
+// usecaseBulkPatch: usecase BulkPatch method
+func BulkPatch[TRequest any, TUInput any, TUOutput any, TResponse any](
+	c *fiber.Ctx,
+	requestMapper func(req TRequest) TUInput,
+	responseMapper func(req TUOutput) TResponse,
+	usecaseBulkPatch func(ctx context.Context, atomic bool, items []usecase.BulkPatchItem[TUInput]) ([]usecase.BulkResult[TUOutput], error),
+) error {
+	request := new(dto.BulkRequest[dto.BulkPatchRequest])
+	atomic, err := bindBulk(c, request)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err),
+		)
+	}
+
+	items := make([]usecase.BulkPatchItem[TUInput], 0, len(request.Items))
+	for i, item := range request.Items {
+		decoded, err := patch.Decode[TRequest](item.Patch)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, fmt.Errorf("item %d: %w", i, err)),
+			)
+		}
+		items = append(items, usecase.BulkPatchItem[TUInput]{Id: item.Id, Version: item.Version, Patch: patch.Map(decoded, requestMapper)})
+	}
+
+	results, err := usecaseBulkPatch(c.Context(), atomic, items)
+	return bulkResponse(c, fiber.StatusOK, atomic, results, err, responseMapper)
+}
+
+// Delete entities by id in one transaction, the result of each item is its id
+func BulkDelete(
+	c *fiber.Ctx,
+	usecaseBulkDelete func(ctx context.Context, atomic bool, ids []int) ([]usecase.BulkResult[int], error),
+) error {
+	request := new(dto.BulkRequest[int])
+	atomic, err := bindBulk(c, request)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err),
+		)
+	}
+
+	results, err := usecaseBulkDelete(c.Context(), atomic, request.Items)
+	return bulkResponse(c, fiber.StatusOK, atomic, results, err, func(id int) int { return id })
+}
+
+// bindBulk parses the body and tells whether the batch is atomic
+func bindBulk[T any](c *fiber.Ctx, request *dto.BulkRequest[T]) (bool, error) {
+	if err := c.BodyParser(request); err != nil {
+		return false, err
+	}
+	if len(request.Items) == 0 || len(request.Items) > maxBulkItems {
+		return false, errBulkSize
+	}
+	switch request.Mode {
+	case "", usecase.BulkAtomic:
+		return true, nil
+	case usecase.BulkBestEffort:
+		return false, nil
+	}
+	return false, errBulkMode
+}
+
+// bulkResponse sends the result of every item. A failed atomic batch answers with the status of
+// its first failure, a best-effort batch with failures answers 207 Multi-Status.
+func bulkResponse[TUOutput any, TResponse any](
+	c *fiber.Ctx,
+	status int,
+	atomic bool,
+	results []usecase.BulkResult[TUOutput],
+	err error,
+	responseMapper func(req TUOutput) TResponse,
+) error {
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err),
+		)
+	}
+
+	var failure error
+	response := make([]dto.BulkItemResponse[TResponse], 0, len(results))
+	for i, result := range results {
+		item := dto.BulkItemResponse[TResponse]{Index: i, Success: result.Err == nil}
+		if result.Err == nil {
+			value := responseMapper(result.Value)
+			item.Result = &value
+		} else {
+			item.Error = result.Err.Error()
+			if failure == nil && !errors.Is(result.Err, usecase.ErrBulkRolledBack) {
+				failure = result.Err
+			}
+		}
+		response = append(response, item)
+	}
+
+	if failure == nil {
+		return c.Status(status).JSON(
+			helper.GenerateBaseResponse(response, true, 0),
+		)
+	}
+	status = fiber.StatusMultiStatus
+	if atomic {
+		status = errorStatus(failure)
+	}
+	return c.Status(status).JSON(
+		helper.GenerateBaseResponse(response, false, helper.InternalError),
+	)
+}
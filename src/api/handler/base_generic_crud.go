@@ -6,9 +6,17 @@ package handler
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/pkg/keyset"
+	"github.com/minisource/template_go/pkg/patch"
+	"github.com/minisource/template_go/pkg/projection"
+	"github.com/minisource/template_go/pkg/search"
+	"github.com/minisource/template_go/usecase"
 	"github.com/gofiber/fiber/v2"
 	"github.com/minisource/go-common/filter"
 	"github.com/minisource/go-common/http/helper"
@@ -17,6 +25,9 @@ import (
 
 var logger = logging.NewLogger(&config.GetConfig().Logger)
 
+var errInvalidIfMatch = errors.New("If-Match must be a single strong ETag returned by this API")
+var errPatchMediaType = errors.New("PATCH body must be " + patch.MediaType + " or application/json")
+
 // Create an entity
 // TRequest: Http request body
 // TUInput: Usecase method input that mapped from TRequest with TUInput := mapper(TRequest)
@@ -61,12 +72,12 @@ func Create[TRequest any, TUInput any, TUOutput any, TResponse any](
 // TResponse: Http response body that mapped from TUOutput with TResponse := mapper(TUOutput)
 // requestMapper: this function map endpoint input to usecase input
 // responseMapper: this function map usecase output to endpoint output
-// usecaseUpdate: usecase Update method
+// usecaseUpdate: usecase Update method, receives the version of the If-Match header or 0
 func Update[TRequest any, TUInput any, TUOutput any, TResponse any](
 	c *fiber.Ctx,
 	requestMapper func(req TRequest) TUInput,
 	responseMapper func(req TUOutput) TResponse,
-	usecaseUpdate func(ctx context.Context, id int, req TUInput) (TUOutput, error),
+	usecaseUpdate func(ctx context.Context, id int, version int, req TUInput) (TUOutput, error),
 ) error {
 	// Bind path param
 	id, err := strconv.Atoi(c.Params("id"))
@@ -76,6 +87,14 @@ func Update[TRequest any, TUInput any, TUOutput any, TResponse any](
 		)
 	}
 
+	// Bind the optimistic concurrency precondition
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.GenerateBaseResponseWithError(nil, false, helper.ValidationError, err),
+		)
+	}
+
 	// Bind request body
 	request := new(TRequest)
 	if err := c.BodyParser(request); err != nil {
@@ -88,20 +107,75 @@ func Update[TRequest any, TUInput any, TUOutput any, TResponse any](
 	usecaseInput := requestMapper(*request)
 
 	// Call usecase
-	usecaseResult, err := usecaseUpdate(c.Context(), id, usecaseInput)
+	usecaseResult, err := usecaseUpdate(c.Context(), id, version, usecaseInput)
 	if err != nil {
-		return c.Status(helper.TranslateErrorToStatusCode(err)).JSON(
+		return c.Status(errorStatus(err)).JSON(
 			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err),
 		)
 	}
 
 	// Map and return response
+	setVersionETag(c, usecaseResult, "")
 	response := responseMapper(usecaseResult)
 	return c.Status(fiber.StatusOK).JSON(
 		helper.GenerateBaseResponse(response, true, 0),
 	)
 }
 
+// Patch an entity with a JSON Merge Patch (RFC 7396), absent fields keep their value and null resets them
+// TRequest: Http request body, every member of the patch must match one of its fields
+// TUInput: Use case method input that mapped from TRequest with TUInput := mapper(TRequest)
+// TUOutput: Use case function output
+// TResponse: Http response body that mapped from TUOutput with TResponse := mapper(TUOutput)
+// requestMapper: this function map endpoint input to usecase input, TUInput must keep the field names of TRequest
+// responseMapper: this function map usecase output to endpoint output
+// usecasePatch: usecase Patch method, receives the version of the If-Match header or 0
+func Patch[TRequest any, TUInput any, TUOutput any, TResponse any](
+	c *fiber.Ctx,
+	requestMapper func(req TRequest) TUInput,
+	responseMapper func(req TUOutput) TResponse,
+	usecasePatch func(ctx context.Context, id int, version int, req patch.Patch[TUInput]) (TUOutput, error),
+) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(
+			helper.GenerateBaseResponse(nil, false, helper.ValidationError),
+		)
+	}
+
+	if !c.Is("json") && !strings.HasPrefix(c.Get(fiber.HeaderContentType), patch.MediaType) {
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(
+			helper.GenerateBaseResponseWithError(nil, false, helper.ValidationError, errPatchMediaType),
+		)
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.GenerateBaseResponseWithError(nil, false, helper.ValidationError, err),
+		)
+	}
+
+	request, err := patch.Decode[TRequest](c.Body())
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err),
+		)
+	}
+
+	usecaseResult, err := usecasePatch(c.Context(), id, version, patch.Map(request, requestMapper))
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err),
+		)
+	}
+
+	setVersionETag(c, usecaseResult, "")
+	response := responseMapper(usecaseResult)
+	return c.Status(fiber.StatusOK).JSON(
+		helper.GenerateBaseResponse(response, true, 0),
+	)
+}
 
 func Delete(c *fiber.Ctx, usecaseDelete func(ctx context.Context, id int) error) error {
 	id, err := strconv.Atoi(c.Params("id"))
@@ -123,6 +197,36 @@ func Delete(c *fiber.Ctx, usecaseDelete func(ctx context.Context, id int) error)
 	)
 }
 
+// Restore a soft-deleted entity
+// TUOutput: Usecase function output
+// TResponse: Http response body that mapped from TUOutput with TResponse := mapper(TUOutput)
+// responseMapper: this function map usecase output to endpoint output
+// usecaseRestore: usecase Restore method
+func Restore[TUOutput any, TResponse any](
+	c *fiber.Ctx,
+	responseMapper func(req TUOutput) TResponse,
+	usecaseRestore func(ctx context.Context, id int) (TUOutput, error),
+) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(
+			helper.GenerateBaseResponse(nil, false, helper.ValidationError),
+		)
+	}
+
+	usecaseResult, err := usecaseRestore(c.Context(), id)
+	if err != nil {
+		return c.Status(helper.TranslateErrorToStatusCode(err)).JSON(
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err),
+		)
+	}
+
+	setVersionETag(c, usecaseResult, "")
+	response := responseMapper(usecaseResult)
+	return c.Status(fiber.StatusOK).JSON(
+		helper.GenerateBaseResponse(response, true, 0),
+	)
+}
 
 // Get an entity
 // TUOutput: Usecase function output
@@ -141,14 +245,31 @@ func GetById[TUOutput any, TResponse any](
 		)
 	}
 
-	usecaseResult, err := usecaseGet(c.Context(), id)
+	ctx, err := readContext(c)
 	if err != nil {
 		return c.Status(helper.TranslateErrorToStatusCode(err)).JSON(
+			helper.GenerateBaseResponseWithError(nil, false, helper.ValidationError, err),
+		)
+	}
+	ctx, fields, err := projectContext[TResponse](c, ctx)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.GenerateBaseResponseWithError(nil, false, helper.ValidationError, err),
+		)
+	}
+
+	usecaseResult, err := usecaseGet(ctx, id)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(
 			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err),
 		)
 	}
 
-	response := responseMapper(usecaseResult)
+	tag := projection.Tag(fields, projection.Parse(c.Query("expand")))
+	if etag := setVersionETag(c, usecaseResult, tag); etag != "" && etagMatches(c.Get(fiber.HeaderIfNoneMatch), etag) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	response := project(responseMapper(usecaseResult), fields)
 	return c.Status(fiber.StatusOK).JSON(
 		helper.GenerateBaseResponse(response, true, 0),
 	)
@@ -171,14 +292,27 @@ func GetByFilter[TUOutput any, TResponse any](
 		)
 	}
 
-	usecaseResult, err := usecaseList(c.Context(), *req)
+	ctx, err := readContext(c)
 	if err != nil {
 		return c.Status(helper.TranslateErrorToStatusCode(err)).JSON(
+			helper.GenerateBaseResponseWithError(nil, false, helper.ValidationError, err),
+		)
+	}
+	ctx, fields, err := projectContext[TResponse](c, ctx)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.GenerateBaseResponseWithError(nil, false, helper.ValidationError, err),
+		)
+	}
+
+	usecaseResult, err := usecaseList(ctx, *req)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(
 			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err),
 		)
 	}
 
-	response := filter.PagedList[TResponse]{
+	response := filter.PagedList[interface{}]{
 		PageNumber:      usecaseResult.PageNumber,
 		PageSize:        usecaseResult.PageSize,
 		TotalRows:       usecaseResult.TotalRows,
@@ -187,9 +321,9 @@ func GetByFilter[TUOutput any, TResponse any](
 		HasNextPage:     usecaseResult.HasNextPage,
 	}
 
-	items := []TResponse{}
+	items := []interface{}{}
 	for _, item := range *usecaseResult.Items {
-		items = append(items, responseMapper(item))
+		items = append(items, project(responseMapper(item), fields))
 	}
 	response.Items = &items
 
@@ -197,3 +331,147 @@ func GetByFilter[TUOutput any, TResponse any](
 		helper.GenerateBaseResponse(response, true, 0),
 	)
 }
+
+// Get a page of entities by keyset cursor, without OFFSET and counting only when asked
+// TUOutput: Usecase function output
+// TResponse: Http response body that mapped from TUOutput with TResponse := mapper(TUOutput)
+// responseMapper: this function map usecase output to endpoint output
+// usecaseList: usecase GetByCursor method
+func GetByCursor[TUOutput any, TResponse any](
+	c *fiber.Ctx,
+	responseMapper func(req TUOutput) TResponse,
+	usecaseList func(ctx context.Context, req keyset.Request) (*keyset.Page[TUOutput], error),
+) error {
+	req := new(keyset.Request)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err),
+		)
+	}
+
+	ctx, err := readContext(c)
+	if err != nil {
+		return c.Status(helper.TranslateErrorToStatusCode(err)).JSON(
+			helper.GenerateBaseResponseWithError(nil, false, helper.ValidationError, err),
+		)
+	}
+	ctx, fields, err := projectContext[TResponse](c, ctx)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.GenerateBaseResponseWithError(nil, false, helper.ValidationError, err),
+		)
+	}
+
+	usecaseResult, err := usecaseList(ctx, *req)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err),
+		)
+	}
+
+	page := keyset.Map(usecaseResult, func(item TUOutput) interface{} {
+		return project(responseMapper(item), fields)
+	})
+	return c.Status(fiber.StatusOK).JSON(
+		helper.GenerateBaseResponse(page, true, 0),
+	)
+}
+
+// readContext applies the read options of the query string, ?includeDeleted=true is for administrators
+// and ?q= searches the lists
+func readContext(c *fiber.Ctx) (context.Context, error) {
+	var ctx context.Context = c.Context()
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		ctx = usecase.Search(ctx, q)
+	}
+	if c.QueryBool("includeDeleted") {
+		return usecase.IncludeDeleted(ctx)
+	}
+	return ctx, nil
+}
+
+// projectContext applies ?fields= and ?expand= to the reads of ctx. The fields are checked against the
+// response and returned with the expanded relations to narrow it, nil keeps the whole response.
+func projectContext[TResponse any](c *fiber.Ctx, ctx context.Context) (context.Context, []string, error) {
+	fields := projection.Parse(c.Query("fields"))
+	expand := projection.Parse(c.Query("expand"))
+	if fields != nil {
+		if err := projection.Check[TResponse](fields); err != nil {
+			return ctx, nil, err
+		}
+		// An expanded relation is shown even when its field was not asked for
+		for _, relation := range expand {
+			fields = append(fields, projection.Root(relation))
+		}
+		ctx = usecase.Project(ctx, fields)
+	}
+	if expand != nil {
+		ctx = usecase.Expand(ctx, expand)
+	}
+	return ctx, fields, nil
+}
+
+// project narrows a response to the requested fields, see projectContext
+func project[TResponse any](response TResponse, fields []string) interface{} {
+	if fields == nil {
+		return response
+	}
+	object, err := projection.Trim(response, fields)
+	if err != nil {
+		return response
+	}
+	return object
+}
+
+// versioned is implemented by usecase outputs that carry the row version, see dto.Versioned
+type versioned interface {
+	GetVersion() int
+}
+
+// setVersionETag sends the row version as a strong ETag and returns it, empty for unversioned outputs.
+// A projected read appends the projection.Tag of its fields, e.g. "3-1f2e3d4c".
+func setVersionETag(c *fiber.Ctx, result any, tag string) string {
+	v, ok := result.(versioned)
+	if !ok || v.GetVersion() <= 0 {
+		return ""
+	}
+	value := strconv.Itoa(v.GetVersion())
+	if tag != "" {
+		value += "-" + tag
+	}
+	etag := fmt.Sprintf("%q", value)
+	c.Set(fiber.HeaderETag, etag)
+	return etag
+}
+
+// ifMatchVersion reads the version the client last saw, 0 when If-Match is absent or "*".
+// The projection tag of an ETag is ignored, the version alone guards the update.
+// Weak and multiple ETags cannot guard an update and are rejected.
+func ifMatchVersion(c *fiber.Ctx) (int, error) {
+	header := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	unquoted, err := strconv.Unquote(header)
+	if err != nil {
+		return 0, errInvalidIfMatch
+	}
+	unquoted, _, _ = strings.Cut(unquoted, "-")
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version <= 0 {
+		return 0, errInvalidIfMatch
+	}
+	return version, nil
+}
+
+// errorStatus adds the errors of the generic usecases to the go-common translation
+func errorStatus(err error) int {
+	if errors.Is(err, usecase.ErrVersionConflict) {
+		return fiber.StatusConflict
+	}
+	if errors.Is(err, keyset.ErrInvalidCursor) || errors.Is(err, keyset.ErrInvalidSort) || errors.Is(err, search.ErrNotSearchable) ||
+		errors.Is(err, projection.ErrExpand) {
+		return fiber.StatusBadRequest
+	}
+	return helper.TranslateErrorToStatusCode(err)
+}
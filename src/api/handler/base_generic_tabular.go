@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/minisource/template_go/api/dto"
+	"github.com/minisource/template_go/pkg/keyset"
+	"github.com/minisource/template_go/pkg/tabular"
+	"github.com/minisource/template_go/usecase"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gofiber/fiber/v2"
+	"github.com/minisource/go-common/http/helper"
+	"github.com/minisource/go-common/logging"
+)
+
+// maxImportRows bounds the transaction of an import
+const maxImportRows int = 10000
+
+var errImportSize = fmt.Errorf("an import takes 1 to %d rows", maxImportRows)
+
+// Export the entities matching the filter as a file, every page of them
+// TUOutput: Usecase function output
+// TResponse: Http response body of one row that mapped from TUOutput with TResponse := mapper(TUOutput)
+// name: file name without extension
+// responseMapper: this function map usecase output to endpoint output
+// usecaseList: usecase GetByCursor method, the rows are read a page at a time
+func Export[TUOutput any, TResponse any](
+	c *fiber.Ctx,
+	name string,
+	responseMapper func(req TUOutput) TResponse,
+	usecaseList func(ctx context.Context, req keyset.Request) (*keyset.Page[TUOutput], error),
+) error {
+	format, err := tabular.ParseFormat(c.Query("format", string(tabular.CSV)))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err),
+		)
+	}
+	var requested []string
+	if list := c.Query("columns"); list != "" {
+		requested = strings.Split(list, ",")
+	}
+	columns, err := tabular.Select[TResponse](requested)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err),
+		)
+	}
+
+	req := new(keyset.Request)
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(
+				helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err),
+			)
+		}
+	}
+	req.Cursor, req.PageSize, req.WithTotal = "", keyset.MaxPageSize, false
+
+	ctx, err := readContext(c)
+	if err != nil {
+		return c.Status(helper.TranslateErrorToStatusCode(err)).JSON(
+			helper.GenerateBaseResponseWithError(nil, false, helper.ValidationError, err),
+		)
+	}
+	if requested != nil {
+		ctx = usecase.Project(ctx, columns)
+	}
+
+	// The first page is read before streaming, so a bad filter or sort still gets its status
+	page, err := usecaseList(ctx, *req)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err),
+		)
+	}
+
+	c.Attachment(name + "." + string(format))
+	c.Set(fiber.HeaderContentType, format.ContentType())
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		// The status is sent already, a failure can only cut the file short
+		if err := exportRows(ctx, w, format, columns, *req, page, responseMapper, usecaseList); err != nil {
+			logger.Error(logging.IO, logging.Select, err.Error(), nil)
+		}
+	})
+	return nil
+}
+
+func exportRows[TUOutput any, TResponse any](
+	ctx context.Context,
+	w io.Writer,
+	format tabular.Format,
+	columns []string,
+	req keyset.Request,
+	page *keyset.Page[TUOutput],
+	responseMapper func(req TUOutput) TResponse,
+	usecaseList func(ctx context.Context, req keyset.Request) (*keyset.Page[TUOutput], error),
+) error {
+	writer, err := tabular.NewWriter(format, w, columns)
+	if err != nil {
+		return err
+	}
+	for {
+		for _, item := range *page.Items {
+			if err := writer.Write(tabular.Values(responseMapper(item), columns)); err != nil {
+				return err
+			}
+		}
+		if page.NextCursor == "" {
+			return writer.Close()
+		}
+		req.Cursor = page.NextCursor
+		if page, err = usecaseList(ctx, req); err != nil {
+			return err
+		}
+	}
+}
+
+// Import creates an entity for every row of an uploaded file in one transaction. Every row is
+// checked against TRequest first, a single invalid row fails the import before anything is written.
+// TRequest: Http request body of one row, its binding tags are validated
+// TUInput: Usecase method input that mapped from TRequest with TUInput := mapper(TRequest)
+// TUOutput: Usecase function output
+// TResponse: Http response body of one row that mapped from TUOutput with TResponse := mapper(TUOutput)
+// requestMapper: this function map endpoint input to usecase input
+// responseMapper: this function map usecase output to endpoint output
+// usecaseBulkCreate: usecase BulkCreate method, called in atomic mode
+func Import[TRequest any, TUInput any, TUOutput any, TResponse any](
+	c *fiber.Ctx,
+	requestMapper func(req TRequest) TUInput,
+	responseMapper func(req TUOutput) TResponse,
+	usecaseBulkCreate func(ctx context.Context, atomic bool, items []TUInput) ([]usecase.BulkResult[TUOutput], error),
+) error {
+	items, rowErrors, err := readImport(c, requestMapper)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err),
+		)
+	}
+	if len(rowErrors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.GenerateBaseResponse(rowErrors, false, helper.ValidationError),
+		)
+	}
+
+	results, err := usecaseBulkCreate(c.Context(), true, items)
+	return bulkResponse(c, fiber.StatusCreated, true, results, err, responseMapper)
+}
+
+// readImport parses the uploaded file, a file that cannot be read is an error, invalid rows are listed
+func readImport[TRequest any, TUInput any](c *fiber.Ctx, requestMapper func(req TRequest) TUInput) ([]TUInput, []dto.ImportRowError, error) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		return nil, nil, err
+	}
+	format, err := tabular.FormatOf(header.Filename)
+	if value := c.FormValue("format"); value != "" {
+		format, err = tabular.ParseFormat(value)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	reader, err := tabular.NewReader(format, file)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer reader.Close()
+
+	var items []TUInput
+	var rowErrors []dto.ImportRowError
+	for row := 1; ; row++ {
+		record, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("row %d: %w", row, err)
+		}
+		if row > maxImportRows {
+			return nil, nil, errImportSize
+		}
+
+		request, err := tabular.Decode[TRequest](record)
+		if err == nil {
+			err = binding.Validator.ValidateStruct(&request)
+		}
+		if err != nil {
+			rowErrors = append(rowErrors, dto.ImportRowError{Row: row, Error: err.Error()})
+			continue
+		}
+		items = append(items, requestMapper(request))
+	}
+	if len(items) == 0 && len(rowErrors) == 0 {
+		return nil, nil, errImportSize
+	}
+	return items, rowErrors, nil
+}
@@ -1,30 +1,39 @@
 package handler
 
 import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"io"
-	"mime/multipart"
-	"os"
+	"mime"
+	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/minisource/template_go/api/dto"
+	apihelper "github.com/minisource/template_go/api/helper"
 	"github.com/minisource/template_go/config"
 	"github.com/minisource/template_go/dependency"
 	"github.com/minisource/template_go/usecase"
+	"github.com/minisource/template_go/pkg/upload"
+	usecaseDto "github.com/minisource/template_go/usecase/dto"
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 	"github.com/minisource/go-common/http/helper"
-	"github.com/minisource/go-common/logging"
 )
 
+// FilesUploadRoute is the name of the upload policy applied to POST /files
+const FilesUploadRoute string = usecase.FilesUploadRoute
+
 type FileHandler struct {
 	usecase *usecase.FileUsecase
+	config  *config.Config
 }
 
 func NewFileHandler(cfg *config.Config) *FileHandler {
 	return &FileHandler{
-		usecase: usecase.NewFileUsecase(cfg, dependency.GetFileRepository(cfg)),
+		usecase: usecase.NewFileUsecase(cfg, dependency.GetFileRepository(cfg), dependency.GetUnitOfWork(), dependency.GetStorage()),
+		config:  cfg,
 	}
 }
 
@@ -37,14 +46,17 @@ func NewFileHandler(cfg *config.Config) *FileHandler {
 // @Param file formData dto.UploadFileRequest true "Create a file"
 // @Param file formData file true "Create a file"
 // @Success 201 {object} helper.BaseHttpResponse{result=dto.FileResponse} "File response"
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.FileResponse} "Already uploaded, existing file response"
 // @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Failure 413 {object} helper.BaseHttpResponse "File too large"
+// @Failure 415 {object} helper.BaseHttpResponse "File type not allowed"
 // @Router /v1/files/ [post]
 // @Security AuthBearer
 func (h *FileHandler) Create(c *fiber.Ctx) error {
-	upload := dto.UploadFileRequest{}
+	form := dto.UploadFileRequest{}
 
 	// Fiber does not have ShouldBind; use BodyParser or custom binding
-	if err := c.BodyParser(&upload); err != nil {
+	if err := c.BodyParser(&form); err != nil {
 		resp := helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err)
 		return c.Status(fiber.StatusBadRequest).JSON(resp)
 	}
@@ -56,25 +68,40 @@ func (h *FileHandler) Create(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(resp)
 	}
 
-	req := dto.CreateFileRequest{
-		Description: upload.Description,
-		MimeType:    file.Header.Get("Content-Type"),
-		Directory:   "uploads",
+	policy := h.config.Upload.Policy(FilesUploadRoute)
+	if file.Size > policy.Limit() {
+		resp := helper.GenerateBaseResponseWithError(nil, false, helper.ValidationError, upload.ErrTooLarge)
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(resp)
 	}
 
-	req.Name, err = saveUploadedFile(file, req.Directory)
+	content, err := file.Open()
 	if err != nil {
 		resp := helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err)
-		return c.Status(helper.TranslateErrorToStatusCode(err)).JSON(resp)
+		return c.Status(fiber.StatusInternalServerError).JSON(resp)
 	}
+	defer content.Close()
 
-	res, err := h.usecase.Create(c.Context(), dto.ToCreateFile(req))
+	res, err := h.usecase.Upload(c.Context(), usecaseDto.UploadFile{
+		Content:     content,
+		FileName:    file.Filename,
+		Description: form.Description,
+		Checksum:    form.Checksum,
+		Policy:      policy,
+	})
+	if status, ok := uploadErrorStatus(err); ok {
+		resp := helper.GenerateBaseResponseWithError(nil, false, helper.ValidationError, err)
+		return c.Status(status).JSON(resp)
+	}
 	if err != nil {
 		resp := helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err)
 		return c.Status(helper.TranslateErrorToStatusCode(err)).JSON(resp)
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(helper.GenerateBaseResponse(res, true, helper.Success))
+	status := fiber.StatusCreated
+	if res.Duplicate {
+		status = fiber.StatusOK
+	}
+	return c.Status(status).JSON(helper.GenerateBaseResponse(dto.ToFileResponse(res.File), true, helper.Success))
 }
 
 
@@ -85,19 +112,43 @@ func (h *FileHandler) Create(c *fiber.Ctx) error {
 // @Accept json
 // @produces json
 // @Param id path int true "Id"
+// @Param If-Match header string false "ETag of the version being edited, e.g. \"3\""
 // @Param Request body dto.UpdateFileRequest true "Update a file"
 // @Success 200 {object} helper.BaseHttpResponse{result=dto.FileResponse} "File response"
+// @Header 200 {string} ETag "Version after the update"
 // @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Failure 409 {object} helper.BaseHttpResponse "Modified since the If-Match version"
 // @Router /v1/files/{id} [put]
 // @Security AuthBearer
 func (h *FileHandler) Update(c *fiber.Ctx) error {
 	return Update(c, dto.ToUpdateFile, dto.ToFileResponse, h.usecase.Update)
 }
 
+// PatchFile godoc
+// @Summary Patch a file
+// @Description Change only the fields present in the JSON Merge Patch, null resets a field
+// @Tags Files
+// @Accept application/merge-patch+json
+// @Accept json
+// @produces json
+// @Param id path int true "Id"
+// @Param If-Match header string false "ETag of the version being edited, e.g. \"3\""
+// @Param Request body dto.UpdateFileRequest true "Fields to change"
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.FileResponse} "File response"
+// @Header 200 {string} ETag "Version after the update"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Failure 409 {object} helper.BaseHttpResponse "Modified since the If-Match version"
+// @Failure 415 {object} helper.BaseHttpResponse "Not a JSON body"
+// @Router /v1/files/{id} [patch]
+// @Security AuthBearer
+func (h *FileHandler) Patch(c *fiber.Ctx) error {
+	return Patch(c, dto.ToUpdateFile, dto.ToFileResponse, h.usecase.Patch)
+}
+
 
 // DeleteFile godoc
 // @Summary Delete a file
-// @Description Delete a file
+// @Description Delete a file and its image variants, administrators can restore it until it is purged
 // @Tags Files
 // @Accept json
 // @produces json
@@ -113,20 +164,6 @@ func (h *FileHandler) Delete(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusNotFound).JSON(resp)
 	}
 
-	file, err := h.usecase.GetById(c.Context(), id)
-	if err != nil {
-		logger.Error(logging.IO, logging.RemoveFile, err.Error(), nil)
-		resp := helper.GenerateBaseResponse(nil, false, helper.NotFoundError)
-		return c.Status(fiber.StatusNotFound).JSON(resp)
-	}
-
-	err = os.Remove(fmt.Sprintf("%s/%s", file.Directory, file.Name))
-	if err != nil {
-		logger.Error(logging.IO, logging.RemoveFile, err.Error(), nil)
-		resp := helper.GenerateBaseResponse(nil, false, helper.InternalError)
-		return c.Status(fiber.StatusInternalServerError).JSON(resp)
-	}
-
 	err = h.usecase.Delete(c.Context(), id)
 	if err != nil {
 		resp := helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err)
@@ -136,6 +173,21 @@ func (h *FileHandler) Delete(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(helper.GenerateBaseResponse(nil, true, helper.Success))
 }
 
+// RestoreFile godoc
+// @Summary Restore a file
+// @Description Restore a deleted file and its image variants, for administrators
+// @Tags Files
+// @produces json
+// @Param id path int true "Id"
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.FileResponse} "File response"
+// @Header 200 {string} ETag "Version after the restore"
+// @Failure 403 {object} helper.BaseHttpResponse "Forbidden"
+// @Failure 404 {object} helper.BaseHttpResponse "No deleted file with this id"
+// @Router /v1/files/{id}/restore [post]
+// @Security AuthBearer
+func (h *FileHandler) Restore(c *fiber.Ctx) error {
+	return Restore(c, dto.ToFileResponse, h.usecase.Restore)
+}
 
 // GetFile godoc
 // @Summary Get a file
@@ -144,7 +196,13 @@ func (h *FileHandler) Delete(c *fiber.Ctx) error {
 // @Accept json
 // @produces json
 // @Param id path int true "Id"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Param includeDeleted query bool false "Also find a deleted file, for administrators"
 // @Success 200 {object} helper.BaseHttpResponse{result=dto.FileResponse} "File response"
+// @Header 200 {string} ETag "Version, send it back in If-Match to update"
+// @Param fields query string false "Comma separated fields of dto.FileResponse to return, all by default"
+// @Param expand query string false "Relations to load as well, e.g. variants"
+// @Success 304 "Not modified"
 // @Failure 400 {object} helper.BaseHttpResponse "Bad request"
 // @Router /v1/files/{id} [get]
 // @Security AuthBearer
@@ -152,6 +210,151 @@ func (h *FileHandler) GetById(c *fiber.Ctx) error {
 	return GetById(c, dto.ToFileResponse, h.usecase.GetById)
 }
 
+// GetFileContent godoc
+// @Summary Download a file
+// @Description Stream the content of a file, supports Range and conditional requests
+// @Tags Files
+// @produces octet-stream
+// @Param id path int true "Id"
+// @Param variant query string false "Image variant, e.g. thumbnail"
+// @Param Range header string false "Byte range, e.g. bytes=0-1023"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Param If-Modified-Since header string false "Date of a cached copy"
+// @Param If-Range header string false "ETag or date the Range applies to"
+// @Success 200 {file} file "File content"
+// @Success 206 {file} file "Partial file content"
+// @Success 304 "Not modified"
+// @Failure 403 {object} helper.BaseHttpResponse "Forbidden"
+// @Failure 404 {object} helper.BaseHttpResponse "Not found, or variant not generated yet when Retry-After is set"
+// @Failure 416 "Range not satisfiable"
+// @Router /v1/files/{id}/content [get]
+// @Security AuthBearer
+func (h *FileHandler) Content(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id == 0 {
+		resp := helper.GenerateBaseResponse(nil, false, helper.ValidationError)
+		return c.Status(fiber.StatusNotFound).JSON(resp)
+	}
+
+	content, err := h.usecase.GetContent(c.Context(), id, c.Query("variant"))
+	if errors.Is(err, usecase.ErrVariantNotReady) {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(h.config.Images.Interval().Seconds())+1))
+		resp := helper.GenerateBaseResponseWithError(nil, false, helper.NotFoundError, err)
+		return c.Status(fiber.StatusNotFound).JSON(resp)
+	}
+	if err != nil {
+		resp := helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err)
+		return c.Status(helper.TranslateErrorToStatusCode(err)).JSON(resp)
+	}
+
+	etag := fmt.Sprintf("%q", strings.Trim(content.ETag, `"`))
+	lastModified := content.LastModified.UTC().Truncate(time.Second)
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderLastModified, lastModified.Format(http.TimeFormat))
+	c.Set(fiber.HeaderCacheControl, "private, no-cache")
+	if digest, err := hex.DecodeString(content.Checksum); err == nil && len(digest) > 0 {
+		// RFC 9530 digest of the full representation, lets clients verify integrity
+		c.Set("Repr-Digest", fmt.Sprintf("sha-256=:%s:", base64.StdEncoding.EncodeToString(digest)))
+	}
+
+	if notModified(c, etag, lastModified) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	contentType := content.MimeType
+	if contentType == "" {
+		contentType = fiber.MIMEOctetStream
+	}
+	filename := content.OriginalName
+	if filename == "" {
+		filename = content.Name
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	c.Set(fiber.HeaderAcceptRanges, "bytes")
+
+	status := fiber.StatusOK
+	byteRange := apihelper.ByteRange{Start: 0, End: content.Size - 1}
+	if rangeHeader := c.Get(fiber.HeaderRange); rangeHeader != "" && rangeApplies(c, etag, lastModified) {
+		requested, ok, err := apihelper.ParseRange(rangeHeader, content.Size)
+		if errors.Is(err, apihelper.ErrRangeUnsatisfiable) {
+			c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes */%d", content.Size))
+			return c.SendStatus(fiber.StatusRequestedRangeNotSatisfiable)
+		}
+		if ok {
+			status = fiber.StatusPartialContent
+			byteRange = requested
+			c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes %d-%d/%d", byteRange.Start, byteRange.End, content.Size))
+		}
+	}
+
+	c.Status(status)
+	if c.Method() == fiber.MethodHead || content.Size == 0 {
+		c.Response().Header.SetContentLength(int(byteRange.Length()))
+		return nil
+	}
+
+	reader, err := h.usecase.OpenContent(c.Context(), content.File, byteRange.Start, byteRange.Length())
+	if err != nil {
+		c.Response().Header.Del(fiber.HeaderContentRange)
+		c.Response().Header.Del(fiber.HeaderContentDisposition)
+		resp := helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(resp)
+	}
+	// fasthttp closes the reader once the body has been written
+	return c.SendStream(reader, int(byteRange.Length()))
+}
+
+// BulkCreateFiles godoc
+// @Summary Register files in bulk
+// @Description Register files whose content is already in storage, for import tools. Atomic batches are rolled back when an item fails, bestEffort batches keep the items that succeeded.
+// @Tags Files
+// @Accept json
+// @produces json
+// @Param Request body dto.BulkRequest[dto.CreateFileRequest] true "Up to 100 files"
+// @Success 201 {object} helper.BaseHttpResponse{result=[]dto.BulkItemResponse[dto.FileResponse]} "Result of every item"
+// @Success 207 {object} helper.BaseHttpResponse{result=[]dto.BulkItemResponse[dto.FileResponse]} "Some items of a bestEffort batch failed"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Failure 403 {object} helper.BaseHttpResponse "Forbidden"
+// @Router /v1/files/bulk [post]
+// @Security AuthBearer
+func (h *FileHandler) BulkCreate(c *fiber.Ctx) error {
+	return BulkCreate(c, dto.ToCreateFile, dto.ToFileResponse, h.usecase.BulkCreate)
+}
+
+// BulkPatchFiles godoc
+// @Summary Patch files in bulk
+// @Description Apply a JSON Merge Patch to each file in one transaction, see the modes of POST /bulk
+// @Tags Files
+// @Accept json
+// @produces json
+// @Param Request body dto.BulkRequest[dto.BulkPatchRequest] true "Up to 100 patches of dto.UpdateFileRequest"
+// @Success 200 {object} helper.BaseHttpResponse{result=[]dto.BulkItemResponse[dto.FileResponse]} "Result of every item"
+// @Success 207 {object} helper.BaseHttpResponse{result=[]dto.BulkItemResponse[dto.FileResponse]} "Some items of a bestEffort batch failed"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Failure 409 {object} helper.BaseHttpResponse "A file of an atomic batch was modified since its version"
+// @Router /v1/files/bulk [patch]
+// @Security AuthBearer
+func (h *FileHandler) BulkPatch(c *fiber.Ctx) error {
+	return BulkPatch(c, dto.ToUpdateFile, dto.ToFileResponse, h.usecase.BulkPatch)
+}
+
+// BulkDeleteFiles godoc
+// @Summary Delete files in bulk
+// @Description Delete each file with its variants in one transaction, see the modes of POST /bulk
+// @Tags Files
+// @Accept json
+// @produces json
+// @Param Request body dto.BulkRequest[int] true "Up to 100 ids"
+// @Success 200 {object} helper.BaseHttpResponse{result=[]dto.BulkItemResponse[int]} "Result of every item"
+// @Success 207 {object} helper.BaseHttpResponse{result=[]dto.BulkItemResponse[int]} "Some items of a bestEffort batch failed"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Router /v1/files/bulk [delete]
+// @Security AuthBearer
+func (h *FileHandler) BulkDelete(c *fiber.Ctx) error {
+	return BulkDelete(c, h.usecase.BulkDelete)
+}
+
 // GetFiles godoc
 // @Summary Get Files
 // @Description Get Files
@@ -159,6 +362,10 @@ func (h *FileHandler) GetById(c *fiber.Ctx) error {
 // @Accept json
 // @produces json
 // @Param Request body filter.PaginationInputWithFilter true "Request"
+// @Param includeDeleted query bool false "Also list deleted files, for administrators"
+// @Param q query string false "Full-text search of the name and description, best matches first with highlights"
+// @Param fields query string false "Comma separated fields of dto.FileResponse to return, all by default"
+// @Param expand query string false "Relations to load as well, e.g. variants"
 // @Success 200 {object} helper.BaseHttpResponse{result=filter.PagedList[dto.FileResponse]} "File response"
 // @Failure 400 {object} helper.BaseHttpResponse "Bad request"
 // @Router /v1/files/get-by-filter [post]
@@ -167,34 +374,104 @@ func (h *FileHandler) GetByFilter(c *fiber.Ctx) error {
 	return GetByFilter(c, dto.ToFileResponse, h.usecase.GetByFilter)
 }
 
-func saveUploadedFile(file *multipart.FileHeader, directory string) (string, error) {
-	// test.txt -> 95239855629856.txt
-	randFileName := uuid.New()
-	err := os.MkdirAll(directory, os.ModePerm)
-	if err != nil {
-		return "", err
+// GetFilesByCursor godoc
+// @Summary Get Files by cursor
+// @Description Get Files a page at a time with the nextCursor or prevCursor of the previous response, sorting only by columns that are never null
+// @Tags Files
+// @Accept json
+// @produces json
+// @Param Request body keyset.Request true "Request, an empty cursor reads the first page"
+// @Param includeDeleted query bool false "Also list deleted files, for administrators"
+// @Param q query string false "Full-text search of the name and description, keeps the sort of the request"
+// @Param fields query string false "Comma separated fields of dto.FileResponse to return, all by default"
+// @Param expand query string false "Relations to load as well, e.g. variants"
+// @Success 200 {object} helper.BaseHttpResponse{result=keyset.Page[dto.FileResponse]} "File response"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Router /v1/files/get-by-cursor [post]
+// @Security AuthBearer
+func (h *FileHandler) GetByCursor(c *fiber.Ctx) error {
+	return GetByCursor(c, dto.ToFileResponse, h.usecase.GetByCursor)
+}
+
+// ExportFiles godoc
+// @Summary Export Files
+// @Description Download every file matching the filter, not just a page, as CSV, JSON Lines or XLSX
+// @Tags Files
+// @Accept json
+// @produces text/csv,application/jsonl,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param Request body keyset.Request false "Filter and sort, the cursor and page size are ignored"
+// @Param format query string false "csv (default), jsonl or xlsx"
+// @Param columns query string false "Comma separated fields of dto.FileResponse, all by default"
+// @Param includeDeleted query bool false "Also export deleted files, for administrators"
+// @Param q query string false "Full-text search of the name and description"
+// @Success 200 {file} file "The files, one per row"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Router /v1/files/export [post]
+// @Security AuthBearer
+func (h *FileHandler) Export(c *fiber.Ctx) error {
+	return Export(c, "files", dto.ToFileResponse, h.usecase.GetByCursor)
+}
+
+// ImportFiles godoc
+// @Summary Import Files
+// @Description Register a file for every row of a CSV, JSON Lines or XLSX file with the columns of dto.CreateFileRequest. Every row is validated first, nothing is registered unless all of them are valid.
+// @Tags Files
+// @Accept multipart/form-data
+// @produces json
+// @Param file formData file true "Up to 10000 rows"
+// @Param format formData string false "csv, jsonl or xlsx, by default the extension of the file"
+// @Success 201 {object} helper.BaseHttpResponse{result=[]dto.BulkItemResponse[dto.FileResponse]} "Result of every row"
+// @Failure 400 {object} helper.BaseHttpResponse{result=[]dto.ImportRowError} "The invalid rows"
+// @Failure 403 {object} helper.BaseHttpResponse "Forbidden"
+// @Router /v1/files/import [post]
+// @Security AuthBearer
+func (h *FileHandler) Import(c *fiber.Ctx) error {
+	return Import(c, dto.ToCreateFile, dto.ToFileResponse, h.usecase.BulkCreate)
+}
+
+// notModified evaluates If-None-Match and If-Modified-Since, the former takes precedence
+func notModified(c *fiber.Ctx, etag string, lastModified time.Time) bool {
+	if match := c.Get(fiber.HeaderIfNoneMatch); match != "" {
+		return etagMatches(match, etag)
+	}
+	if since, err := http.ParseTime(c.Get(fiber.HeaderIfModifiedSince)); err == nil {
+		return !lastModified.After(since)
 	}
-	fileName := file.Filename
-	fileNameArr := strings.Split(fileName, ".")
-	fileExt := fileNameArr[len(fileNameArr)-1]
-	fileName = fmt.Sprintf("%s.%s", randFileName, fileExt)
-	dst := fmt.Sprintf("%s/%s", directory, fileName)
+	return false
+}
 
-	src, err := file.Open()
-	if err != nil {
-		return "", err
+// rangeApplies evaluates If-Range, a stale validator means the full content must be sent
+func rangeApplies(c *fiber.Ctx, etag string, lastModified time.Time) bool {
+	ifRange := c.Get(fiber.HeaderIfRange)
+	if ifRange == "" {
+		return true
+	}
+	if date, err := http.ParseTime(ifRange); err == nil {
+		return lastModified.Equal(date)
 	}
-	defer src.Close()
+	return ifRange == etag
+}
 
-	out, err := os.Create(dst)
-	if err != nil {
-		return "", err
+func etagMatches(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
 	}
-	defer out.Close()
+	return false
+}
 
-	_, err = io.Copy(out, src)
-	if err != nil {
-		return "", err
+func uploadErrorStatus(err error) (int, bool) {
+	switch {
+	case err == nil:
+		return 0, false
+	case errors.Is(err, upload.ErrTooLarge):
+		return fiber.StatusRequestEntityTooLarge, true
+	case errors.Is(err, upload.ErrTypeNotAllowed), errors.Is(err, upload.ErrExtensionNotAllowed):
+		return fiber.StatusUnsupportedMediaType, true
+	case errors.Is(err, upload.ErrEmpty), errors.Is(err, upload.ErrChecksumMismatch):
+		return fiber.StatusBadRequest, true
 	}
-	return fileName, nil
+	return 0, false
 }
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/minisource/go-common/http/helper"
+	"github.com/minisource/template_go/api/dto"
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/dependency"
+	"github.com/minisource/template_go/usecase"
+)
+
+type ProfileHandler struct {
+	usecase *usecase.ProfileUsecase
+}
+
+func NewProfileHandler(cfg *config.Config) *ProfileHandler {
+	return &ProfileHandler{
+		usecase: usecase.NewProfileUsecase(cfg, dependency.GetUserRepository(cfg), dependency.GetUnitOfWork(), dependency.GetFileRepository(cfg)),
+	}
+}
+
+// GetMe godoc
+// @Summary Get my profile
+// @Description Get the profile of the authenticated user
+// @Tags Users
+// @produces json
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.ProfileResponse} "Profile response"
+// @Failure 401 {object} helper.BaseHttpResponse "Unauthorized"
+// @Router /v1/users/me [get]
+// @Security AuthBearer
+func (h *ProfileHandler) GetMe(c *fiber.Ctx) error {
+	profile, err := h.usecase.GetMe(c.Context())
+	if err != nil {
+		resp := helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err)
+		return c.Status(helper.TranslateErrorToStatusCode(err)).JSON(resp)
+	}
+	return c.Status(fiber.StatusOK).JSON(helper.GenerateBaseResponse(dto.ToProfileResponse(profile), true, helper.Success))
+}
+
+// UpdateMe godoc
+// @Summary Update my profile
+// @Description Change the fields present in the body, preferences are replaced as a whole
+// @Tags Users
+// @Accept json
+// @produces json
+// @Param Request body dto.UpdateProfileRequest true "Profile fields"
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.ProfileResponse} "Profile response"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Failure 401 {object} helper.BaseHttpResponse "Unauthorized"
+// @Router /v1/users/me [patch]
+// @Security AuthBearer
+func (h *ProfileHandler) UpdateMe(c *fiber.Ctx) error {
+	req := dto.UpdateProfileRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		resp := helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err)
+		return c.Status(fiber.StatusBadRequest).JSON(resp)
+	}
+
+	profile, err := h.usecase.UpdateMe(c.Context(), dto.ToUpdateUserProfile(req))
+	if profileInvalid(err) {
+		resp := helper.GenerateBaseResponseWithError(nil, false, helper.ValidationError, err)
+		return c.Status(fiber.StatusBadRequest).JSON(resp)
+	}
+	if err != nil {
+		resp := helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err)
+		return c.Status(helper.TranslateErrorToStatusCode(err)).JSON(resp)
+	}
+	return c.Status(fiber.StatusOK).JSON(helper.GenerateBaseResponse(dto.ToProfileResponse(profile), true, helper.Success))
+}
+
+// GetUsers godoc
+// @Summary Get Users
+// @Description List user profiles, for administrators
+// @Tags Users
+// @Accept json
+// @produces json
+// @Param Request body filter.PaginationInputWithFilter true "Request"
+// @Param fields query string false "Comma separated fields of dto.ProfileResponse to return, all by default"
+// @Param expand query string false "Relations to load as well, e.g. Avatar.Variants"
+// @Success 200 {object} helper.BaseHttpResponse{result=filter.PagedList[dto.ProfileResponse]} "Profile response"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Failure 403 {object} helper.BaseHttpResponse "Forbidden"
+// @Router /v1/users/get-by-filter [post]
+// @Security AuthBearer
+func (h *ProfileHandler) GetByFilter(c *fiber.Ctx) error {
+	return GetByFilter(c, dto.ToProfileResponse, h.usecase.GetByFilter)
+}
+
+func profileInvalid(err error) bool {
+	return errors.Is(err, usecase.ErrInvalidDisplayName) ||
+		errors.Is(err, usecase.ErrInvalidLocale) ||
+		errors.Is(err, usecase.ErrInvalidTimezone) ||
+		errors.Is(err, usecase.ErrInvalidPreferences) ||
+		errors.Is(err, usecase.ErrInvalidAvatar)
+}
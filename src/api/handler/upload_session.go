@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/minisource/go-common/http/helper"
+	"github.com/minisource/template_go/api/dto"
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/dependency"
+	"github.com/minisource/template_go/usecase"
+	usecaseDto "github.com/minisource/template_go/usecase/dto"
+)
+
+type UploadSessionHandler struct {
+	usecase *usecase.UploadSessionUsecase
+}
+
+func NewUploadSessionHandler(cfg *config.Config) *UploadSessionHandler {
+	files := usecase.NewFileUsecase(cfg, dependency.GetFileRepository(cfg), dependency.GetUnitOfWork(), dependency.GetStorage())
+	return &UploadSessionHandler{
+		usecase: usecase.NewUploadSessionUsecase(cfg, dependency.GetUploadSessionRepository(cfg), files, dependency.GetStorage()),
+	}
+}
+
+// CreateUploadSession godoc
+// @Summary Start a resumable upload
+// @Description Open an upload session, the file is then sent in parts of chunkSize bytes
+// @Tags Files
+// @Accept json
+// @produces json
+// @Param Request body dto.CreateUploadSessionRequest true "Start a resumable upload"
+// @Success 201 {object} helper.BaseHttpResponse{result=dto.UploadSessionResponse} "Upload session response"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Failure 413 {object} helper.BaseHttpResponse "File too large"
+// @Failure 415 {object} helper.BaseHttpResponse "File type not allowed"
+// @Router /v1/files/uploads [post]
+// @Security AuthBearer
+func (h *UploadSessionHandler) Create(c *fiber.Ctx) error {
+	req := dto.CreateUploadSessionRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		resp := helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err)
+		return c.Status(fiber.StatusBadRequest).JSON(resp)
+	}
+
+	session, err := h.usecase.Create(c.Context(), dto.ToCreateUploadSession(req))
+	if err != nil {
+		return uploadSessionError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(helper.GenerateBaseResponse(dto.ToUploadSessionResponse(session), true, helper.Success))
+}
+
+// GetUploadSession godoc
+// @Summary Get a resumable upload
+// @Description Get the state of an upload session, resume by sending the parts missing from receivedParts
+// @Tags Files
+// @produces json
+// @Param uploadId path string true "Upload id"
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.UploadSessionResponse} "Upload session response"
+// @Failure 404 {object} helper.BaseHttpResponse "Not found"
+// @Router /v1/files/uploads/{uploadId} [get]
+// @Security AuthBearer
+func (h *UploadSessionHandler) GetById(c *fiber.Ctx) error {
+	session, err := h.usecase.Get(c.Context(), c.Params("uploadId"))
+	if err != nil {
+		return uploadSessionError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(helper.GenerateBaseResponse(dto.ToUploadSessionResponse(session), true, helper.Success))
+}
+
+// PutUploadPart godoc
+// @Summary Upload a part
+// @Description Send one part of a resumable upload as the raw request body, sending a part again replaces it
+// @Tags Files
+// @Accept octet-stream
+// @produces json
+// @Param uploadId path string true "Upload id"
+// @Param number path int true "Part number, starting at 1"
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.UploadSessionResponse} "Upload session response"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Failure 404 {object} helper.BaseHttpResponse "Not found"
+// @Failure 409 {object} helper.BaseHttpResponse "Upload session closed"
+// @Router /v1/files/uploads/{uploadId}/parts/{number} [put]
+// @Security AuthBearer
+func (h *UploadSessionHandler) PutPart(c *fiber.Ctx) error {
+	number, err := strconv.Atoi(c.Params("number"))
+	if err != nil {
+		resp := helper.GenerateBaseResponseWithError(nil, false, helper.ValidationError, usecase.ErrInvalidPartNumber)
+		return c.Status(fiber.StatusBadRequest).JSON(resp)
+	}
+
+	body := c.Body()
+	session, err := h.usecase.PutPart(c.Context(), usecaseDto.UploadPart{
+		Key:     c.Params("uploadId"),
+		Number:  number,
+		Size:    int64(len(body)),
+		Content: bytes.NewReader(body),
+	})
+	if err != nil {
+		return uploadSessionError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(helper.GenerateBaseResponse(dto.ToUploadSessionResponse(session), true, helper.Success))
+}
+
+// CompleteUploadSession godoc
+// @Summary Complete a resumable upload
+// @Description Assemble the parts, validate the file against the upload policy and register it
+// @Tags Files
+// @produces json
+// @Param uploadId path string true "Upload id"
+// @Success 201 {object} helper.BaseHttpResponse{result=dto.FileResponse} "File response"
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.FileResponse} "Already uploaded, existing file response"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Failure 409 {object} helper.BaseHttpResponse "Missing parts or upload session closed"
+// @Failure 415 {object} helper.BaseHttpResponse "File type not allowed"
+// @Router /v1/files/uploads/{uploadId}/complete [post]
+// @Security AuthBearer
+func (h *UploadSessionHandler) Complete(c *fiber.Ctx) error {
+	res, err := h.usecase.Complete(c.Context(), c.Params("uploadId"))
+	if err != nil {
+		return uploadSessionError(c, err)
+	}
+
+	status := fiber.StatusCreated
+	if res.Duplicate {
+		status = fiber.StatusOK
+	}
+	return c.Status(status).JSON(helper.GenerateBaseResponse(dto.ToFileResponse(res.File), true, helper.Success))
+}
+
+// AbortUploadSession godoc
+// @Summary Abort a resumable upload
+// @Description Discard an upload session and the parts received so far
+// @Tags Files
+// @produces json
+// @Param uploadId path string true "Upload id"
+// @Success 200 {object} helper.BaseHttpResponse "response"
+// @Failure 404 {object} helper.BaseHttpResponse "Not found"
+// @Failure 409 {object} helper.BaseHttpResponse "Upload session closed"
+// @Router /v1/files/uploads/{uploadId} [delete]
+// @Security AuthBearer
+func (h *UploadSessionHandler) Delete(c *fiber.Ctx) error {
+	if err := h.usecase.Abort(c.Context(), c.Params("uploadId")); err != nil {
+		return uploadSessionError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(helper.GenerateBaseResponse(nil, true, helper.Success))
+}
+
+func uploadSessionError(c *fiber.Ctx, err error) error {
+	if status, ok := uploadErrorStatus(err); ok {
+		resp := helper.GenerateBaseResponseWithError(nil, false, helper.ValidationError, err)
+		return c.Status(status).JSON(resp)
+	}
+	switch {
+	case errors.Is(err, usecase.ErrInvalidPartNumber), errors.Is(err, usecase.ErrInvalidPartSize):
+		resp := helper.GenerateBaseResponseWithError(nil, false, helper.ValidationError, err)
+		return c.Status(fiber.StatusBadRequest).JSON(resp)
+	case errors.Is(err, usecase.ErrUploadIncomplete), errors.Is(err, usecase.ErrUploadSessionClosed):
+		resp := helper.GenerateBaseResponseWithError(nil, false, helper.ValidationError, err)
+		return c.Status(fiber.StatusConflict).JSON(resp)
+	}
+	resp := helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err)
+	return c.Status(helper.TranslateErrorToStatusCode(err)).JSON(resp)
+}
@@ -1,6 +1,10 @@
 package handler
 
 import (
+	"errors"
+	"math"
+	"strconv"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/minisource/go-common/http/helper"
 	"github.com/minisource/template_go/api/dto"
@@ -11,13 +15,15 @@ import (
 )
 
 type UsersHandler struct {
-	userUsecase *usecase.UserUsecase
-	config      *config.Config
+	userUsecase  *usecase.UserUsecase
+	tokenUsecase *usecase.TokenUsecase
+	config       *config.Config
 }
 
 func NewUserHandler(cfg *config.Config) *UsersHandler {
-	userUsecase := usecase.NewUserUsecase(cfg, dependency.GetUserRepository(cfg))
-	return &UsersHandler{userUsecase: userUsecase, config: cfg}
+	userUsecase := usecase.NewUserUsecase(cfg, dependency.GetUserRepository(cfg), dependency.GetUnitOfWork(), dependency.GetRateLimitStore())
+	tokenUsecase := usecase.NewTokenUsecase(cfg, dependency.GetRevokedTokenRepository(cfg))
+	return &UsersHandler{userUsecase: userUsecase, tokenUsecase: tokenUsecase, config: cfg}
 }
 
 // SendOtp godoc
@@ -30,6 +36,7 @@ func NewUserHandler(cfg *config.Config) *UsersHandler {
 // @Success 201 {object} helper.BaseHttpResponse "Success"
 // @Failure 400 {object} helper.BaseHttpResponse "Failed"
 // @Failure 409 {object} helper.BaseHttpResponse "Failed"
+// @Failure 429 {object} helper.BaseHttpResponse "Too many requests"
 // @Router /v1/users/send-otp [post]
 func (h *UsersHandler) SendOtp(c *fiber.Ctx) error {
 	req := new(dto.GetOtpRequest)
@@ -39,7 +46,10 @@ func (h *UsersHandler) SendOtp(c *fiber.Ctx) error {
 		)
 	}
 
-	if err := h.userUsecase.SendOtpByMobileNumber(req.CountryCode, req.MobileNumber); err != nil {
+	if err := h.userUsecase.SendOtpByMobileNumber(c.Context(), req.CountryCode, req.MobileNumber, c.IP()); err != nil {
+		if limitErr, ok := otpLimitError(err); ok {
+			return tooManyRequests(c, limitErr)
+		}
 		return c.Status(helper.TranslateErrorToStatusCode(err)).JSON(
 			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err),
 		)
@@ -60,6 +70,7 @@ func (h *UsersHandler) SendOtp(c *fiber.Ctx) error {
 // @Success 201 {object} helper.BaseHttpResponse "Success"
 // @Failure 400 {object} helper.BaseHttpResponse "Failed"
 // @Failure 409 {object} helper.BaseHttpResponse "Failed"
+// @Failure 429 {object} helper.BaseHttpResponse "Phone number locked after too many wrong codes"
 // @Router /v1/users/login-by-mobile [post]
 func (h *UsersHandler) RegisterLoginByMobileNumber(c *fiber.Ctx) error {
 	req := new(dto.RegisterLoginByMobileRequest)
@@ -71,28 +82,103 @@ func (h *UsersHandler) RegisterLoginByMobileNumber(c *fiber.Ctx) error {
 
 	token, err := h.userUsecase.RegisterAndLoginByMobileNumber(c.Context(), req.CountryCode, req.MobileNumber, req.Otp)
 	if err != nil {
+		if limitErr, ok := otpLimitError(err); ok {
+			return tooManyRequests(c, limitErr)
+		}
 		return c.Status(helper.TranslateErrorToStatusCode(err)).JSON(
 			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err),
 		)
 	}
 
 	// Set the refresh token in a cookie
+	h.setRefreshCookie(c, token.RefreshToken)
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.GenerateBaseResponse(token, true, helper.Success),
+	)
+}
+
+// RefreshToken godoc
+// @Summary RefreshToken
+// @Description Exchange the refresh token cookie for a new token pair, the used refresh token is revoked
+// @Tags Users
+// @Produce  json
+// @Success 200 {object} helper.BaseHttpResponse "Success"
+// @Failure 401 {object} helper.BaseHttpResponse "Failed"
+// @Router /v1/users/refresh-token [post]
+func (h *UsersHandler) RefreshToken(c *fiber.Ctx) error {
+	token, err := h.tokenUsecase.Refresh(c.Context(), c.Cookies(constant.RefreshTokenCookieName))
+	if err != nil {
+		h.clearRefreshCookie(c)
+		return c.Status(helper.TranslateErrorToStatusCode(err)).JSON(
+			helper.GenerateBaseResponseWithError(nil, false, helper.AuthError, err),
+		)
+	}
+
+	h.setRefreshCookie(c, token.RefreshToken)
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.GenerateBaseResponse(token, true, helper.Success),
+	)
+}
+
+// Logout godoc
+// @Summary Logout
+// @Description Revoke the refresh token cookie and clear it
+// @Tags Users
+// @Produce  json
+// @Success 200 {object} helper.BaseHttpResponse "Success"
+// @Router /v1/users/logout [post]
+func (h *UsersHandler) Logout(c *fiber.Ctx) error {
+	err := h.tokenUsecase.Logout(c.Context(), c.Cookies(constant.RefreshTokenCookieName))
+	if err != nil {
+		return c.Status(helper.TranslateErrorToStatusCode(err)).JSON(
+			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err),
+		)
+	}
+
+	h.clearRefreshCookie(c)
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.GenerateBaseResponse(nil, true, helper.Success),
+	)
+}
+
+func otpLimitError(err error) (*usecase.OtpLimitError, bool) {
+	var limitErr *usecase.OtpLimitError
+	ok := errors.As(err, &limitErr)
+	return limitErr, ok
+}
+
+func tooManyRequests(c *fiber.Ctx, err *usecase.OtpLimitError) error {
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(err.RetryAfter.Seconds()))))
+	return c.Status(fiber.StatusTooManyRequests).JSON(
+		helper.GenerateBaseResponseWithError(nil, false, helper.OtpLimiterError, err),
+	)
+}
+
+func (h *UsersHandler) setRefreshCookie(c *fiber.Ctx, refreshToken string) {
 	maxAge := h.config.Server.RefreshCookieMaxAgeSecs
 	if maxAge == 0 {
 		maxAge = 604800 // Default: 7 days in seconds
 	}
-	c.Cookie(&fiber.Cookie{
+	c.Cookie(h.refreshCookie(refreshToken, maxAge))
+}
+
+// clearRefreshCookie expires the cookie, its attributes must match the ones it was set with
+func (h *UsersHandler) clearRefreshCookie(c *fiber.Ctx) {
+	c.Cookie(h.refreshCookie("", -1))
+}
+
+func (h *UsersHandler) refreshCookie(value string, maxAge int) *fiber.Cookie {
+	return &fiber.Cookie{
 		Name:     constant.RefreshTokenCookieName,
-		Value:    token.RefreshToken,
+		Value:    value,
 		MaxAge:   maxAge,
 		Path:     "/",
 		Domain:   h.config.Server.Domain,
 		Secure:   true,
 		HTTPOnly: true,
 		SameSite: "Strict",
-	})
-
-	return c.Status(fiber.StatusOK).JSON(
-		helper.GenerateBaseResponse(token, true, helper.Success),
-	)
+	}
 }
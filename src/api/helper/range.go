@@ -0,0 +1,66 @@
+package helper
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var ErrRangeUnsatisfiable = errors.New("range not satisfiable")
+
+// ByteRange is an inclusive byte range of a resource
+type ByteRange struct {
+	Start int64
+	End   int64
+}
+
+func (r ByteRange) Length() int64 {
+	return r.End - r.Start + 1
+}
+
+// ParseRange parses a single range Range header (RFC 9110 section 14.2) for a resource of size bytes.
+// It returns false when the header is absent, malformed or asks for several ranges,
+// in which case the whole resource should be served.
+func ParseRange(header string, size int64) (ByteRange, bool, error) {
+	spec, found := strings.CutPrefix(strings.TrimSpace(header), "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return ByteRange{}, false, nil
+	}
+	startStr, endStr, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return ByteRange{}, false, nil
+	}
+
+	var r ByteRange
+	if startStr == "" {
+		// Suffix range: the last n bytes
+		n, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil || n < 0 {
+			return ByteRange{}, false, nil
+		}
+		if n == 0 || size == 0 {
+			return ByteRange{}, true, ErrRangeUnsatisfiable
+		}
+		r.Start = max(size-n, 0)
+		r.End = size - 1
+		return r, true, nil
+	}
+
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil || start < 0 {
+		return ByteRange{}, false, nil
+	}
+	end := size - 1
+	if endStr != "" {
+		end, err = strconv.ParseInt(endStr, 10, 64)
+		if err != nil || end < start {
+			return ByteRange{}, false, nil
+		}
+	}
+	if start >= size {
+		return ByteRange{}, true, ErrRangeUnsatisfiable
+	}
+	r.Start = start
+	r.End = min(end, size-1)
+	return r, true, nil
+}
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/minisource/go-common/http/helper"
+	"github.com/minisource/go-common/logging"
+	"github.com/minisource/go-common/service_errors"
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/constant"
+	"github.com/minisource/template_go/dependency"
+	"github.com/minisource/template_go/domain/principal"
+	"github.com/minisource/template_go/pkg/token"
+	"github.com/minisource/template_go/usecase"
+)
+
+const jwksPath string = "/.well-known/jwks"
+
+// Authentication verifies the bearer access token and stores the typed claims
+// and the caller's principal, including the resolved tenant, in the request locals
+func Authentication(cfg *config.Config) fiber.Handler {
+	verifier, err := NewVerifier(cfg)
+	if err != nil {
+		logging.NewLogger(&cfg.Logger).Fatal(logging.General, logging.Startup, err.Error(), nil)
+	}
+	userUsecase := usecase.NewUserUsecase(cfg, dependency.GetUserRepository(cfg), dependency.GetUnitOfWork(), dependency.GetRateLimitStore())
+
+	return func(c *fiber.Ctx) error {
+		header := c.Get(constant.AuthorizationHeaderKey)
+		scheme, raw, found := strings.Cut(header, " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") || raw == "" {
+			return unauthorized(c, service_errors.TokenRequired)
+		}
+
+		claims, err := verifier.Verify(c.Context(), raw)
+		if err != nil {
+			return unauthorized(c, service_errors.TokenInvalid)
+		}
+		if claims.IsForbidden || claims.IsDeleted {
+			return unauthorized(c, service_errors.UserDisabled)
+		}
+
+		user, err := userUsecase.EnsureUser(c.Context(), claims.Identity())
+		if err != nil {
+			return c.Status(helper.TranslateErrorToStatusCode(err)).JSON(
+				helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err),
+			)
+		}
+
+		roles := slices.Clone([]string(claims.Roles))
+		if claims.IsAdmin && !claims.HasRole(constant.AdminRoleName) {
+			roles = append(roles, constant.AdminRoleName)
+		}
+
+		tenantId, ok := resolveTenant(c, cfg.Tenancy, claims, slices.Contains(roles, constant.AdminRoleName))
+		if !ok {
+			return forbidden(c)
+		}
+
+		requestId, _ := c.Locals(requestid.ConfigDefault.ContextKey).(string)
+		principal.SetLocal(c.Locals, principal.Principal{
+			UserId:    user.Id,
+			Username:  claims.Name,
+			Roles:     roles,
+			TenantId:  tenantId,
+			RequestId: requestId,
+			Ip:        c.IP(),
+		})
+		c.Locals(constant.ClaimsKey, claims)
+		c.Locals(constant.UsernameKey, claims.Name)
+		c.Locals(constant.MobileNumberKey, claims.Phone)
+		if claims.ExpiresAt != nil {
+			c.Locals(constant.ExpireTimeKey, claims.ExpiresAt.Unix())
+		}
+
+		return c.Next()
+	}
+}
+
+// RequireRoles lets the request through when the caller has at least one of the roles,
+// admins are always allowed. It must run after Authentication.
+func RequireRoles(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		caller, ok := principal.FromContext(c.Context())
+		if !ok {
+			return unauthorized(c, service_errors.TokenRequired)
+		}
+		if caller.IsAdmin() || slices.ContainsFunc(roles, caller.HasRole) {
+			return c.Next()
+		}
+		return forbidden(c)
+	}
+}
+
+// GetClaims returns the claims of the authenticated caller
+func GetClaims(c *fiber.Ctx) (*token.Claims, bool) {
+	claims, ok := c.Locals(constant.ClaimsKey).(*token.Claims)
+	return claims, ok
+}
+
+// NewVerifier builds the access token verifier selected by the configuration
+func NewVerifier(cfg *config.Config) (*token.Verifier, error) {
+	options := token.Options{
+		Issuer:   cfg.Jwt.Issuer,
+		Audience: cfg.Jwt.Audience,
+		Leeway:   cfg.Jwt.Leeway,
+	}
+
+	if strings.EqualFold(cfg.Jwt.Verifier, "jwks") {
+		url := cfg.Jwt.JwksUrl
+		if url == "" {
+			url = strings.TrimSuffix(cfg.Auth.Endpoint, "/") + jwksPath
+		}
+		return token.NewVerifier(token.NewJWKS(url, cfg.Jwt.JwksRefresh), options), nil
+	}
+
+	certificate := cfg.Jwt.Certificate
+	if certificate == "" {
+		certificate = cfg.Auth.Certificate
+	}
+	key, err := token.NewStaticKey(certificate, cfg.Jwt.Secret)
+	if err != nil {
+		return nil, err
+	}
+	if key.HasSecret() {
+		options.Methods = append(slices.Clone(token.AsymmetricMethods), token.HMACMethods...)
+	}
+	return token.NewVerifier(key, options), nil
+}
+
+func forbidden(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusForbidden).JSON(
+		helper.GenerateBaseResponseWithError(nil, false, helper.ForbiddenError, &service_errors.ServiceError{EndUserMessage: service_errors.PermissionDenied}),
+	)
+}
+
+func unauthorized(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusUnauthorized).JSON(
+		helper.GenerateBaseResponseWithError(nil, false, helper.AuthError, &service_errors.ServiceError{EndUserMessage: message}),
+	)
+}
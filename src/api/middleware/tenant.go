@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/pkg/token"
+)
+
+// resolveTenant reads the tenant from the configured sources, the first one present wins.
+// Only administrators may act in an organization other than the owner of their token.
+func resolveTenant(c *fiber.Ctx, cfg config.TenancyConfig, claims *token.Claims, admin bool) (string, bool) {
+	if !cfg.Enabled {
+		return "", true
+	}
+
+	tenant := ""
+	for _, source := range cfg.SourceList() {
+		switch strings.ToLower(source) {
+		case "header":
+			tenant = strings.TrimSpace(c.Get(cfg.HeaderName()))
+		case "subdomain":
+			tenant = subdomain(c.Hostname(), cfg.BaseDomain)
+		case "claim":
+			tenant = claims.Owner
+		}
+		if tenant != "" {
+			break
+		}
+	}
+
+	if tenant == "" || (tenant != claims.Owner && !admin) {
+		return "", false
+	}
+	return tenant, true
+}
+
+// subdomain returns acme for acme.example.com below example.com, nested labels are not tenants
+func subdomain(host string, baseDomain string) string {
+	if baseDomain == "" {
+		return ""
+	}
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	label, found := strings.CutSuffix(strings.ToLower(host), "."+strings.ToLower(baseDomain))
+	if !found || label == "" || strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
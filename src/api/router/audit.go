@@ -0,0 +1,15 @@
+package router
+
+import (
+	"github.com/minisource/template_go/api/handler"
+	"github.com/minisource/template_go/config"
+	"github.com/gofiber/fiber/v2"
+)
+
+func Audit(r fiber.Router, cfg *config.Config) {
+	h := handler.NewAuditHandler(cfg)
+
+	r.Post(GetByFilterExp, h.GetByFilter)
+	r.Post(GetByCursorExp, h.GetByCursor)
+	r.Post(ExportExp, h.Export)
+}
@@ -2,18 +2,48 @@ package router
 
 import (
 	"github.com/minisource/template_go/api/handler"
+	"github.com/minisource/template_go/api/middleware"
 	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/constant"
 	"github.com/gofiber/fiber/v2"
 )
 
 const GetByFilterExp string = "/get-by-filter"
+const GetByCursorExp string = "/get-by-cursor"
+const BulkExp string = "/bulk"
+const ExportExp string = "/export"
+const ImportExp string = "/import"
 
 func File(r fiber.Router, cfg *config.Config) {
 	h := handler.NewFileHandler(cfg)
 
+	// Registered before the /:id routes so an upload id is never taken for a file id
+	uploads := r.Group("/uploads")
+	FileUpload(uploads, cfg)
+
 	r.Post("/", h.Create)
+	// Registering files skips the upload checks, it is for import tools
+	r.Post(BulkExp, middleware.RequireRoles(constant.AdminRoleName), h.BulkCreate)
+	r.Patch(BulkExp, h.BulkPatch)
+	r.Delete(BulkExp, h.BulkDelete)
+	r.Post(ExportExp, h.Export)
+	r.Post(ImportExp, middleware.RequireRoles(constant.AdminRoleName), h.Import)
 	r.Put("/:id", h.Update)
+	r.Patch("/:id", h.Patch)
 	r.Delete("/:id", h.Delete)
 	r.Get("/:id", h.GetById)
+	r.Get("/:id/content", h.Content)
+	r.Post("/:id/restore", middleware.RequireRoles(constant.AdminRoleName), h.Restore)
 	r.Post(GetByFilterExp, h.GetByFilter)
+	r.Post(GetByCursorExp, h.GetByCursor)
+}
+
+func FileUpload(r fiber.Router, cfg *config.Config) {
+	h := handler.NewUploadSessionHandler(cfg)
+
+	r.Post("/", h.Create)
+	r.Get("/:uploadId", h.GetById)
+	r.Put("/:uploadId/parts/:number", h.PutPart)
+	r.Post("/:uploadId/complete", h.Complete)
+	r.Delete("/:uploadId", h.Delete)
 }
@@ -2,14 +2,27 @@ package router
 
 import (
 	"github.com/minisource/template_go/api/handler"
+	"github.com/minisource/template_go/api/middleware"
 	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/constant"
 	"github.com/gofiber/fiber/v2"
 )
 
 func User(r fiber.Router, cfg *config.Config) {
 	h := handler.NewUserHandler(cfg)
 
-	r.Post("/send-otp" /*, middleware.OtpLimiter(&cfg.OTP)*/, h.SendOtp)
+	// Sends and wrong codes are limited by the user usecase, see config OtpLimit
+	r.Post("/send-otp", h.SendOtp)
 	r.Post("/login-by-mobile", h.RegisterLoginByMobileNumber)
-	// r.Post("/refresh-token", h.RefreshToken)
+	r.Post("/refresh-token", h.RefreshToken)
+	r.Post("/logout", h.Logout)
+}
+
+// UserProfile must be mounted behind middleware.Authentication
+func UserProfile(r fiber.Router, cfg *config.Config) {
+	h := handler.NewProfileHandler(cfg)
+
+	r.Get("/me", h.GetMe)
+	r.Patch("/me", h.UpdateMe)
+	r.Post(GetByFilterExp, middleware.RequireRoles(constant.AdminRoleName), h.GetByFilter)
 }
@@ -0,0 +1,80 @@
+// Command gen scaffolds CRUD resources from YAML definitions, see cmd/gen/example.yml.
+// Run it from src: go run ./cmd/gen [-force] [-dry-run] definitions...
+//
+// Generated files start with a checksum, regenerating skips the ones edited since.
+// The mappers of the usecases are then written with go generate, see cmd/mapgen.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"text/tabwriter"
+
+	"github.com/minisource/template_go/pkg/scaffold"
+)
+
+func main() {
+	root := flag.String("root", ".", "Directory of the go.mod")
+	force := flag.Bool("force", false, "Overwrite files edited since they were generated")
+	dryRun := flag.Bool("dry-run", false, "Only print what would be written")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: gen [-root dir] [-force] [-dry-run] definition.yml...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(*root, flag.Args(), *force, *dryRun); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(root string, definitions []string, force bool, dryRun bool) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	kept := false
+	for _, definition := range definitions {
+		resource, err := scaffold.Load(definition)
+		if err != nil {
+			return err
+		}
+		plan, err := scaffold.NewPlan(root, resource)
+		if err != nil {
+			return err
+		}
+		actions, err := scaffold.Write(root, resource.Source, plan, force, dryRun)
+		for _, action := range actions {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", resource.Name, action.Action, action.Path)
+			kept = kept || action.Action == scaffold.Kept
+		}
+		if err != nil {
+			return err
+		}
+	}
+	w.Flush()
+
+	if kept {
+		fmt.Println("\nKept files were edited by hand, pass -force to overwrite them")
+	}
+	if dryRun {
+		return nil
+	}
+
+	// The usecases only compile once their mappers are written
+	generate := exec.Command("go", "generate", "./usecase")
+	generate.Dir = root
+	generate.Stdout = os.Stdout
+	generate.Stderr = os.Stderr
+	if err := generate.Run(); err != nil {
+		return fmt.Errorf("writing the mappers: %w, fix the definition and run go generate ./usecase", err)
+	}
+	fmt.Println("\nRun make swagger to document the new endpoints")
+	return nil
+}
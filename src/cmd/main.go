@@ -1,9 +1,18 @@
 package main
 
 import (
+	"context"
+	"os"
+	// Profile time zones are validated against the embedded database, images may lack tzdata
+	_ "time/tzdata"
+
 	"github.com/minisource/template_go/api"
 	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/infra/cache"
 	"github.com/minisource/template_go/infra/persistence/migration"
+	"github.com/minisource/template_go/infra/ratelimit"
+	"github.com/minisource/template_go/infra/storage"
+	"github.com/minisource/template_go/job"
 	auth "github.com/minisource/auth/service"
 	"github.com/minisource/go-common/db/gorm"
 	"github.com/minisource/go-common/logging"
@@ -16,6 +25,18 @@ func main() {
 	cfg := config.GetConfig()
 	logger := logging.NewLogger(&cfg.Logger)
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err := gormdb.InitDb(&cfg.Gorm)
+		if err != nil {
+			logger.Fatal(logging.Postgres, logging.Startup, err.Error(), nil)
+		}
+		defer gormdb.CloseDb()
+		if err = runMigrate(os.Args[2:]); err != nil {
+			logger.Fatal(logging.Postgres, logging.Migration, err.Error(), nil)
+		}
+		return
+	}
+
 	auth := auth.NewAuthService(cfg.Auth)
 	err := auth.HealthCheck()
 	if err != nil {
@@ -23,11 +44,26 @@ func main() {
 	}
 
 	err = gormdb.InitDb(&cfg.Gorm)
-	defer gormdb.CloseDb()
 	if err != nil {
 		logger.Fatal(logging.Postgres, logging.Startup, err.Error(), nil)
 	}
-	migration.Up1()
+	defer gormdb.CloseDb()
+	err = migration.NewMigrator(gormdb.GetDb()).Up()
+	if err != nil {
+		logger.Fatal(logging.Postgres, logging.Migration, err.Error(), nil)
+	}
+
+	err = storage.InitStorage(&cfg.Storage)
+	if err != nil {
+		logger.Fatal(logging.IO, logging.Startup, err.Error(), nil)
+	}
+
+	err = ratelimit.InitStore(cfg)
+	defer cache.CloseRedis()
+	if err != nil {
+		logger.Fatal(logging.Redis, logging.Startup, err.Error(), nil)
+	}
 
+	job.Start(context.Background(), cfg)
 	api.InitServer(cfg)
 }
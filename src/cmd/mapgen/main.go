@@ -0,0 +1,43 @@
+// Command mapgen writes the typed mappers of the usecases to usecase/mapper_gen.go.
+// Run go generate ./usecase after adding a function below or changing a model or DTO.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/minisource/template_go/domain/model"
+	"github.com/minisource/template_go/pkg/mapgen"
+	"github.com/minisource/template_go/usecase/dto"
+)
+
+const usecasePackage string = "github.com/minisource/template_go/usecase"
+
+var mappers = []mapgen.Func{
+	mapgen.Map[dto.CreateFile, model.File]("toFileEntity"),
+	mapgen.Columns[dto.UpdateFile]("fileColumns"),
+	mapgen.Map[model.File, dto.File]("toFileDto"),
+
+	mapgen.Map[dto.UpdateUserProfile, model.User]("toUserEntity"),
+	mapgen.Columns[dto.UpdateUserProfile]("userProfileColumns"),
+	mapgen.Map[model.User, dto.UserProfile]("toUserProfileDto"),
+
+	mapgen.Map[model.AuditLog, dto.AuditLog]("toAuditLogDto"),
+	// cmd/gen adds the mappers of new resources above this line
+}
+
+func main() {
+	output := flag.String("o", "usecase/mapper_gen.go", "File to write")
+	flag.Parse()
+
+	source, err := mapgen.Generate(usecasePackage, mappers...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*output, source, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/minisource/go-common/db/gorm"
+	"github.com/minisource/template_go/infra/persistence/migration"
+)
+
+const migrateUsage = "usage: migrate up|down|status|to N"
+
+// runMigrate executes the `migrate` subcommand, defaulting to `up`
+func runMigrate(args []string) error {
+	migrator := migration.NewMigrator(gormdb.GetDb())
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		return migrator.Up()
+	case "down":
+		return migrator.Down()
+	case "to":
+		if len(args) < 2 {
+			return fmt.Errorf("missing target version, %s", migrateUsage)
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil || version < 0 {
+			return fmt.Errorf("invalid target version %q, %s", args[1], migrateUsage)
+		}
+		return migrator.To(version)
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		printStatus(statuses)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q, %s", command, migrateUsage)
+	}
+}
+
+func printStatus(statuses []migration.Status) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, s := range statuses {
+		state, appliedAt := "pending", "-"
+		if s.Applied {
+			state = "applied"
+			appliedAt = s.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+	}
+	w.Flush()
+}
@@ -4,21 +4,33 @@ import (
 	"errors"
 	"log"
 	"os"
+	"slices"
+	"time"
 
 	auth "github.com/minisource/auth/service"
 	gormdb "github.com/minisource/go-common/db/gorm"
 	"github.com/minisource/go-common/http/middleware"
 	"github.com/minisource/go-common/logging"
+	"github.com/minisource/template_go/pkg/imaging"
+	"github.com/minisource/template_go/pkg/upload"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Server ServerConfig
-	Gorm   gormdb.GormConfig
-	Cors   CorsConfig
-	Logger logging.LoggerConfig
-	Auth   auth.AuthServiceConfig
-	OTP    middleware.OtpConfig
+	Server     ServerConfig
+	Gorm       gormdb.GormConfig
+	Cors       CorsConfig
+	Logger     logging.LoggerConfig
+	Auth       auth.AuthServiceConfig
+	Jwt        JwtConfig
+	Tenancy    TenancyConfig
+	OTP        middleware.OtpConfig
+	OtpLimit   OtpLimitConfig
+	Redis      RedisConfig
+	Storage    StorageConfig
+	Upload     UploadConfig
+	Images     ImageConfig
+	SoftDelete SoftDeleteConfig
 }
 
 type ServerConfig struct {
@@ -29,10 +41,232 @@ type ServerConfig struct {
 	RefreshCookieMaxAgeSecs int // Max age for refresh token cookie in seconds (default: 604800 = 7 days)
 }
 
+// JwtConfig selects how access tokens are verified
+type JwtConfig struct {
+	Verifier    string        // static or jwks (default: static)
+	Certificate string        // PEM certificate or public key for static verification (default: Auth.Certificate)
+	Secret      string        // HMAC secret, enables HS256/HS384/HS512 for static verification
+	JwksUrl     string        // Key set for jwks verification (default: Auth.Endpoint + /.well-known/jwks)
+	JwksRefresh time.Duration // How long downloaded keys are trusted (default: 1h)
+	Issuer      string        // Expected iss claim, not checked when empty
+	Audience    string        // Expected aud claim, not checked when empty
+	Leeway      time.Duration // Tolerated clock skew
+}
+
+// TenancyConfig scopes the rows of BaseRepository by the caller's organization
+type TenancyConfig struct {
+	Enabled    bool
+	Sources    []string // Where the tenant is read, in order: header, subdomain, claim (default: claim)
+	Header     string   // Header of the header source (default: X-Tenant-Id)
+	BaseDomain string   // Domain below which the subdomain source finds the tenant, e.g. example.com
+}
+
+func (c TenancyConfig) HeaderName() string {
+	if c.Header == "" {
+		return "X-Tenant-Id"
+	}
+	return c.Header
+}
+
+func (c TenancyConfig) SourceList() []string {
+	if len(c.Sources) == 0 {
+		return []string{"claim"}
+	}
+	return c.Sources
+}
+
+// OtpLimitConfig protects OTP login from SMS flooding and code guessing
+type OtpLimitConfig struct {
+	Store        string        // memory or redis (default: memory, limits are per instance)
+	SendPerPhone int           // OTPs sent to a phone number per SendWindow (default: 3)
+	SendPerIp    int           // OTPs requested from a client IP per SendWindow (default: 10)
+	SendWindow   time.Duration // default: 10m
+	MaxAttempts  int           // Wrong codes before the phone number is locked (default: 5)
+	LockDuration time.Duration // How long a phone number stays locked (default: 15m)
+}
+
+// WithDefaults fills the limits left empty in the configuration
+func (c OtpLimitConfig) WithDefaults() OtpLimitConfig {
+	if c.SendPerPhone <= 0 {
+		c.SendPerPhone = 3
+	}
+	if c.SendPerIp <= 0 {
+		c.SendPerIp = 10
+	}
+	if c.SendWindow <= 0 {
+		c.SendWindow = 10 * time.Minute
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 5
+	}
+	if c.LockDuration <= 0 {
+		c.LockDuration = 15 * time.Minute
+	}
+	return c
+}
+
+type RedisConfig struct {
+	Host         string
+	Port         string
+	Password     string
+	Db           int
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	PoolSize     int
+}
+
 type CorsConfig struct {
 	AllowOrigins string
 }
 
+type UploadConfig struct {
+	Default   upload.Policy
+	Routes    map[string]upload.Policy // Overrides of the default policy by route name, e.g. files
+	Resumable ResumableUploadConfig
+}
+
+type ResumableUploadConfig struct {
+	MaxSize         int64         // Largest file accepted, the rest of the policy is the one of the route (default: the route's)
+	ChunkSize       int64         // Size of every part except the last one (default: 5 MB)
+	SessionTTL      time.Duration // Sessions without a new part for this long are expired (default: 24h)
+	CleanupInterval time.Duration // How often expired sessions are cleaned up (default: 1h)
+}
+
+func (c ResumableUploadConfig) PartSize() int64 {
+	if c.ChunkSize <= 0 {
+		return 5 << 20
+	}
+	return c.ChunkSize
+}
+
+func (c ResumableUploadConfig) TTL() time.Duration {
+	if c.SessionTTL <= 0 {
+		return 24 * time.Hour
+	}
+	return c.SessionTTL
+}
+
+func (c ResumableUploadConfig) Interval() time.Duration {
+	if c.CleanupInterval <= 0 {
+		return time.Hour
+	}
+	return c.CleanupInterval
+}
+
+// Policy returns the upload policy of a route. Its unset fields fall back to the default
+// policy and the types denied by default stay denied.
+func (c UploadConfig) Policy(route string) upload.Policy {
+	policy, ok := c.Routes[route]
+	if !ok {
+		return c.Default
+	}
+	if policy.MaxSize == 0 {
+		policy.MaxSize = c.Default.MaxSize
+	}
+	if len(policy.AllowedTypes) == 0 {
+		policy.AllowedTypes = c.Default.AllowedTypes
+	}
+	if len(policy.AllowedExtensions) == 0 {
+		policy.AllowedExtensions = c.Default.AllowedExtensions
+	}
+	denied := slices.Clone(c.Default.DeniedTypes)
+	for _, mimeType := range policy.DeniedTypes {
+		if !slices.Contains(denied, mimeType) {
+			denied = append(denied, mimeType)
+		}
+	}
+	policy.DeniedTypes = denied
+	return policy
+}
+
+// ResumablePolicy is the policy of a route for files sent in parts, only the size limit differs
+func (c UploadConfig) ResumablePolicy(route string) upload.Policy {
+	policy := c.Policy(route)
+	if c.Resumable.MaxSize > 0 {
+		policy.MaxSize = c.Resumable.MaxSize
+	}
+	return policy
+}
+
+// MaxSize is the largest upload body accepted by any route
+func (c UploadConfig) MaxSize() int64 {
+	size := max(c.Default.Limit(), c.Resumable.PartSize())
+	for route := range c.Routes {
+		size = max(size, c.Policy(route).Limit())
+	}
+	return size
+}
+
+type ImageConfig struct {
+	Variants     []imaging.Variant // Generated in the background for every uploaded image
+	MaxPixels    int               // Larger images get no variants (default: 50 MP)
+	PollInterval time.Duration     // How often new images are picked up (default: 5s)
+	BatchSize    int               // Images processed per poll (default: 10)
+}
+
+func (c ImageConfig) Interval() time.Duration {
+	if c.PollInterval <= 0 {
+		return 5 * time.Second
+	}
+	return c.PollInterval
+}
+
+func (c ImageConfig) Batch() int {
+	if c.BatchSize <= 0 {
+		return 10
+	}
+	return c.BatchSize
+}
+
+// SoftDeleteConfig controls how long deleted rows can be restored before they are purged
+type SoftDeleteConfig struct {
+	RetentionDays int           // Deleted rows are purged after this many days (default: 30)
+	PurgeInterval time.Duration // How often purgeable rows are looked for (default: 1h)
+	BatchSize     int           // Rows purged per statement (default: 100)
+}
+
+func (c SoftDeleteConfig) Retention() time.Duration {
+	if c.RetentionDays <= 0 {
+		return 30 * 24 * time.Hour
+	}
+	return time.Duration(c.RetentionDays) * 24 * time.Hour
+}
+
+func (c SoftDeleteConfig) Interval() time.Duration {
+	if c.PurgeInterval <= 0 {
+		return time.Hour
+	}
+	return c.PurgeInterval
+}
+
+func (c SoftDeleteConfig) Batch() int {
+	if c.BatchSize <= 0 {
+		return 100
+	}
+	return c.BatchSize
+}
+
+type StorageConfig struct {
+	Type  string // local or s3 (default: local)
+	Local LocalStorageConfig
+	S3    S3StorageConfig
+}
+
+type LocalStorageConfig struct {
+	RootDirectory string // Base directory for stored files (default: current directory)
+}
+
+type S3StorageConfig struct {
+	Endpoint     string // host:port of the S3 compatible service, e.g. localhost:9000 for MinIO
+	Region       string
+	Bucket       string
+	AccessKey    string
+	SecretKey    string
+	UseSSL       bool
+	CreateBucket bool // Create the bucket on startup when it does not exist
+}
+
 func GetConfig() *Config {
 	cfgPath := getConfigPath(os.Getenv("APP_ENV"))
 	v, err := LoadConfig(cfgPath, "yml")
@@ -9,13 +9,12 @@ const (
 
 	// Claims
 	AuthorizationHeaderKey string = "Authorization"
-	UserIdKey              string = "UserId"
+	ClaimsKey              string = "Claims"
 	FirstNameKey           string = "FirstName"
 	LastNameKey            string = "LastName"
 	UsernameKey            string = "Username"
 	EmailKey               string = "Email"
 	MobileNumberKey        string = "MobileNumber"
-	RolesKey               string = "Roles"
 	ExpireTimeKey          string = "Exp"
 
 	// JWT
@@ -2,10 +2,12 @@ package dependency
 
 import (
 	"github.com/minisource/template_go/config"
-	"github.com/minisource/template_go/domain/model"
 	contractRepository "github.com/minisource/template_go/domain/repository"
+	contractRateLimit "github.com/minisource/template_go/domain/ratelimit"
+	contractStorage "github.com/minisource/template_go/domain/storage"
 	infrarepository "github.com/minisource/template_go/infra/persistence/repository"
-	gormdb "github.com/minisource/go-common/db/gorm"
+	infraratelimit "github.com/minisource/template_go/infra/ratelimit"
+	infrastorage "github.com/minisource/template_go/infra/storage"
 )
 
 // func GetUserRepository(cfg *config.Config) contractRepository.UserRepository {
@@ -13,10 +15,33 @@ import (
 // }
 
 func GetFileRepository(cfg *config.Config) contractRepository.FileRepository {
-	var preloads []gormdb.PreloadEntity = []gormdb.PreloadEntity{}
-	return infrarepository.NewBaseRepository[model.File](cfg, preloads)
+	return infrarepository.NewFileRepository(cfg)
 }
 
 func GetUserRepository(cfg *config.Config) contractRepository.UserRepository {
 	return infrarepository.NewUserRepository(cfg)
 }
+
+func GetStorage() contractStorage.Storage {
+	return infrastorage.GetStorage()
+}
+
+func GetUploadSessionRepository(cfg *config.Config) contractRepository.UploadSessionRepository {
+	return infrarepository.NewUploadSessionRepository(cfg)
+}
+
+func GetRevokedTokenRepository(cfg *config.Config) contractRepository.RevokedTokenRepository {
+	return infrarepository.NewRevokedTokenRepository(cfg)
+}
+
+func GetRateLimitStore() contractRateLimit.Store {
+	return infraratelimit.GetStore()
+}
+
+func GetAuditLogRepository(cfg *config.Config) contractRepository.AuditLogRepository {
+	return infrarepository.NewAuditLogRepository(cfg)
+}
+
+func GetUnitOfWork() contractRepository.UnitOfWork {
+	return infrarepository.NewUnitOfWork()
+}
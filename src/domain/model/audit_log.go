@@ -0,0 +1,67 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+const (
+	AuditCreate  string = "create"
+	AuditUpdate  string = "update"
+	AuditDelete  string = "delete"
+	AuditRestore string = "restore"
+	AuditPurge   string = "purge"
+)
+
+// AuditLog is an append-only record of a change made through BaseRepository.
+// CreatedBy and CreatedAt tell who made the change and when.
+type AuditLog struct {
+	BaseModel
+	Action    string          `gorm:"size:20;type:string;not null"`
+	Entity    string          `gorm:"size:100;type:string;not null;index:idx_audit_logs_entity"` // Model name, e.g. File
+	EntityId  int             `gorm:"not null;index:idx_audit_logs_entity"`
+	Username  string          `gorm:"size:100;type:string;not null;default:''"`
+	Ip        string          `gorm:"size:45;type:string;not null;default:''"`
+	RequestId string          `gorm:"size:100;type:string;not null;default:''"`
+	Changes   json.RawMessage `gorm:"type:jsonb"` // Field name to its before and after value, updates only
+}
+
+// FieldChange is the value of a field before and after an update
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditChanges keeps the fields whose value differs between the column values read before and after
+// an update, compared by their JSON form
+func AuditChanges(before, after map[string]interface{}) map[string]FieldChange {
+	changed := map[string]FieldChange{}
+	for field, value := range after {
+		was, now := auditValue(before[field]), auditValue(value)
+		wasJson, _ := json.Marshal(was)
+		nowJson, _ := json.Marshal(now)
+		if !bytes.Equal(wasJson, nowJson) {
+			changed[field] = FieldChange{Before: was, After: now}
+		}
+	}
+	return changed
+}
+
+// auditValue keeps json and text columns readable, the driver returns some of them as bytes
+func auditValue(value interface{}) interface{} {
+	raw, ok := value.([]byte)
+	if !ok {
+		return value
+	}
+	if json.Valid(raw) {
+		return json.RawMessage(raw)
+	}
+	return string(raw)
+}
+
+// Unaudited keeps BaseRepository from auditing the changes of a model, the audit log itself
+type Unaudited interface {
+	Unaudited()
+}
+
+func (AuditLog) Unaudited() {}
@@ -1,9 +1,27 @@
 package model
 
+const (
+	VariantStatusPending    string = "pending"
+	VariantStatusProcessing string = "processing"
+	VariantStatusDone       string = "done"
+	VariantStatusFailed     string = "failed"
+)
+
 type File struct {
 	BaseModel
-	Name        string `gorm:"size:100;type:string;not null"`
-	Directory   string `gorm:"size:100;type:string;not null"`
-	Description string `gorm:"size:500;type:string;not null"`
-	MimeType    string `gorm:"size:20;type:string;not null"`
+	SearchHit
+	Name         string `gorm:"size:100;type:string;not null" search:"A"`
+	OriginalName string `gorm:"size:255;type:string;not null;default:''" search:"A"`
+	Directory    string `gorm:"size:100;type:string;not null"`
+	Description  string `gorm:"size:500;type:string;not null" search:"B"`
+	MimeType     string `gorm:"size:255;type:string;not null"`
+	Size         int64  `gorm:"not null;default:0"`
+	Checksum     string `gorm:"size:64;type:string;not null;default:'';index"`
+	// Image variants are child rows of the uploaded original
+	ParentId      *int   `gorm:"null;index"`
+	Variant       string `gorm:"size:50;type:string;not null;default:''"`
+	Width         int    `gorm:"not null;default:0"`
+	Height        int    `gorm:"not null;default:0"`
+	VariantStatus string `gorm:"size:20;type:string;not null;default:''"` // Empty when no variant has to be generated
+	Variants      []File `gorm:"foreignKey:ParentId;constraint:OnUpdate:NO ACTION;OnDelete:NO ACTION"`
 }
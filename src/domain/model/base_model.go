@@ -4,11 +4,20 @@ import (
 	"database/sql"
 	"time"
 
+	"github.com/minisource/template_go/domain/principal"
 	"gorm.io/gorm"
 )
 
+// Global is implemented by models whose rows are shared by every tenant,
+// BaseRepository does not scope them and BaseModel leaves their TenantId empty
+type Global interface {
+	Global()
+}
+
 type BaseModel struct {
-	Id int `gorm:"primarykey"`
+	Id       int    `gorm:"primarykey"`
+	TenantId string `gorm:"size:100;type:string;not null;default:'';index"`
+	Version  int    `gorm:"not null;default:1"` // Incremented by every BaseRepository.Update, sent as ETag
 
 	CreatedAt  time.Time    `gorm:"type:TIMESTAMP with time zone;not null"`
 	ModifiedAt sql.NullTime `gorm:"type:TIMESTAMP with time zone;null"`
@@ -20,34 +29,35 @@ type BaseModel struct {
 }
 
 func (m *BaseModel) BeforeCreate(tx *gorm.DB) (err error) {
-	value := tx.Statement.Context.Value("UserId")
-	var userId = -1
-	if value != nil {
-		userId = int(value.(float64))
+	userId, ok := principal.UserId(tx.Statement.Context)
+	if !ok {
+		userId = principal.SystemUserId
 	}
 	m.CreatedAt = time.Now().UTC()
 	m.CreatedBy = userId
+	m.Version = 1
+	if _, global := tx.Statement.Dest.(Global); !global && m.TenantId == "" {
+		if p, ok := principal.FromContext(tx.Statement.Context); ok {
+			m.TenantId = p.TenantId
+		}
+	}
 	return
 }
 
 func (m *BaseModel) BeforeUpdate(tx *gorm.DB) (err error) {
-	value := tx.Statement.Context.Value("UserId")
-	var userId = &sql.NullInt64{Valid: false}
-	if value != nil {
-		userId = &sql.NullInt64{Valid: true, Int64: int64(value.(float64))}
-	}
 	m.ModifiedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
-	m.ModifiedBy = userId
+	m.ModifiedBy = actor(tx)
 	return
 }
 
 func (m *BaseModel) BeforeDelete(tx *gorm.DB) (err error) {
-	value := tx.Statement.Context.Value("UserId")
-	var userId = &sql.NullInt64{Valid: false}
-	if value != nil {
-		userId = &sql.NullInt64{Valid: true, Int64: int64(value.(float64))}
-	}
 	m.DeletedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
-	m.DeletedBy = userId
+	m.DeletedBy = actor(tx)
 	return
 }
+
+// actor is the user behind the statement, null when the context carries no principal
+func actor(tx *gorm.DB) *sql.NullInt64 {
+	userId, ok := principal.UserId(tx.Statement.Context)
+	return &sql.NullInt64{Valid: ok, Int64: int64(userId)}
+}
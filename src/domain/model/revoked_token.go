@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+const (
+	TokenRevokedLogout  string = "logout"
+	TokenRevokedRotated string = "rotated"
+)
+
+// RevokedToken blocks a refresh token until it would have expired anyway.
+// Only the SHA-256 of the token is stored.
+type RevokedToken struct {
+	BaseModel
+	TokenHash string    `gorm:"size:64;type:string;not null;uniqueIndex"`
+	UserId    string    `gorm:"size:100;type:string;not null;index"` // Auth service user id
+	Reason    string    `gorm:"size:20;type:string;not null"`
+	ExpiresAt time.Time `gorm:"type:TIMESTAMP with time zone;not null;index"`
+}
+
+// Global, refresh tokens are looked up before the tenant is known
+func (RevokedToken) Global() {}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SearchHit receives the rank and snippets of a full-text search, embed it in models
+// that have fields tagged `search`. It is only read, never stored.
+type SearchHit struct {
+	SearchRank       float64    `gorm:"->;-:migration"`
+	SearchHighlights Highlights `gorm:"->;-:migration;type:jsonb"`
+}
+
+// Highlights maps a searchable column to its snippet with the matched words marked
+type Highlights map[string]string
+
+func (h *Highlights) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*h = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, h)
+	case string:
+		return json.Unmarshal([]byte(v), h)
+	}
+	return fmt.Errorf("cannot scan %T into Highlights", value)
+}
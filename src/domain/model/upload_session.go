@@ -0,0 +1,50 @@
+package model
+
+import "time"
+
+const (
+	UploadSessionPending    string = "pending"
+	UploadSessionCompleting string = "completing" // Claimed by a request assembling the file
+	UploadSessionCompleted  string = "completed"
+	UploadSessionAborted    string = "aborted"
+	UploadSessionExpired    string = "expired"
+)
+
+// UploadSession tracks a resumable upload whose parts are sent in separate requests
+type UploadSession struct {
+	BaseModel
+	Key         string       `gorm:"size:36;type:string;not null;uniqueIndex"`
+	FileName    string       `gorm:"size:255;type:string;not null"`
+	Description string       `gorm:"size:500;type:string;not null"`
+	TotalSize   int64        `gorm:"not null"`
+	ChunkSize   int64        `gorm:"not null"`
+	Checksum    string       `gorm:"size:64;type:string;not null;default:''"`
+	Status      string       `gorm:"size:20;type:string;not null"`
+	ExpiresAt   time.Time    `gorm:"type:TIMESTAMP with time zone;not null;index"`
+	FileId      *int         `gorm:"null"`
+	File        *File        `gorm:"foreignKey:FileId;constraint:OnUpdate:NO ACTION;OnDelete:SET NULL"`
+	Parts       []UploadPart `gorm:"foreignKey:UploadSessionId;constraint:OnUpdate:NO ACTION;OnDelete:CASCADE"`
+}
+
+// TotalParts is the number of parts the client has to send
+func (s UploadSession) TotalParts() int {
+	if s.ChunkSize <= 0 {
+		return 0
+	}
+	return int((s.TotalSize + s.ChunkSize - 1) / s.ChunkSize)
+}
+
+// PartSize is the exact size expected for a part, only the last one may be shorter
+func (s UploadSession) PartSize(number int) int64 {
+	if number < s.TotalParts() {
+		return s.ChunkSize
+	}
+	return s.TotalSize - int64(s.TotalParts()-1)*s.ChunkSize
+}
+
+type UploadPart struct {
+	BaseModel
+	UploadSessionId int   `gorm:"not null;uniqueIndex:idx_upload_parts_session_number"`
+	Number          int   `gorm:"not null;uniqueIndex:idx_upload_parts_session_number"`
+	Size            int64 `gorm:"not null"`
+}
@@ -1,6 +1,27 @@
 package model
 
+import (
+	"database/sql"
+	"encoding/json"
+)
+
+// User is the local profile of an auth service account.
+// Username, Email and Phone are copied from the auth service on every login,
+// the other profile fields belong to this service and are edited by the user.
 type User struct {
 	BaseModel
-	UserId string
+	UserId      string          `gorm:"index"`
+	Username    string          `gorm:"size:100;type:string;not null;default:''"`
+	DisplayName string          `gorm:"size:100;type:string;not null;default:''"`
+	Email       string          `gorm:"size:100;type:string;not null;default:''"`
+	Phone       string          `gorm:"size:20;type:string;not null;default:''"`
+	AvatarId    *int            `gorm:"null"`
+	Avatar      *File           `gorm:"foreignKey:AvatarId;constraint:OnUpdate:NO ACTION;OnDelete:SET NULL"`
+	Locale      string          `gorm:"size:35;type:string;not null;default:''"` // BCP 47 tag, e.g. fa-IR
+	Timezone    string          `gorm:"size:64;type:string;not null;default:''"` // IANA name, e.g. Asia/Tehran
+	Preferences json.RawMessage `gorm:"type:jsonb;not null;default:'{}'"`
+	SyncedAt    sql.NullTime    `gorm:"type:TIMESTAMP with time zone;null"` // Last profile sync from the auth service
 }
+
+// Global, an account can sign in to every organization it belongs to
+func (User) Global() {}
@@ -0,0 +1,75 @@
+// Package principal carries the identity a request or a background job acts as.
+// It is stored under an unexported context key, use the accessors to read it.
+package principal
+
+import (
+	"context"
+	"slices"
+
+	"github.com/minisource/template_go/constant"
+)
+
+// SystemUserId is recorded as the author of rows written without a caller
+const SystemUserId int = -1
+
+type Principal struct {
+	UserId    int // Local user id, model.User.Id
+	Username  string
+	Roles     []string
+	TenantId  string // Organization the request acts in, empty when tenancy is disabled
+	RequestId string
+	Ip        string // Client address of the request
+	System    bool   // Background work, not an end user
+}
+
+type contextKey struct{}
+
+// System is the principal of a background job, it may act on every user's data
+func System(job string) Principal {
+	return Principal{UserId: SystemUserId, Username: "system:" + job, System: true}
+}
+
+// For returns a copy acting as userId, e.g. a job writing rows owned by the user who triggered it
+func (p Principal) For(userId int) Principal {
+	p.UserId = userId
+	return p
+}
+
+// In returns a copy acting in tenantId, e.g. a job writing rows next to the ones it read
+func (p Principal) In(tenantId string) Principal {
+	p.TenantId = tenantId
+	return p
+}
+
+func (p Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// IsAdmin also holds for system principals, they are not limited to a single owner
+func (p Principal) IsAdmin() bool {
+	return p.System || p.HasRole(constant.AdminRoleName)
+}
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// SetLocal stores the principal through a context-backed setter such as fiber's Ctx.Locals,
+// so the request context passed to the usecases resolves it
+func SetLocal(set func(key interface{}, value ...interface{}) interface{}, p Principal) {
+	set(contextKey{}, p)
+}
+
+func FromContext(ctx context.Context) (Principal, bool) {
+	if ctx == nil {
+		return Principal{}, false
+	}
+	p, ok := ctx.Value(contextKey{}).(Principal)
+	return p, ok
+}
+
+// UserId returns the id of the user the context acts as
+func UserId(ctx context.Context) (int, bool) {
+	p, ok := FromContext(ctx)
+	return p.UserId, ok
+}
@@ -0,0 +1,16 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Store keeps fixed window counters shared by every instance of the service
+type Store interface {
+	// Increment adds one to the counter of key, a new counter expires after window.
+	// It returns the new count and the time left before the counter expires.
+	Increment(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error)
+	// TTL returns the time left before the counter of key expires, zero when there is none
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/minisource/template_go/domain/model"
+	"github.com/minisource/template_go/pkg/keyset"
+	"github.com/minisource/go-common/filter"
+)
+
+// AuditLogRepository reads the audit log, BaseRepository writes it along with every change
+type AuditLogRepository interface {
+	GetByFilter(ctx context.Context, req filter.PaginationInputWithFilter) (int64, *[]model.AuditLog, error)
+	GetByCursor(ctx context.Context, req keyset.Request) (*keyset.Page[model.AuditLog], error)
+}
@@ -2,19 +2,94 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/minisource/template_go/domain/model"
+	"github.com/minisource/template_go/pkg/keyset"
 	"github.com/minisource/go-common/filter"
+	"github.com/minisource/go-common/service_errors"
 )
 
+// ErrVersionConflict is returned by Update when the row was modified since the caller read it
+var ErrVersionConflict = &service_errors.ServiceError{EndUserMessage: "record was modified by another request"}
+
+type includeDeletedKey struct{}
+
+// IncludeDeleted makes the reads done with ctx return soft-deleted rows too
+func IncludeDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, includeDeletedKey{}, true)
+}
+
+func IncludesDeleted(ctx context.Context) bool {
+	include, _ := ctx.Value(includeDeletedKey{}).(bool)
+	return include
+}
+
+type searchKey struct{}
+
+// Search narrows the lists read with ctx to the rows matching the full-text query q, see package search
+func Search(ctx context.Context, q string) context.Context {
+	return context.WithValue(ctx, searchKey{}, q)
+}
+
+// SearchQuery is the full-text query of ctx, empty when the lists are not searched
+func SearchQuery(ctx context.Context) string {
+	q, _ := ctx.Value(searchKey{}).(string)
+	return q
+}
+
+type projectionKey struct{}
+
+// Project makes the reads done with ctx load only the columns of the given fields, see package projection
+func Project(ctx context.Context, fields []string) context.Context {
+	return context.WithValue(ctx, projectionKey{}, fields)
+}
+
+// Projection is the fields of ctx, nil when whole rows are read
+func Projection(ctx context.Context) []string {
+	fields, _ := ctx.Value(projectionKey{}).([]string)
+	return fields
+}
+
+type expandKey struct{}
+
+// Expand makes the reads done with ctx also load the given relations, if the repository allows them
+func Expand(ctx context.Context, relations []string) context.Context {
+	return context.WithValue(ctx, expandKey{}, relations)
+}
+
+// Expansions is the relations ctx asks for besides the repository's preloads
+func Expansions(ctx context.Context) []string {
+	relations, _ := ctx.Value(expandKey{}).([]string)
+	return relations
+}
+
 type BaseRepository[TEntity any] interface {
 	Create(ctx context.Context, entity TEntity) (TEntity, error)
-	Update(ctx context.Context, id int, entity map[string]interface{}) (TEntity, error)
+	// Update applies the changes when the row is still at version, version 0 skips the check
+	Update(ctx context.Context, id int, version int, entity map[string]interface{}) (TEntity, error)
+	// Delete is a soft delete, the row is hidden from reads until it is restored or purged
 	Delete(ctx context.Context, id int) error
+	// Restore undoes Delete
+	Restore(ctx context.Context, id int) (TEntity, error)
+	// Purge removes up to limit rows deleted before the given time for good and returns them
+	Purge(ctx context.Context, before time.Time, limit int) ([]TEntity, error)
 	GetById(ctx context.Context, id int) (TEntity, error)
 	GetByFilter(ctx context.Context, req filter.PaginationInputWithFilter) (int64, *[]TEntity, error)
+	// GetByCursor pages with keyset cursors instead of OFFSET, see package keyset
+	GetByCursor(ctx context.Context, req keyset.Request) (*keyset.Page[TEntity], error)
 }
 
 type FileRepository interface {
 	BaseRepository[model.File]
+	GetByFilterCreatedBy(ctx context.Context, req filter.PaginationInputWithFilter, createdBy int) (int64, *[]model.File, error)
+	GetByCursorCreatedBy(ctx context.Context, req keyset.Request, createdBy int) (*keyset.Page[model.File], error)
+	// GetByChecksum returns nil when the user has no file with this content
+	GetByChecksum(ctx context.Context, checksum string, createdBy int) (*model.File, error)
+	// GetVariant returns nil when the variant has not been generated
+	GetVariant(ctx context.Context, parentId int, variant string) (*model.File, error)
+	// ClaimPendingVariants marks up to limit files waiting for variants as processing and returns them,
+	// files stuck in processing since before staleBefore are claimed again
+	ClaimPendingVariants(ctx context.Context, limit int, staleBefore time.Time) ([]model.File, error)
+	SetVariantStatus(ctx context.Context, id int, status string) error
 }
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/minisource/template_go/domain/model"
+)
+
+type RevokedTokenRepository interface {
+	// Revoke adds a token to the revocation list, it reports false when the token was already revoked
+	Revoke(ctx context.Context, token model.RevokedToken) (bool, error)
+	// Restore removes a token from the revocation list
+	Restore(ctx context.Context, tokenHash string) error
+	// DeleteExpired forgets revocations of tokens that expired before the given time
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,10 @@
+package repository
+
+import "context"
+
+// UnitOfWork runs several repository calls atomically
+type UnitOfWork interface {
+	// Do runs fn in a transaction carried by the context fn receives, repositories called with
+	// that context join it. A nested Do runs in a savepoint, its error only undoes its own changes.
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
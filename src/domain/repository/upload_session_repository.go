@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/minisource/template_go/domain/model"
+)
+
+type UploadSessionRepository interface {
+	BaseRepository[model.UploadSession]
+	// GetByKey returns the session with its received parts
+	GetByKey(ctx context.Context, key string) (model.UploadSession, error)
+	// SavePart inserts a part or replaces a previously received one and extends the session expiry
+	SavePart(ctx context.Context, part model.UploadPart, expiresAt time.Time) error
+	// ChangeStatus moves a session from status from to status to and sets its expiry,
+	// it reports false when the session was not in status from anymore
+	ChangeStatus(ctx context.Context, id int, from string, to string, expiresAt time.Time) (bool, error)
+	// Close moves a session from status from to a final status and forgets its parts,
+	// it reports false when the session was not in status from anymore
+	Close(ctx context.Context, id int, from string, status string, fileId *int) (bool, error)
+	// GetExpired returns pending and completing sessions that expired before the given time
+	GetExpired(ctx context.Context, before time.Time, limit int) ([]model.UploadSession, error)
+}
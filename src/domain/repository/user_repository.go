@@ -7,6 +7,13 @@ import (
 )
 
 type UserRepository interface {
+	BaseRepository[model.User]
 	ExistsUserId(ctx context.Context, userId string) (bool, error)
 	CreateUser(ctx context.Context, u model.User) (model.User, error)
+	GetByUserId(ctx context.Context, userId string) (model.User, error)
+	// LockUserId serializes the callers registering the same user id, it must run in a UnitOfWork
+	LockUserId(ctx context.Context, userId string) error
+	// SyncProfile copies the account fields of the auth service onto the user.
+	// DisplayName and Locale are only taken while the user has not set them.
+	SyncProfile(ctx context.Context, id int, account model.User) (model.User, error)
 }
@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+var (
+	ErrObjectNotFound      = errors.New("object not found")
+	ErrPresignNotSupported = errors.New("presigned urls are not supported by this storage")
+	ErrInvalidObjectKey    = errors.New("invalid object key")
+)
+
+// ObjectInfo describes a stored blob
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+}
+
+// Storage is a blob store for uploaded files.
+// Keys are slash separated paths relative to the storage root or bucket.
+type Storage interface {
+	Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	GetRange(ctx context.Context, key string, offset int64, length int64) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+	Presign(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/minisource/template_go/config"
+	"github.com/redis/go-redis/v9"
+)
+
+var redisClient *redis.Client
+
+// InitRedis connects to Redis and checks the connection
+func InitRedis(cfg *config.RedisConfig) error {
+	redisClient = redis.NewClient(&redis.Options{
+		Addr:         fmt.Sprintf("%s:%s", cfg.Host, cfg.Port),
+		Password:     cfg.Password,
+		DB:           cfg.Db,
+		DialTimeout:  cfg.DialTimeout,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		PoolSize:     cfg.PoolSize,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return redisClient.Ping(ctx).Err()
+}
+
+func GetRedis() *redis.Client {
+	return redisClient
+}
+
+func CloseRedis() {
+	if redisClient != nil {
+		redisClient.Close()
+	}
+}
@@ -0,0 +1,32 @@
+package migration
+
+import (
+	"github.com/minisource/template_go/domain/model"
+	"gorm.io/gorm"
+)
+
+var versionedModels = []interface{}{&model.User{}, &model.File{}, &model.UploadSession{}, &model.UploadPart{}, &model.RevokedToken{}}
+
+func up10(tx *gorm.DB) error {
+	migrator := tx.Migrator()
+	for _, table := range versionedModels {
+		if migrator.HasColumn(table, "Version") {
+			continue
+		}
+		// Existing rows start at the column default
+		if err := migrator.AddColumn(table, "Version"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func down10(tx *gorm.DB) error {
+	migrator := tx.Migrator()
+	for _, table := range versionedModels {
+		if err := migrator.DropColumn(table, "Version"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package migration
+
+import (
+	"github.com/minisource/template_go/domain/model"
+	"gorm.io/gorm"
+)
+
+// The audit log is append-only, even for the application's own database user
+const auditAppendOnlyUp string = `
+CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_logs_append_only
+	BEFORE UPDATE OR DELETE ON audit_logs
+	FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only();
+`
+
+const auditAppendOnlyDown string = `DROP FUNCTION IF EXISTS audit_logs_append_only()`
+
+func up11(tx *gorm.DB) error {
+	if tx.Migrator().HasTable(&model.AuditLog{}) {
+		return nil
+	}
+	if err := tx.Migrator().CreateTable(&model.AuditLog{}); err != nil {
+		return err
+	}
+	return tx.Exec(auditAppendOnlyUp).Error
+}
+
+func down11(tx *gorm.DB) error {
+	if err := tx.Migrator().DropTable(&model.AuditLog{}); err != nil {
+		return err
+	}
+	return tx.Exec(auditAppendOnlyDown).Error
+}
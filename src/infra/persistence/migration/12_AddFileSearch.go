@@ -0,0 +1,30 @@
+package migration
+
+import (
+	"fmt"
+
+	"github.com/minisource/template_go/domain/model"
+	"github.com/minisource/template_go/pkg/search"
+	"gorm.io/gorm"
+)
+
+func up12(tx *gorm.DB) error {
+	return addSearchColumn(tx, &model.File{}, "files", search.Fields[model.File]())
+}
+
+func down12(tx *gorm.DB) error {
+	return tx.Migrator().DropColumn(&model.File{}, search.Column)
+}
+
+// addSearchColumn adds the generated tsvector column of the searchable fields and its GIN index,
+// Postgres fills it for existing rows and keeps it up to date
+func addSearchColumn(tx *gorm.DB, table interface{}, name string, fields []search.Field) error {
+	if tx.Migrator().HasColumn(table, search.Column) {
+		return nil
+	}
+	column := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s tsvector GENERATED ALWAYS AS (%s) STORED", name, search.Column, search.Vector(fields))
+	if err := tx.Exec(column).Error; err != nil {
+		return err
+	}
+	return tx.Exec(fmt.Sprintf("CREATE INDEX idx_%s_%s ON %s USING GIN (%s)", name, search.Column, name, search.Column)).Error
+}
@@ -1,25 +1,28 @@
 package migration
 
 import (
+	"sync"
+
 	"github.com/minisource/template_go/config"
 	"github.com/minisource/template_go/domain/model"
-	"github.com/minisource/go-common/db/gorm"
 	"github.com/minisource/go-common/logging"
 	"gorm.io/gorm"
 )
 
 const countStarExp = "count(*)"
 
-var logger = logging.NewLogger(&config.GetConfig().Logger)
-
-func Up1() {
-	database := gormdb.GetDb()
+// logger loads the config on first use, so the package can be imported without one
+var logger = sync.OnceValue(func() logging.Logger { return logging.NewLogger(&config.GetConfig().Logger) })
 
-	createTables(database)
-	// createCountry(database)
+func up1(tx *gorm.DB) error {
+	if err := createTables(tx); err != nil {
+		return err
+	}
+	// createCountry(tx)
+	return nil
 }
 
-func createTables(database *gorm.DB) {
+func createTables(database *gorm.DB) error {
 	tables := []interface{}{}
 
 	// User
@@ -27,11 +30,15 @@ func createTables(database *gorm.DB) {
 
 	err := database.Migrator().CreateTable(tables...)
 	if err != nil {
-		logger.Error(logging.Postgres, logging.Migration, err.Error(), nil)
+		logger().Error(logging.Postgres, logging.Migration, err.Error(), nil)
+		return err
 	}
-	logger.Info(logging.Postgres, logging.Migration, "tables created", nil)
+	logger().Info(logging.Postgres, logging.Migration, "tables created", nil)
+	return nil
 }
 
+// addNewTable skips tables that already exist, so databases bootstrapped
+// before schema_migrations existed can adopt the versioned migrations
 func addNewTable(database *gorm.DB, model interface{}, tables []interface{}) []interface{} {
 	if !database.Migrator().HasTable(model) {
 		tables = append(tables, model)
@@ -55,7 +62,3 @@ func addNewTable(database *gorm.DB, model interface{}, tables []interface{}) []i
 // 		}})
 // 	}
 // }
-
-func Down1() {
-	// nothing
-}
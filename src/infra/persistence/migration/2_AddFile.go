@@ -0,0 +1,15 @@
+package migration
+
+import (
+	"github.com/minisource/template_go/domain/model"
+	"gorm.io/gorm"
+)
+
+func up2(tx *gorm.DB) error {
+	tables := addNewTable(tx, model.File{}, []interface{}{})
+	return tx.Migrator().CreateTable(tables...)
+}
+
+func down2(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&model.File{})
+}
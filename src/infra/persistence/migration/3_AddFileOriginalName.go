@@ -0,0 +1,17 @@
+package migration
+
+import (
+	"github.com/minisource/template_go/domain/model"
+	"gorm.io/gorm"
+)
+
+func up3(tx *gorm.DB) error {
+	if tx.Migrator().HasColumn(&model.File{}, "OriginalName") {
+		return nil
+	}
+	return tx.Migrator().AddColumn(&model.File{}, "OriginalName")
+}
+
+func down3(tx *gorm.DB) error {
+	return tx.Migrator().DropColumn(&model.File{}, "OriginalName")
+}
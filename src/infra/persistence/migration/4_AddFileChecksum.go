@@ -0,0 +1,38 @@
+package migration
+
+import (
+	"github.com/minisource/template_go/domain/model"
+	"gorm.io/gorm"
+)
+
+func up4(tx *gorm.DB) error {
+	migrator := tx.Migrator()
+	for _, column := range []string{"Size", "Checksum"} {
+		if migrator.HasColumn(&model.File{}, column) {
+			continue
+		}
+		if err := migrator.AddColumn(&model.File{}, column); err != nil {
+			return err
+		}
+	}
+	if !migrator.HasIndex(&model.File{}, "Checksum") {
+		if err := migrator.CreateIndex(&model.File{}, "Checksum"); err != nil {
+			return err
+		}
+	}
+	// Detected types such as application/vnd.openxmlformats-officedocument.wordprocessingml.document overflow 20 chars
+	return migrator.AlterColumn(&model.File{}, "MimeType")
+}
+
+func down4(tx *gorm.DB) error {
+	migrator := tx.Migrator()
+	if err := migrator.DropIndex(&model.File{}, "Checksum"); err != nil {
+		return err
+	}
+	for _, column := range []string{"Checksum", "Size"} {
+		if err := migrator.DropColumn(&model.File{}, column); err != nil {
+			return err
+		}
+	}
+	return tx.Exec("ALTER TABLE files ALTER COLUMN mime_type TYPE varchar(20) USING left(mime_type, 20)").Error
+}
@@ -0,0 +1,17 @@
+package migration
+
+import (
+	"github.com/minisource/template_go/domain/model"
+	"gorm.io/gorm"
+)
+
+func up5(tx *gorm.DB) error {
+	tables := []interface{}{}
+	tables = addNewTable(tx, model.UploadSession{}, tables)
+	tables = addNewTable(tx, model.UploadPart{}, tables)
+	return tx.Migrator().CreateTable(tables...)
+}
+
+func down5(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&model.UploadPart{}, &model.UploadSession{})
+}
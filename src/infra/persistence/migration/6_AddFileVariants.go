@@ -0,0 +1,45 @@
+package migration
+
+import (
+	"github.com/minisource/template_go/domain/model"
+	"gorm.io/gorm"
+)
+
+var fileVariantColumns = []string{"ParentId", "Variant", "Width", "Height", "VariantStatus"}
+
+func up6(tx *gorm.DB) error {
+	migrator := tx.Migrator()
+	for _, column := range fileVariantColumns {
+		if migrator.HasColumn(&model.File{}, column) {
+			continue
+		}
+		if err := migrator.AddColumn(&model.File{}, column); err != nil {
+			return err
+		}
+	}
+	if !migrator.HasIndex(&model.File{}, "ParentId") {
+		if err := migrator.CreateIndex(&model.File{}, "ParentId"); err != nil {
+			return err
+		}
+	}
+	if !migrator.HasConstraint(&model.File{}, "Variants") {
+		return migrator.CreateConstraint(&model.File{}, "Variants")
+	}
+	return nil
+}
+
+func down6(tx *gorm.DB) error {
+	migrator := tx.Migrator()
+	if err := migrator.DropConstraint(&model.File{}, "Variants"); err != nil {
+		return err
+	}
+	if err := migrator.DropIndex(&model.File{}, "ParentId"); err != nil {
+		return err
+	}
+	for _, column := range fileVariantColumns {
+		if err := migrator.DropColumn(&model.File{}, column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
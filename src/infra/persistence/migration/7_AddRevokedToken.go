@@ -0,0 +1,15 @@
+package migration
+
+import (
+	"github.com/minisource/template_go/domain/model"
+	"gorm.io/gorm"
+)
+
+func up7(tx *gorm.DB) error {
+	tables := addNewTable(tx, model.RevokedToken{}, []interface{}{})
+	return tx.Migrator().CreateTable(tables...)
+}
+
+func down7(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&model.RevokedToken{})
+}
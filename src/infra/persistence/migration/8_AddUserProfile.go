@@ -0,0 +1,42 @@
+package migration
+
+import (
+	"github.com/minisource/template_go/domain/model"
+	"gorm.io/gorm"
+)
+
+var userProfileColumns = []string{"Username", "DisplayName", "Email", "Phone", "AvatarId", "Locale", "Timezone", "Preferences", "SyncedAt"}
+
+func up8(tx *gorm.DB) error {
+	migrator := tx.Migrator()
+	for _, column := range userProfileColumns {
+		if migrator.HasColumn(&model.User{}, column) {
+			continue
+		}
+		if err := migrator.AddColumn(&model.User{}, column); err != nil {
+			return err
+		}
+	}
+	if !migrator.HasIndex(&model.User{}, "UserId") {
+		if err := migrator.CreateIndex(&model.User{}, "UserId"); err != nil {
+			return err
+		}
+	}
+	if !migrator.HasConstraint(&model.User{}, "Avatar") {
+		return migrator.CreateConstraint(&model.User{}, "Avatar")
+	}
+	return nil
+}
+
+func down8(tx *gorm.DB) error {
+	migrator := tx.Migrator()
+	if err := migrator.DropConstraint(&model.User{}, "Avatar"); err != nil {
+		return err
+	}
+	for _, column := range userProfileColumns {
+		if err := migrator.DropColumn(&model.User{}, column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
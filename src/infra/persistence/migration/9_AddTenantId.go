@@ -0,0 +1,36 @@
+package migration
+
+import (
+	"github.com/minisource/template_go/domain/model"
+	"gorm.io/gorm"
+)
+
+// tenantModels lists every table built on BaseModel, global ones get the column too but stay unscoped
+var tenantModels = []interface{}{&model.User{}, &model.File{}, &model.UploadSession{}, &model.UploadPart{}, &model.RevokedToken{}}
+
+func up9(tx *gorm.DB) error {
+	migrator := tx.Migrator()
+	for _, table := range tenantModels {
+		if !migrator.HasColumn(table, "TenantId") {
+			if err := migrator.AddColumn(table, "TenantId"); err != nil {
+				return err
+			}
+		}
+		if !migrator.HasIndex(table, "TenantId") {
+			if err := migrator.CreateIndex(table, "TenantId"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func down9(tx *gorm.DB) error {
+	migrator := tx.Migrator()
+	for _, table := range tenantModels {
+		if err := migrator.DropColumn(table, "TenantId"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
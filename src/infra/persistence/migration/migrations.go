@@ -0,0 +1,20 @@
+package migration
+
+// migrations lists every schema change in the order it must be applied.
+// Append new entries with the next version number and never edit or
+// renumber a migration that has already been released.
+var migrations = []Migration{
+	// The baseline has no Down step, it may have adopted tables it did not create
+	{Version: 1, Name: "init", Up: up1},
+	{Version: 2, Name: "add_file", Up: up2, Down: down2},
+	{Version: 3, Name: "add_file_original_name", Up: up3, Down: down3},
+	{Version: 4, Name: "add_file_checksum", Up: up4, Down: down4},
+	{Version: 5, Name: "add_upload_session", Up: up5, Down: down5},
+	{Version: 6, Name: "add_file_variants", Up: up6, Down: down6},
+	{Version: 7, Name: "add_revoked_token", Up: up7, Down: down7},
+	{Version: 8, Name: "add_user_profile", Up: up8, Down: down8},
+	{Version: 9, Name: "add_tenant_id", Up: up9, Down: down9},
+	{Version: 10, Name: "add_version", Up: up10, Down: down10},
+	{Version: 11, Name: "add_audit_log", Up: up11, Down: down11},
+	{Version: 12, Name: "add_file_search", Up: up12, Down: down12},
+}
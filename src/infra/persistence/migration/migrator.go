@@ -0,0 +1,274 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/minisource/go-common/logging"
+	"gorm.io/gorm"
+)
+
+// advisoryLockKey serializes migrations between replicas that start at the same time
+const advisoryLockKey int64 = 7_340_001
+
+const versionFilterExp string = "version = ?"
+
+var ErrIrreversible = errors.New("migration cannot be rolled back")
+
+// Migration is a single numbered schema change.
+// Up and Down run inside a transaction together with the bookkeeping row,
+// so a failing step leaves both the schema and schema_migrations untouched.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error // Nil when the migration cannot be rolled back
+}
+
+// SchemaMigration is a row of the schema_migrations bookkeeping table
+type SchemaMigration struct {
+	Version   int       `gorm:"primarykey;autoIncrement:false"`
+	Name      string    `gorm:"size:200;type:string;not null"`
+	AppliedAt time.Time `gorm:"type:TIMESTAMP with time zone;not null"`
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Status reports whether a registered migration has been applied
+type Status struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	database   *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator returns a migrator over the registered migrations list
+func NewMigrator(database *gorm.DB) *Migrator {
+	return &Migrator{database: database, migrations: migrations}
+}
+
+// Up applies every pending migration in version order
+func (m *Migrator) Up() error {
+	return m.To(m.latest())
+}
+
+// Down rolls back the most recently applied migration
+func (m *Migrator) Down() error {
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		logger().Info(logging.Postgres, logging.Migration, "no migration to roll back", nil)
+		return nil
+	}
+	return m.To(Previous(applied))
+}
+
+// To migrates the schema up or down until version is the latest applied migration.
+// Version 0 rolls back everything, it fails while the irreversible baseline is applied.
+func (m *Migrator) To(version int) error {
+	if err := validate(m.migrations); err != nil {
+		return err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+	steps, err := Plan(m.migrations, applied, version)
+	if err != nil {
+		return err
+	}
+	for _, step := range steps {
+		if step.Down {
+			err = m.revert(step.Migration)
+		} else {
+			err = m.apply(step.Migration)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Step is a migration To applies, or rolls back when Down is set
+type Step struct {
+	Migration Migration
+	Down      bool
+}
+
+// Plan returns the steps from the applied migrations to version: the pending ones up to it
+// in version order, then the applied ones above it newest first. A plan rolling back a migration
+// without a Down step is rejected before anything runs.
+func Plan(migrations []Migration, applied map[int]SchemaMigration, version int) ([]Step, error) {
+	if err := validate(migrations); err != nil {
+		return nil, err
+	}
+	if version != 0 && !slices.ContainsFunc(migrations, func(mig Migration) bool { return mig.Version == version }) {
+		return nil, fmt.Errorf("migration %d is not registered", version)
+	}
+
+	var steps []Step
+	for _, mig := range migrations {
+		if mig.Version > version {
+			break
+		}
+		if _, ok := applied[mig.Version]; !ok {
+			steps = append(steps, Step{Migration: mig})
+		}
+	}
+	for i := len(migrations) - 1; i >= 0; i-- {
+		mig := migrations[i]
+		if mig.Version <= version {
+			break
+		}
+		if _, ok := applied[mig.Version]; !ok {
+			continue
+		}
+		if mig.Down == nil {
+			return nil, fmt.Errorf("%w: %d_%s", ErrIrreversible, mig.Version, mig.Name)
+		}
+		steps = append(steps, Step{Migration: mig, Down: true})
+	}
+	return steps, nil
+}
+
+// Previous is the version Down migrates to, the applied one before the latest
+func Previous(applied map[int]SchemaMigration) int {
+	versions := sortedVersions(applied)
+	if len(versions) < 2 {
+		return 0
+	}
+	return versions[len(versions)-2]
+}
+
+// Status lists every registered migration with its applied state
+func (m *Migrator) Status() ([]Status, error) {
+	if err := validate(m.migrations); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		status := Status{Version: mig.Version, Name: mig.Name}
+		if row, ok := applied[mig.Version]; ok {
+			appliedAt := row.AppliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func (m *Migrator) apply(mig Migration) error {
+	err := m.database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", advisoryLockKey).Error; err != nil {
+			return err
+		}
+		// Another replica may have applied it while we waited for the lock
+		var count int64
+		if err := tx.Model(&SchemaMigration{}).Where(versionFilterExp, mig.Version).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		if err := mig.Up(tx); err != nil {
+			return err
+		}
+		return tx.Create(&SchemaMigration{Version: mig.Version, Name: mig.Name, AppliedAt: time.Now().UTC()}).Error
+	})
+	if err != nil {
+		logger().Error(logging.Postgres, logging.Migration, fmt.Sprintf("migration %d_%s failed: %s", mig.Version, mig.Name, err.Error()), nil)
+		return err
+	}
+	logger().Info(logging.Postgres, logging.Migration, fmt.Sprintf("migration %d_%s applied", mig.Version, mig.Name), nil)
+	return nil
+}
+
+func (m *Migrator) revert(mig Migration) error {
+	if mig.Down == nil {
+		return fmt.Errorf("%w: %d_%s", ErrIrreversible, mig.Version, mig.Name)
+	}
+	err := m.database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", advisoryLockKey).Error; err != nil {
+			return err
+		}
+		result := tx.Where(versionFilterExp, mig.Version).Delete(&SchemaMigration{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		return mig.Down(tx)
+	})
+	if err != nil {
+		logger().Error(logging.Postgres, logging.Migration, fmt.Sprintf("rollback of %d_%s failed: %s", mig.Version, mig.Name, err.Error()), nil)
+		return err
+	}
+	logger().Info(logging.Postgres, logging.Migration, fmt.Sprintf("migration %d_%s rolled back", mig.Version, mig.Name), nil)
+	return nil
+}
+
+func (m *Migrator) applied() (map[int]SchemaMigration, error) {
+	if err := m.database.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, err
+	}
+	var rows []SchemaMigration
+	if err := m.database.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int]SchemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+func validate(migrations []Migration) error {
+	if len(migrations) == 0 {
+		return errors.New("no migration registered")
+	}
+	for i, mig := range migrations {
+		if mig.Version <= 0 || mig.Up == nil {
+			return fmt.Errorf("migration %d_%s must have a positive version and an Up step", mig.Version, mig.Name)
+		}
+		if i > 0 && mig.Version <= migrations[i-1].Version {
+			return fmt.Errorf("migration %d_%s is out of order", mig.Version, mig.Name)
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+func sortedVersions(applied map[int]SchemaMigration) []int {
+	versions := make([]int, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+	return versions
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/domain/model"
+	gormdb "github.com/minisource/go-common/db/gorm"
+)
+
+type PostgresAuditLogRepository struct {
+	*BaseRepository[model.AuditLog]
+}
+
+func NewAuditLogRepository(cfg *config.Config) *PostgresAuditLogRepository {
+	var preloads []gormdb.PreloadEntity = []gormdb.PreloadEntity{}
+	var expandable []gormdb.PreloadEntity = []gormdb.PreloadEntity{}
+	return &PostgresAuditLogRepository{BaseRepository: NewBaseRepository[model.AuditLog](cfg, preloads, expandable)}
+}
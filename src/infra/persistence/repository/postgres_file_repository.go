@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/domain/model"
+	"github.com/minisource/template_go/pkg/keyset"
+	gormdb "github.com/minisource/go-common/db/gorm"
+	"github.com/minisource/go-common/filter"
+	"github.com/minisource/go-common/logging"
+	"gorm.io/gorm"
+)
+
+const createdByFilterExp string = "created_by = ?"
+const checksumFilterExp string = "checksum = ? and created_by = ? and parent_id is null and deleted_by is null"
+const originalFilterExp string = "parent_id is null"
+const variantFilterExp string = "parent_id = ? and variant = ? and deleted_by is null"
+// The variant status is part of the file, changing it bumps the version so ETags change
+const claimVariantsQuery string = `UPDATE files SET variant_status = ?, modified_at = ?, version = version + 1
+WHERE id IN (
+	SELECT id FROM files
+	WHERE deleted_by is null and (variant_status = ? or (variant_status = ? and modified_at < ?))
+	ORDER BY id
+	LIMIT ?
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING *`
+
+type PostgresFileRepository struct {
+	*BaseRepository[model.File]
+}
+
+func NewFileRepository(cfg *config.Config) *PostgresFileRepository {
+	var preloads []gormdb.PreloadEntity = []gormdb.PreloadEntity{{Entity: "Variants"}}
+	var expandable []gormdb.PreloadEntity = []gormdb.PreloadEntity{}
+	return &PostgresFileRepository{BaseRepository: NewBaseRepository[model.File](cfg, preloads, expandable)}
+}
+
+// GetByFilter lists uploaded files, their variants are only reachable through the original
+func (r *PostgresFileRepository) GetByFilter(ctx context.Context, req filter.PaginationInputWithFilter) (int64, *[]model.File, error) {
+	return r.getByFilter(ctx, req, originals)
+}
+
+func (r *PostgresFileRepository) GetByFilterCreatedBy(ctx context.Context, req filter.PaginationInputWithFilter, createdBy int) (int64, *[]model.File, error) {
+	return r.getByFilter(ctx, req, originals, func(db *gorm.DB) *gorm.DB {
+		return db.Where(createdByFilterExp, createdBy)
+	})
+}
+
+// GetByCursor lists uploaded files like GetByFilter
+func (r *PostgresFileRepository) GetByCursor(ctx context.Context, req keyset.Request) (*keyset.Page[model.File], error) {
+	return r.getByCursor(ctx, req, originals)
+}
+
+func (r *PostgresFileRepository) GetByCursorCreatedBy(ctx context.Context, req keyset.Request, createdBy int) (*keyset.Page[model.File], error) {
+	return r.getByCursor(ctx, req, originals, func(db *gorm.DB) *gorm.DB {
+		return db.Where(createdByFilterExp, createdBy)
+	})
+}
+
+// GetByChecksum only deduplicates within the caller's tenant
+func (r *PostgresFileRepository) GetByChecksum(ctx context.Context, checksum string, createdBy int) (*model.File, error) {
+	tenant, err := r.tenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var files []model.File
+	if err := r.db(ctx).
+		Scopes(tenant).
+		Where(checksumFilterExp, checksum, createdBy).
+		Limit(1).
+		Find(&files).
+		Error; err != nil {
+		r.logger.Error(logging.Postgres, logging.Select, err.Error(), nil)
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+	return &files[0], nil
+}
+
+func (r *PostgresFileRepository) GetVariant(ctx context.Context, parentId int, variant string) (*model.File, error) {
+	var files []model.File
+	if err := r.db(ctx).
+		Where(variantFilterExp, parentId, variant).
+		Limit(1).
+		Find(&files).
+		Error; err != nil {
+		r.logger.Error(logging.Postgres, logging.Select, err.Error(), nil)
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+	return &files[0], nil
+}
+
+func (r *PostgresFileRepository) ClaimPendingVariants(ctx context.Context, limit int, staleBefore time.Time) ([]model.File, error) {
+	var files []model.File
+	if err := r.db(ctx).
+		Raw(claimVariantsQuery, model.VariantStatusProcessing, time.Now().UTC(),
+			model.VariantStatusPending, model.VariantStatusProcessing, staleBefore, limit).
+		Scan(&files).
+		Error; err != nil {
+		r.logger.Error(logging.Postgres, logging.Update, err.Error(), nil)
+		return nil, err
+	}
+	return files, nil
+}
+
+// SetVariantStatus bumps the version, the variants attached since the claim are part of the file
+func (r *PostgresFileRepository) SetVariantStatus(ctx context.Context, id int, status string) error {
+	if err := r.db(ctx).
+		Model(&model.File{}).
+		Where(softDeleteExp, id).
+		Updates(map[string]interface{}{"variant_status": status, "version": gorm.Expr("version + 1")}).
+		Error; err != nil {
+		r.logger.Error(logging.Postgres, logging.Update, err.Error(), nil)
+		return err
+	}
+	return nil
+}
+
+func originals(db *gorm.DB) *gorm.DB {
+	return db.Where(originalFilterExp)
+}
@@ -3,10 +3,19 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"reflect"
+	"slices"
+	"strings"
 	"time"
 
-	"github.com/minisource/template_go/constant"
+	"github.com/minisource/template_go/domain/model"
+	"github.com/minisource/template_go/domain/principal"
+	"github.com/minisource/template_go/pkg/keyset"
+	"github.com/minisource/template_go/pkg/projection"
+	"github.com/minisource/template_go/pkg/search"
+	contractRepository "github.com/minisource/template_go/domain/repository"
 	"github.com/minisource/go-common/common"
 	gormdb "github.com/minisource/go-common/db/gorm"
 	"github.com/minisource/go-common/filter"
@@ -14,98 +23,473 @@ import (
 	"github.com/minisource/go-common/metrics"
 	"github.com/minisource/go-common/service_errors"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 		"github.com/minisource/template_go/config"
 )
 
 const softDeleteExp string = "id = ? and deleted_by is null"
+const idFilterExp string = "id = ?"
+const notDeletedExp string = "deleted_by is null"
+const deletedFilterExp string = "id = ? and deleted_by is not null"
+const purgeFilterExp string = "deleted_by is not null and deleted_at < ?"
+const tenantFilterExp string = "tenant_id = ?"
+const versionFilterExp string = "version = ?"
 
 type BaseRepository[TEntity any] struct {
-	database *gorm.DB
-	logger   logging.Logger
-	preloads []gormdb.PreloadEntity
+	database   *gorm.DB
+	logger     logging.Logger
+	preloads   []gormdb.PreloadEntity
+	expandable []gormdb.PreloadEntity // Loaded only when a read asks for them, see contractRepository.Expand
+	tenancy    bool                   // Rows are scoped by the caller's tenant
+	audit      bool                   // Changes are recorded in the audit log
+	entity     string
+	search     []search.Field
 }
 
-func NewBaseRepository[TEntity any](cfg *config.Config, preloads []gormdb.PreloadEntity) *BaseRepository[TEntity] {
+func NewBaseRepository[TEntity any](cfg *config.Config, preloads []gormdb.PreloadEntity, expandable []gormdb.PreloadEntity) *BaseRepository[TEntity] {
+	_, global := any(new(TEntity)).(model.Global)
+	_, unaudited := any(new(TEntity)).(model.Unaudited)
 	return &BaseRepository[TEntity]{
-		database: gormdb.GetDb(),
-		logger:   logging.NewLogger(&cfg.Logger),
-		preloads: preloads,
+		database:   gormdb.GetDb(),
+		logger:     logging.NewLogger(&cfg.Logger),
+		preloads:   preloads,
+		expandable: expandable,
+		tenancy:    cfg.Tenancy.Enabled && !global,
+		audit:      !unaudited,
+		entity:     reflect.TypeOf(*new(TEntity)).Name(),
+		search:     search.Fields[TEntity](),
 	}
 }
 
+// tenantScope narrows a query to the caller's tenant. System principals without a tenant
+// see every tenant, any other caller without one is refused.
+func (r BaseRepository[TEntity]) tenantScope(ctx context.Context) (func(*gorm.DB) *gorm.DB, error) {
+	if !r.tenancy {
+		return unscoped, nil
+	}
+	caller, ok := principal.FromContext(ctx)
+	switch {
+	case ok && caller.TenantId != "":
+		return func(db *gorm.DB) *gorm.DB {
+			return db.Where(tenantFilterExp, caller.TenantId)
+		}, nil
+	case ok && caller.System:
+		return unscoped, nil
+	}
+	return nil, &service_errors.ServiceError{EndUserMessage: service_errors.PermissionDenied}
+}
+
+// db joins the unit of work running in ctx, if any
+func (r BaseRepository[TEntity]) db(ctx context.Context) *gorm.DB {
+	return conn(ctx, r.database)
+}
+
+func unscoped(db *gorm.DB) *gorm.DB {
+	return db
+}
+
+// searchScope keeps the rows matching the full-text query of ctx, lists of entities
+// without searchable fields cannot be searched
+func (r BaseRepository[TEntity]) searchScope(ctx context.Context) (func(*gorm.DB) *gorm.DB, error) {
+	q := contractRepository.SearchQuery(ctx)
+	if q == "" {
+		return unscoped, nil
+	}
+	if len(r.search) == 0 {
+		return nil, search.ErrNotSearchable
+	}
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(search.Match, q)
+	}, nil
+}
+
+// selectColumns selects the projected columns, nil for all of them, and the rank and snippets
+// of the searched rows into model.SearchHit
+func (r BaseRepository[TEntity]) selectColumns(ctx context.Context, db *gorm.DB, columns []string) *gorm.DB {
+	q := contractRepository.SearchQuery(ctx)
+	switch {
+	case q == "" && columns == nil:
+		return db
+	case q == "":
+		return db.Select(columns)
+	case columns == nil:
+		selected, args := search.Select(r.search, q)
+		return db.Select(selected, args...)
+	}
+	hits, args := search.Hits(r.search, q)
+	return db.Select(strings.Join(columns, ", ")+", "+hits, args...)
+}
+
+// deletedScope hides soft-deleted rows unless the context asks for them
+func deletedScope(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	if contractRepository.IncludesDeleted(ctx) {
+		return unscoped
+	}
+	return notDeleted
+}
+
+func notDeleted(db *gorm.DB) *gorm.DB {
+	return db.Where(notDeletedExp)
+}
+
+// preload loads the relations of the entity, their soft-deleted rows are hidden like the entity's own
+func (r BaseRepository[TEntity]) preload(ctx context.Context, db *gorm.DB) *gorm.DB {
+	scope := deletedScope(ctx)
+	for _, entity := range r.preloads {
+		db = db.Preload(entity.Entity, scope)
+	}
+	return db
+}
+
+// read starts a query that loads the relations the projection of ctx needs and the expanded ones.
+// The columns are nil unless ctx projects fields, the BaseModel columns and extra are always among them.
+func (r BaseRepository[TEntity]) read(ctx context.Context, extra ...string) (*gorm.DB, []string, error) {
+	fields := contractRepository.Projection(ctx)
+	relations, err := projection.Relations(entities(r.preloads), entities(r.expandable), fields, contractRepository.Expansions(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+	db := r.db(ctx)
+	scope := deletedScope(ctx)
+	for _, relation := range relations {
+		db = db.Preload(relation, scope)
+	}
+	if fields == nil {
+		return db, nil, nil
+	}
+
+	// Columns the entity does not have select nothing
+	keep := append(append(slices.Clone(fields), baseColumns...), extra...)
+	columns, err := projection.Columns[TEntity](keep)
+	if err != nil {
+		return nil, nil, err
+	}
+	return db, columns, nil
+}
+
+// baseColumns are needed by ETags, ownership checks and tenancy whatever the client asked for
+var baseColumns, _ = projection.Columns[model.BaseModel](nil)
+
+func entities(preloads []gormdb.PreloadEntity) []string {
+	names := make([]string, 0, len(preloads))
+	for _, preload := range preloads {
+		names = append(names, preload.Entity)
+	}
+	return names
+}
+
+// Create stamps the caller's tenant on the entity through the BaseModel hook
 func (r BaseRepository[TEntity]) Create(ctx context.Context, entity TEntity) (TEntity, error) {
-	tx := r.database.WithContext(ctx).Begin()
-	err := tx.
-		Create(&entity).
-		Error
+	if _, err := r.tenantScope(ctx); err != nil {
+		return entity, err
+	}
+	err := r.db(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&entity).Error; err != nil {
+			return err
+		}
+		id, tenantId := rowKey(entity)
+		return r.record(ctx, tx, model.AuditCreate, id, tenantId, nil)
+	})
 	if err != nil {
-		tx.Rollback()
 		r.logger.Error(logging.Postgres, logging.Insert, err.Error(), nil)
 		metrics.DbCall.WithLabelValues(reflect.TypeOf(entity).String(), "Create", "Failed").Inc()
 		return entity, err
 	}
-	tx.Commit()
 
 	metrics.DbCall.WithLabelValues(reflect.TypeOf(entity).String(), "Create", "Success").Inc()
 	return entity, nil
 }
 
-func (r BaseRepository[TEntity]) Update(ctx context.Context, id int, entity map[string]interface{}) (TEntity, error) {
+// Update is a compare-and-swap on the version column when version is set,
+// a stale version fails with ErrVersionConflict instead of overwriting newer changes.
+// Only the given columns are written, the reloaded row is returned with its preloads.
+func (r BaseRepository[TEntity]) Update(ctx context.Context, id int, version int, entity map[string]interface{}) (TEntity, error) {
 	snakeMap := map[string]interface{}{}
 	for k, v := range entity {
 		snakeMap[common.ToSnakeCase(k)] = v
 	}
-	snakeMap["modified_by"] = &sql.NullInt64{Int64: int64(ctx.Value(constant.UserIdKey).(float64)), Valid: true}
+	userId, ok := principal.UserId(ctx)
+	if !ok {
+		return *new(TEntity), &service_errors.ServiceError{EndUserMessage: service_errors.PermissionDenied}
+	}
+	tenant, err := r.tenantScope(ctx)
+	if err != nil {
+		return *new(TEntity), err
+	}
+	// The tenant of a row never changes and the version only moves forward
+	delete(snakeMap, "tenant_id")
+	fields := make([]string, 0, len(snakeMap))
+	for field := range snakeMap {
+		fields = append(fields, field)
+	}
+	snakeMap["version"] = gorm.Expr("version + 1")
+	snakeMap["modified_by"] = &sql.NullInt64{Int64: int64(userId), Valid: true}
 	snakeMap["modified_at"] = sql.NullTime{Valid: true, Time: time.Now().UTC()}
-	model := new(TEntity)
-	tx := r.database.WithContext(ctx).Begin()
-	if err := tx.Model(model).
+	row := new(TEntity)
+	err = r.db(ctx).Transaction(func(tx *gorm.DB) error {
+		before, err := r.auditFields(tx, tenant, id, fields, true)
+		if err != nil {
+			return err
+		}
+		query := tx.Model(row).
+			Scopes(tenant).
+			Where(softDeleteExp, id)
+		if version > 0 {
+			query = query.Where(versionFilterExp, version)
+		}
+		result := query.Updates(snakeMap)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return r.missingOrStale(tx, tenant, id)
+		}
+		// Reload in the same transaction so the caller gets the row this update produced
+		if err := r.preload(ctx, tx).
+			Where(softDeleteExp, id).
+			First(row).
+			Error; err != nil {
+			return err
+		}
+		after, err := r.auditFields(tx, tenant, id, fields, false)
+		if err != nil {
+			return err
+		}
+		_, tenantId := rowKey(*row)
+		return r.record(ctx, tx, model.AuditUpdate, id, tenantId, model.AuditChanges(before, after))
+	})
+	if err != nil {
+		if !errors.Is(err, contractRepository.ErrVersionConflict) {
+			r.logger.Error(logging.Postgres, logging.Update, err.Error(), nil)
+		}
+		metrics.DbCall.WithLabelValues(reflect.TypeOf(*row).String(), "Update", "Failed").Inc()
+		return *row, err
+	}
+	metrics.DbCall.WithLabelValues(reflect.TypeOf(*row).String(), "Update", "Success").Inc()
+	return *row, nil
+}
+
+// record appends an audit entry in the transaction of the change, so a change is never saved without it.
+// An empty tenantId lets the BaseModel hook take the caller's tenant.
+func (r BaseRepository[TEntity]) record(ctx context.Context, tx *gorm.DB, action string, id int, tenantId string, changes map[string]model.FieldChange) error {
+	if !r.audit {
+		return nil
+	}
+	caller, _ := principal.FromContext(ctx)
+	entry := model.AuditLog{
+		BaseModel: model.BaseModel{TenantId: tenantId},
+		Action:    action,
+		Entity:    r.entity,
+		EntityId:  id,
+		Username:  caller.Username,
+		Ip:        caller.Ip,
+		RequestId: caller.RequestId,
+	}
+	if len(changes) > 0 {
+		raw, err := json.Marshal(changes)
+		if err != nil {
+			return err
+		}
+		entry.Changes = raw
+	}
+	return tx.Create(&entry).Error
+}
+
+// auditFields reads the current values of the updated columns, lock holds the row until the update
+func (r BaseRepository[TEntity]) auditFields(tx *gorm.DB, tenant func(*gorm.DB) *gorm.DB, id int, fields []string, lock bool) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	if !r.audit || len(fields) == 0 {
+		return values, nil
+	}
+	query := tx.Model(new(TEntity)).
+		Select(fields).
+		Scopes(tenant).
+		Where(softDeleteExp, id)
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	err := query.Find(&values).Error
+	return values, err
+}
+
+// rowKey reads the id and tenant of an entity embedding BaseModel
+func rowKey(entity any) (int, string) {
+	v := reflect.Indirect(reflect.ValueOf(entity))
+	if v.Kind() != reflect.Struct {
+		return 0, ""
+	}
+	base, ok := v.FieldByName("BaseModel").Interface().(model.BaseModel)
+	if !ok {
+		return 0, ""
+	}
+	return base.Id, base.TenantId
+}
+
+// missingOrStale tells apart an update that found no row from one that lost the version race
+func (r BaseRepository[TEntity]) missingOrStale(tx *gorm.DB, tenant func(*gorm.DB) *gorm.DB, id int) error {
+	var count int64
+	if err := tx.
+		Model(new(TEntity)).
+		Scopes(tenant).
 		Where(softDeleteExp, id).
-		Updates(snakeMap).
+		Count(&count).
 		Error; err != nil {
-		tx.Rollback()
-		r.logger.Error(logging.Postgres, logging.Update, err.Error(), nil)
-		metrics.DbCall.WithLabelValues(reflect.TypeOf(*model).String(), "Update", "Failed").Inc()
-		return *model, err
+		return err
 	}
-	tx.Commit()
-	metrics.DbCall.WithLabelValues(reflect.TypeOf(*model).String(), "Update", "Success").Inc()
-	return *model, nil
+	if count > 0 {
+		return contractRepository.ErrVersionConflict
+	}
+	return &service_errors.ServiceError{EndUserMessage: service_errors.RecordNotFound}
 }
 
 func (r BaseRepository[TEntity]) Delete(ctx context.Context, id int) error {
-	tx := r.database.WithContext(ctx).Begin()
-
-	model := new(TEntity)
+	userId, ok := principal.UserId(ctx)
+	if !ok {
+		return &service_errors.ServiceError{EndUserMessage: service_errors.PermissionDenied}
+	}
+	tenant, err := r.tenantScope(ctx)
+	if err != nil {
+		return err
+	}
 
+	row := new(TEntity)
 	deleteMap := map[string]interface{}{
-		"deleted_by": &sql.NullInt64{Int64: int64(ctx.Value(constant.UserIdKey).(float64)), Valid: true},
+		"deleted_by": &sql.NullInt64{Int64: int64(userId), Valid: true},
 		"deleted_at": sql.NullTime{Valid: true, Time: time.Now().UTC()},
 	}
 
-	if ctx.Value(constant.UserIdKey) == nil {
-		return &service_errors.ServiceError{EndUserMessage: service_errors.PermissionDenied}
+	err = r.db(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.
+			Model(row).
+			Scopes(tenant).
+			Where(softDeleteExp, id).
+			Updates(deleteMap)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return &service_errors.ServiceError{EndUserMessage: service_errors.RecordNotFound}
+		}
+		// The entry takes the caller's tenant, which scoped the delete
+		return r.record(ctx, tx, model.AuditDelete, id, "", nil)
+	})
+	if err != nil {
+		r.logger.Error(logging.Postgres, logging.Update, err.Error(), nil)
+		metrics.DbCall.WithLabelValues(reflect.TypeOf(*row).String(), "Delete", "Failed").Inc()
+		return err
 	}
-	if cnt := tx.
-		Model(model).
-		Where(softDeleteExp, id).
-		Updates(deleteMap).
-		RowsAffected; cnt == 0 {
-		tx.Rollback()
-		r.logger.Error(logging.Postgres, logging.Update, service_errors.RecordNotFound, nil)
-		metrics.DbCall.WithLabelValues(reflect.TypeOf(*model).String(), "Delete", "Failed").Inc()
-		return &service_errors.ServiceError{EndUserMessage: service_errors.RecordNotFound}
-	}
-	tx.Commit()
-	metrics.DbCall.WithLabelValues(reflect.TypeOf(*model).String(), "Delete", "Success").Inc()
+	metrics.DbCall.WithLabelValues(reflect.TypeOf(*row).String(), "Delete", "Success").Inc()
 	return nil
 }
 
+// Restore brings back a soft-deleted row, it counts as a change and gets a new version
+func (r BaseRepository[TEntity]) Restore(ctx context.Context, id int) (TEntity, error) {
+	row := new(TEntity)
+	userId, ok := principal.UserId(ctx)
+	if !ok {
+		return *row, &service_errors.ServiceError{EndUserMessage: service_errors.PermissionDenied}
+	}
+	tenant, err := r.tenantScope(ctx)
+	if err != nil {
+		return *row, err
+	}
+	restoreMap := map[string]interface{}{
+		"deleted_by":  nil,
+		"deleted_at":  nil,
+		"version":     gorm.Expr("version + 1"),
+		"modified_by": &sql.NullInt64{Int64: int64(userId), Valid: true},
+		"modified_at": sql.NullTime{Valid: true, Time: time.Now().UTC()},
+	}
+
+	err = r.db(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.
+			Model(row).
+			Scopes(tenant).
+			Where(deletedFilterExp, id).
+			Updates(restoreMap)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return &service_errors.ServiceError{EndUserMessage: service_errors.RecordNotFound}
+		}
+		if err := r.preload(ctx, tx).
+			Where(softDeleteExp, id).
+			First(row).
+			Error; err != nil {
+			return err
+		}
+		_, tenantId := rowKey(*row)
+		return r.record(ctx, tx, model.AuditRestore, id, tenantId, nil)
+	})
+	if err != nil {
+		r.logger.Error(logging.Postgres, logging.Update, err.Error(), nil)
+		metrics.DbCall.WithLabelValues(reflect.TypeOf(*row).String(), "Restore", "Failed").Inc()
+		return *row, err
+	}
+	metrics.DbCall.WithLabelValues(reflect.TypeOf(*row).String(), "Restore", "Success").Inc()
+	return *row, nil
+}
+
+// Purge deletes the rows for good, newest ids first so rows referencing an older row of the same
+// table, such as image variants, go in the same batch as or before the row they reference
+func (r BaseRepository[TEntity]) Purge(ctx context.Context, before time.Time, limit int) ([]TEntity, error) {
+	var purged []TEntity
+	tenant, err := r.tenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = r.db(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Scopes(tenant).
+			Where(purgeFilterExp, before).
+			Order("id desc").
+			Limit(limit).
+			Find(&purged).
+			Error; err != nil {
+			return err
+		}
+		if len(purged) == 0 {
+			return nil
+		}
+		// BeforeDelete would stamp the rows as deleted now, they are gone either way
+		if err := tx.Session(&gorm.Session{SkipHooks: true}).Delete(&purged).Error; err != nil {
+			return err
+		}
+		for _, entity := range purged {
+			id, tenantId := rowKey(entity)
+			if err := r.record(ctx, tx, model.AuditPurge, id, tenantId, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		r.logger.Error(logging.Postgres, logging.Delete, err.Error(), nil)
+		metrics.DbCall.WithLabelValues(reflect.TypeOf(*new(TEntity)).String(), "Purge", "Failed").Inc()
+		return nil, err
+	}
+	metrics.DbCall.WithLabelValues(reflect.TypeOf(*new(TEntity)).String(), "Purge", "Success").Inc()
+	return purged, nil
+}
+
+// GetById skips soft-deleted rows unless the context includes them
 func (r BaseRepository[TEntity]) GetById(ctx context.Context, id int) (TEntity, error) {
 	model := new(TEntity)
-	db := gormdb.Preload(r.database, r.preloads)
-	err := db.
-		Where(softDeleteExp, id).
+	tenant, err := r.tenantScope(ctx)
+	if err != nil {
+		return *model, err
+	}
+	db, columns, err := r.read(ctx)
+	if err != nil {
+		return *model, err
+	}
+	if columns != nil {
+		db = db.Select(columns)
+	}
+	err = db.
+		Scopes(tenant, deletedScope(ctx)).
+		Where(idFilterExp, id).
 		First(model).
 		Error
 	if err != nil {
@@ -117,20 +501,46 @@ func (r BaseRepository[TEntity]) GetById(ctx context.Context, id int) (TEntity,
 }
 
 func (r BaseRepository[TEntity]) GetByFilter(ctx context.Context, req filter.PaginationInputWithFilter) (int64, *[]TEntity, error) {
+	return r.getByFilter(ctx, req)
+}
+
+// getByFilter runs the dynamic filter query narrowed by the tenant, the soft-delete filter and the
+// extra scopes, so specific repositories can restrict the rows a caller is allowed to list
+func (r BaseRepository[TEntity]) getByFilter(ctx context.Context, req filter.PaginationInputWithFilter, scopes ...func(*gorm.DB) *gorm.DB) (int64, *[]TEntity, error) {
 	model := new(TEntity)
 	var items *[]TEntity
 
-	db := gormdb.Preload(r.database, r.preloads)
+	tenant, err := r.tenantScope(ctx)
+	if err != nil {
+		return 0, &[]TEntity{}, err
+	}
+	matches, err := r.searchScope(ctx)
+	if err != nil {
+		return 0, &[]TEntity{}, err
+	}
+	scopes = append([]func(*gorm.DB) *gorm.DB{tenant, deletedScope(ctx), matches}, scopes...)
+
+	db, columns, err := r.read(ctx)
+	if err != nil {
+		return 0, &[]TEntity{}, err
+	}
 	query := gormdb.GenerateDynamicQuery[TEntity](&req.DynamicFilter)
 	sort := gormdb.GenerateDynamicSort[TEntity](&req.DynamicFilter)
 	var totalRows int64 = 0
 
 	db.
 		Model(model).
+		Scopes(scopes...).
 		Where(query).
 		Count(&totalRows)
 
-	err := db.
+	rows := r.selectColumns(ctx, db, columns)
+	if contractRepository.SearchQuery(ctx) != "" {
+		// Best matches first, the requested sort breaks ties
+		rows = rows.Order(search.RankOrder)
+	}
+	err = rows.
+		Scopes(scopes...).
 		Where(query).
 		Offset(req.GetOffset()).
 		Limit(req.GetPageSize()).
@@ -144,3 +554,89 @@ func (r BaseRepository[TEntity]) GetByFilter(ctx context.Context, req filter.Pag
 	return totalRows, items, err
 
 }
+
+func (r BaseRepository[TEntity]) GetByCursor(ctx context.Context, req keyset.Request) (*keyset.Page[TEntity], error) {
+	return r.getByCursor(ctx, req)
+}
+
+// getByCursor reads the page after (or before) the request cursor without OFFSET,
+// the rows are counted only when asked for. Scopes work as in getByFilter.
+func (r BaseRepository[TEntity]) getByCursor(ctx context.Context, req keyset.Request, scopes ...func(*gorm.DB) *gorm.DB) (*keyset.Page[TEntity], error) {
+	sort, err := keyset.ParseSort[TEntity](req.Sort)
+	if err != nil {
+		return nil, err
+	}
+	var values []interface{}
+	backward := false
+	if req.Cursor != "" {
+		if values, backward, err = sort.Decode(req.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	tenant, err := r.tenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := r.searchScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	scopes = append([]func(*gorm.DB) *gorm.DB{tenant, deletedScope(ctx), matches}, scopes...)
+
+	// The cursors are built from the sort keys, a projection must not leave them out
+	db, columns, err := r.read(ctx, sort.Columns()...)
+	if err != nil {
+		return nil, err
+	}
+	query := gormdb.GenerateDynamicQuery[TEntity](&req.DynamicFilter)
+	limit := req.Limit()
+	page := &keyset.Page[TEntity]{PageSize: limit}
+
+	if req.WithTotal {
+		var totalRows int64
+		if err := db.Model(new(TEntity)).Scopes(scopes...).Where(query).Count(&totalRows).Error; err != nil {
+			r.logger.Error(logging.Postgres, logging.Select, err.Error(), nil)
+			return nil, err
+		}
+		page.TotalRows = &totalRows
+	}
+
+	// Searching only filters, the rank is not a key the cursor could continue from
+	rows := r.selectColumns(ctx, db, columns).Scopes(scopes...).Where(query)
+	if values != nil {
+		after, args := sort.After(values, backward)
+		rows = rows.Where(after, args...)
+	}
+	// One extra row tells whether there is a further page
+	items := []TEntity{}
+	if err := rows.Order(sort.Order(backward)).Limit(limit + 1).Find(&items).Error; err != nil {
+		r.logger.Error(logging.Postgres, logging.Select, err.Error(), nil)
+		return nil, err
+	}
+	more := len(items) > limit
+	if more {
+		items = items[:limit]
+	}
+	if backward {
+		slices.Reverse(items)
+	}
+	page.Items = &items
+	if len(items) == 0 {
+		return page, nil
+	}
+
+	// Coming back from a later page there is always a next one, an earlier page exists
+	// when the request had a cursor going forward
+	if more || backward {
+		if page.NextCursor, err = sort.Cursor(items[len(items)-1], false); err != nil {
+			return nil, err
+		}
+	}
+	if (more && backward) || (!backward && values != nil) {
+		if page.PrevCursor, err = sort.Cursor(items[0], true); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
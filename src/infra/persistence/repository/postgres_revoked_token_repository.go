@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/domain/model"
+	gormdb "github.com/minisource/go-common/db/gorm"
+	"github.com/minisource/go-common/logging"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const tokenHashFilterExp string = "token_hash = ?"
+const expiresBeforeFilterExp string = "expires_at < ?"
+
+type PostgresRevokedTokenRepository struct {
+	database *gorm.DB
+	logger   logging.Logger
+}
+
+func NewRevokedTokenRepository(cfg *config.Config) *PostgresRevokedTokenRepository {
+	return &PostgresRevokedTokenRepository{
+		database: gormdb.GetDb(),
+		logger:   logging.NewLogger(&cfg.Logger),
+	}
+}
+
+func (r *PostgresRevokedTokenRepository) Revoke(ctx context.Context, token model.RevokedToken) (bool, error) {
+	result := conn(ctx, r.database).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "token_hash"}}, DoNothing: true}).
+		Create(&token)
+	if result.Error != nil {
+		r.logger.Error(logging.Postgres, logging.Insert, result.Error.Error(), nil)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *PostgresRevokedTokenRepository) Restore(ctx context.Context, tokenHash string) error {
+	if err := conn(ctx, r.database).
+		Where(tokenHashFilterExp, tokenHash).
+		Delete(&model.RevokedToken{}).
+		Error; err != nil {
+		r.logger.Error(logging.Postgres, logging.Delete, err.Error(), nil)
+		return err
+	}
+	return nil
+}
+
+func (r *PostgresRevokedTokenRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := conn(ctx, r.database).
+		Where(expiresBeforeFilterExp, before).
+		Delete(&model.RevokedToken{})
+	if result.Error != nil {
+		r.logger.Error(logging.Postgres, logging.Delete, result.Error.Error(), nil)
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/domain/model"
+	gormdb "github.com/minisource/go-common/db/gorm"
+	"github.com/minisource/go-common/logging"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const keyFilterExp string = "key = ? and deleted_by is null"
+const uploadSessionIdFilterExp string = "upload_session_id = ?"
+const sessionStatusFilterExp string = "id = ? and status = ?"
+const expiredSessionFilterExp string = "status in ? and expires_at < ?"
+
+type PostgresUploadSessionRepository struct {
+	*BaseRepository[model.UploadSession]
+}
+
+func NewUploadSessionRepository(cfg *config.Config) *PostgresUploadSessionRepository {
+	var preloads []gormdb.PreloadEntity = []gormdb.PreloadEntity{}
+	var expandable []gormdb.PreloadEntity = []gormdb.PreloadEntity{}
+	return &PostgresUploadSessionRepository{BaseRepository: NewBaseRepository[model.UploadSession](cfg, preloads, expandable)}
+}
+
+func (r *PostgresUploadSessionRepository) GetByKey(ctx context.Context, key string) (model.UploadSession, error) {
+	var session model.UploadSession
+	tenant, err := r.tenantScope(ctx)
+	if err != nil {
+		return session, err
+	}
+	if err := r.db(ctx).
+		Scopes(tenant).
+		Preload("Parts", func(db *gorm.DB) *gorm.DB {
+			return db.Order("number")
+		}).
+		Where(keyFilterExp, key).
+		First(&session).
+		Error; err != nil {
+		r.logger.Error(logging.Postgres, logging.Select, err.Error(), nil)
+		return session, err
+	}
+	return session, nil
+}
+
+func (r *PostgresUploadSessionRepository) SavePart(ctx context.Context, part model.UploadPart, expiresAt time.Time) error {
+	err := r.db(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "upload_session_id"}, {Name: "number"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"size": part.Size, "modified_at": time.Now().UTC()}),
+		}).Create(&part).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.UploadSession{}).
+			Where(sessionStatusFilterExp, part.UploadSessionId, model.UploadSessionPending).
+			Update("expires_at", expiresAt).
+			Error
+	})
+	if err != nil {
+		r.logger.Error(logging.Postgres, logging.Insert, err.Error(), nil)
+		return err
+	}
+	return nil
+}
+
+func (r *PostgresUploadSessionRepository) ChangeStatus(ctx context.Context, id int, from string, to string, expiresAt time.Time) (bool, error) {
+	result := r.db(ctx).Model(&model.UploadSession{}).
+		Where(sessionStatusFilterExp, id, from).
+		Updates(map[string]interface{}{"status": to, "expires_at": expiresAt, "modified_at": time.Now().UTC()})
+	if result.Error != nil {
+		r.logger.Error(logging.Postgres, logging.Update, result.Error.Error(), nil)
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *PostgresUploadSessionRepository) Close(ctx context.Context, id int, from string, status string, fileId *int) (bool, error) {
+	closed := false
+	err := r.db(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.UploadSession{}).
+			Where(sessionStatusFilterExp, id, from).
+			Updates(map[string]interface{}{"status": status, "file_id": fileId, "modified_at": time.Now().UTC()})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		closed = true
+		return tx.Where(uploadSessionIdFilterExp, id).Delete(&model.UploadPart{}).Error
+	})
+	if err != nil {
+		r.logger.Error(logging.Postgres, logging.Update, err.Error(), nil)
+		return false, err
+	}
+	return closed, nil
+}
+
+func (r *PostgresUploadSessionRepository) GetExpired(ctx context.Context, before time.Time, limit int) ([]model.UploadSession, error) {
+	var sessions []model.UploadSession
+	if err := r.db(ctx).
+		Preload("Parts").
+		Where(expiredSessionFilterExp, []string{model.UploadSessionPending, model.UploadSessionCompleting}, before).
+		Order("expires_at").
+		Limit(limit).
+		Find(&sessions).
+		Error; err != nil {
+		r.logger.Error(logging.Postgres, logging.Select, err.Error(), nil)
+		return nil, err
+	}
+	return sessions, nil
+}
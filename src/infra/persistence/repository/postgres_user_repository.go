@@ -2,41 +2,59 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/minisource/template_go/config"
 	"github.com/minisource/template_go/domain/model"
 	gormdb "github.com/minisource/go-common/db/gorm"
 	"github.com/minisource/go-common/logging"
+	"gorm.io/gorm"
 )
 
 const userIdFilterExp string = "user_id = ?"
 const countFilterExp string = "count(*) > 0"
+const keepWhenSetExp string = "CASE WHEN %s = '' THEN ? ELSE %s END"
+const lockUserIdQuery string = "SELECT pg_advisory_xact_lock(hashtext('users.user_id'), hashtext(?))"
 
 type PostgresUserRepository struct {
 	*BaseRepository[model.User]
 }
 
 func NewUserRepository(cfg *config.Config) *PostgresUserRepository {
-	var preloads []gormdb.PreloadEntity = []gormdb.PreloadEntity{}
-	return &PostgresUserRepository{BaseRepository: NewBaseRepository[model.User](cfg, preloads)}
+	var preloads []gormdb.PreloadEntity = []gormdb.PreloadEntity{{Entity: "Avatar"}}
+	// The avatar thumbnails, for profile screens
+	var expandable []gormdb.PreloadEntity = []gormdb.PreloadEntity{{Entity: "Avatar.Variants"}}
+	return &PostgresUserRepository{BaseRepository: NewBaseRepository[model.User](cfg, preloads, expandable)}
 }
 
 func (r *PostgresUserRepository) CreateUser(ctx context.Context, u model.User) (model.User, error) {
-	tx := r.database.WithContext(ctx).Begin()
-	err := tx.Create(&u).Error
+	err := r.db(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&u).Error
+	})
 	if err != nil {
-		tx.Rollback()
 		r.logger.Error(logging.Postgres, logging.Rollback, err.Error(), nil)
 		return u, err
 	}
-	tx.Commit()
 	return u, nil
 }
 
+// LockUserId holds a transaction-level advisory lock on the auth service user id until the
+// unit of work in ctx ends, so concurrent first logins register the account once
+func (r *PostgresUserRepository) LockUserId(ctx context.Context, userId string) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); !ok {
+		return errNoUnitOfWork
+	}
+	if err := r.db(ctx).Exec(lockUserIdQuery, userId).Error; err != nil {
+		r.logger.Error(logging.Postgres, logging.Select, err.Error(), nil)
+		return err
+	}
+	return nil
+}
 
 func (r *PostgresUserRepository) ExistsUserId(ctx context.Context, userId string) (bool, error) {
 	var exists bool
-	if err := r.database.WithContext(ctx).Model(&model.User{}).
+	if err := r.db(ctx).Model(&model.User{}).
 		Select(countFilterExp).
 		Where(userIdFilterExp, userId).
 		Find(&exists).
@@ -46,3 +64,38 @@ func (r *PostgresUserRepository) ExistsUserId(ctx context.Context, userId string
 	}
 	return exists, nil
 }
+
+// GetByUserId finds no deleted user, ExistsUserId still reports them so no second row is created at login
+func (r *PostgresUserRepository) GetByUserId(ctx context.Context, userId string) (model.User, error) {
+	var user model.User
+	if err := r.db(ctx).
+		Scopes(notDeleted).
+		Where(userIdFilterExp, userId).
+		First(&user).
+		Error; err != nil {
+		r.logger.Error(logging.Postgres, logging.Select, err.Error(), nil)
+		return user, err
+	}
+	return user, nil
+}
+
+// SyncProfile runs at login, when the context carries no user, so the audit columns are left alone
+func (r *PostgresUserRepository) SyncProfile(ctx context.Context, id int, account model.User) (model.User, error) {
+	columns := map[string]interface{}{
+		"username":     account.Username,
+		"email":        account.Email,
+		"phone":        account.Phone,
+		"display_name": gorm.Expr(fmt.Sprintf(keepWhenSetExp, "display_name", "display_name"), account.DisplayName),
+		"locale":       gorm.Expr(fmt.Sprintf(keepWhenSetExp, "locale", "locale"), account.Locale),
+		"synced_at":    time.Now().UTC(),
+	}
+	if err := r.db(ctx).
+		Model(&model.User{}).
+		Where(softDeleteExp, id).
+		UpdateColumns(columns).
+		Error; err != nil {
+		r.logger.Error(logging.Postgres, logging.Update, err.Error(), nil)
+		return model.User{}, err
+	}
+	return r.GetById(ctx, id)
+}
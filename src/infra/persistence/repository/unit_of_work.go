@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	gormdb "github.com/minisource/go-common/db/gorm"
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+// errNoUnitOfWork is returned by methods whose effect lasts until the end of the unit of work
+var errNoUnitOfWork = errors.New("must run in a unit of work")
+
+type PostgresUnitOfWork struct {
+	database *gorm.DB
+}
+
+func NewUnitOfWork() *PostgresUnitOfWork {
+	return &PostgresUnitOfWork{database: gormdb.GetDb()}
+}
+
+// Do commits when fn returns nil and rolls back on an error or a panic.
+// gorm turns a transaction started inside another one into a savepoint.
+func (u *PostgresUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return conn(ctx, u.database).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// conn returns the transaction of the unit of work running in ctx, or database outside of one
+func conn(ctx context.Context, database *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return database.WithContext(ctx)
+}
@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepEvery is the number of increments between two removals of expired counters
+const sweepEvery = 1024
+
+type counter struct {
+	count     int64
+	expiresAt time.Time
+}
+
+// MemoryStore keeps counters in the process memory
+type MemoryStore struct {
+	mu         sync.Mutex
+	counters   map[string]counter
+	increments int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: map[string]counter{}}
+}
+
+func (s *MemoryStore) Increment(_ context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.increments++
+	if s.increments%sweepEvery == 0 {
+		s.sweep(now)
+	}
+
+	c, ok := s.counters[key]
+	if !ok || !now.Before(c.expiresAt) {
+		c = counter{expiresAt: now.Add(window)}
+	}
+	c.count++
+	s.counters[key] = c
+	return c.count, c.expiresAt.Sub(now), nil
+}
+
+func (s *MemoryStore) TTL(_ context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.counters[key]
+	if !ok {
+		return 0, nil
+	}
+	return max(0, time.Until(c.expiresAt)), nil
+}
+
+func (s *MemoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.counters, key)
+	return nil
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, c := range s.counters {
+		if !now.Before(c.expiresAt) {
+			delete(s.counters, key)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/domain/ratelimit"
+	"github.com/minisource/template_go/infra/cache"
+)
+
+var store ratelimit.Store
+
+// InitStore creates the counter store selected by configuration,
+// the memory store only limits a single instance of the service
+func InitStore(cfg *config.Config) error {
+	switch strings.ToLower(cfg.OtpLimit.Store) {
+	case "", "memory":
+		store = NewMemoryStore()
+	case "redis":
+		if err := cache.InitRedis(&cfg.Redis); err != nil {
+			return err
+		}
+		store = NewRedisStore(cache.GetRedis())
+	default:
+		return fmt.Errorf("unknown rate limit store %q", cfg.OtpLimit.Store)
+	}
+	return nil
+}
+
+func GetStore() ratelimit.Store {
+	return store
+}
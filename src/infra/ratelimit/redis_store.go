@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// incrementScript sets the expiry with the first increment so a counter never outlives its window
+var incrementScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return {count, redis.call('PTTL', KEYS[1])}
+`)
+
+// RedisStore shares counters between every instance of the service
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) Increment(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	values, err := incrementScript.Run(ctx, s.client, []string{key}, window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return 0, 0, err
+	}
+	return values[0], time.Duration(values[1]) * time.Millisecond, nil
+}
+
+func (s *RedisStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := s.client.PTTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// Negative values mean the key does not exist or has no expiry
+	return max(0, ttl), nil
+}
+
+func (s *RedisStore) Delete(ctx context.Context, key string) error {
+	return s.client.Del(ctx, key).Err()
+}
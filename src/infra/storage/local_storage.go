@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/domain/storage"
+)
+
+// LocalStorage keeps blobs on the local disk under a root directory
+type LocalStorage struct {
+	root string
+}
+
+func NewLocalStorage(cfg *config.LocalStorageConfig) (*LocalStorage, error) {
+	root := cfg.RootDirectory
+	if root == "" {
+		root = "."
+	}
+	if err := os.MkdirAll(root, os.ModePerm); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{root: root}, nil
+}
+
+func (s *LocalStorage) Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+	dst, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so readers never see a partial blob
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = io.Copy(tmp, content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+func (s *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	src, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(src)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, storage.ErrObjectNotFound
+	}
+	return file, err
+}
+
+func (s *LocalStorage) GetRange(ctx context.Context, key string, offset int64, length int64) (io.ReadCloser, error) {
+	reader, err := s.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	file := reader.(*os.File)
+	if _, err = file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &limitedReadCloser{Reader: io.LimitReader(file, length), Closer: file}, nil
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	src, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(src)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *LocalStorage) Stat(ctx context.Context, key string) (storage.ObjectInfo, error) {
+	src, err := s.path(key)
+	if err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	info, err := os.Stat(src)
+	if errors.Is(err, fs.ErrNotExist) {
+		return storage.ObjectInfo{}, storage.ErrObjectNotFound
+	}
+	if err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	return storage.ObjectInfo{
+		Key:          key,
+		Size:         info.Size(),
+		ContentType:  mime.TypeByExtension(path.Ext(key)),
+		ETag:         fmt.Sprintf("%x-%x", info.ModTime().UnixNano(), info.Size()),
+		LastModified: info.ModTime().UTC(),
+	}, nil
+}
+
+// Presign is not available for local disk, files must be streamed through the api
+func (s *LocalStorage) Presign(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	return "", storage.ErrPresignNotSupported
+}
+
+// path maps a key to a file under the root and rejects keys that are not canonical,
+// which also prevents escaping the root with ".." segments
+func (s *LocalStorage) path(key string) (string, error) {
+	if key == "" || path.Clean("/"+key) != "/"+key {
+		return "", storage.ErrInvalidObjectKey
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/domain/storage"
+)
+
+// S3Storage keeps blobs in a bucket of an S3 compatible service such as AWS S3 or MinIO
+type S3Storage struct {
+	client *minio.Client
+	bucket string
+}
+
+func NewS3Storage(cfg *config.S3StorageConfig) (*S3Storage, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("s3 storage bucket is not configured")
+	}
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	exists, err := client.BucketExists(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if !cfg.CreateBucket {
+			return nil, errors.New("s3 storage bucket " + cfg.Bucket + " does not exist")
+		}
+		if err = client.MakeBucket(ctx, cfg.Bucket, minio.MakeBucketOptions{Region: cfg.Region}); err != nil {
+			return nil, err
+		}
+	}
+	return &S3Storage{client: client, bucket: cfg.Bucket}, nil
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, content, size, minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	object, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, translateS3Error(err)
+	}
+	// GetObject is lazy, stat it so a missing key fails here instead of on first read
+	if _, err = object.Stat(); err != nil {
+		object.Close()
+		return nil, translateS3Error(err)
+	}
+	return object, nil
+}
+
+func (s *S3Storage) GetRange(ctx context.Context, key string, offset int64, length int64) (io.ReadCloser, error) {
+	opts := minio.GetObjectOptions{}
+	if err := opts.SetRange(offset, offset+length-1); err != nil {
+		return nil, err
+	}
+	object, err := s.client.GetObject(ctx, s.bucket, key, opts)
+	if err != nil {
+		return nil, translateS3Error(err)
+	}
+	if _, err = object.Stat(); err != nil {
+		object.Close()
+		return nil, translateS3Error(err)
+	}
+	return object, nil
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (s *S3Storage) Stat(ctx context.Context, key string) (storage.ObjectInfo, error) {
+	info, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return storage.ObjectInfo{}, translateS3Error(err)
+	}
+	return storage.ObjectInfo{
+		Key:          key,
+		Size:         info.Size,
+		ContentType:  info.ContentType,
+		ETag:         info.ETag,
+		LastModified: info.LastModified.UTC(),
+	}, nil
+}
+
+func (s *S3Storage) Presign(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	presigned, err := s.client.PresignedGetObject(ctx, s.bucket, key, expiry, url.Values{})
+	if err != nil {
+		return "", err
+	}
+	return presigned.String(), nil
+}
+
+func translateS3Error(err error) error {
+	if minio.ToErrorResponse(err).StatusCode == http.StatusNotFound {
+		return storage.ErrObjectNotFound
+	}
+	return err
+}
@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/domain/storage"
+)
+
+var blobStorage storage.Storage
+
+// InitStorage creates the blob storage selected by configuration
+func InitStorage(cfg *config.StorageConfig) error {
+	var err error
+	switch strings.ToLower(cfg.Type) {
+	case "", "local":
+		blobStorage, err = NewLocalStorage(&cfg.Local)
+	case "s3":
+		blobStorage, err = NewS3Storage(&cfg.S3)
+	default:
+		err = fmt.Errorf("unknown storage type %q", cfg.Type)
+	}
+	return err
+}
+
+func GetStorage() storage.Storage {
+	return blobStorage
+}
@@ -0,0 +1,47 @@
+// Package job runs periodic background maintenance inside the API process.
+package job
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/minisource/go-common/logging"
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/domain/principal"
+)
+
+const jobSubCategory logging.SubCategory = "Job"
+
+// Job is a task repeated every Interval, the first run happens right after startup
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Start runs every registered job in its own goroutine until ctx is canceled.
+// Runs of the same job never overlap, a slow run delays the next one.
+// Jobs act as the system principal named after the job.
+func Start(ctx context.Context, cfg *config.Config) {
+	logger := logging.NewLogger(&cfg.Logger)
+	for _, job := range jobs(cfg) {
+		go run(ctx, logger, job)
+	}
+}
+
+func run(ctx context.Context, logger logging.Logger, job Job) {
+	ctx = principal.WithPrincipal(ctx, principal.System(job.Name))
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+	for {
+		if err := job.Run(ctx); err != nil {
+			logger.Error(logging.Internal, jobSubCategory, fmt.Sprintf("job %s failed: %s", job.Name, err.Error()), nil)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
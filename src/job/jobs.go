@@ -0,0 +1,24 @@
+package job
+
+import (
+	"time"
+
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/dependency"
+	"github.com/minisource/template_go/usecase"
+)
+
+// jobs lists the background jobs, register new ones here
+func jobs(cfg *config.Config) []Job {
+	files := usecase.NewFileUsecase(cfg, dependency.GetFileRepository(cfg), dependency.GetUnitOfWork(), dependency.GetStorage())
+	uploads := usecase.NewUploadSessionUsecase(cfg, dependency.GetUploadSessionRepository(cfg), files, dependency.GetStorage())
+	images := usecase.NewImageVariantUsecase(cfg, dependency.GetFileRepository(cfg), dependency.GetStorage())
+	tokens := usecase.NewTokenUsecase(cfg, dependency.GetRevokedTokenRepository(cfg))
+
+	return []Job{
+		{Name: "expire-upload-sessions", Interval: cfg.Upload.Resumable.Interval(), Run: uploads.CleanupExpired},
+		{Name: "render-image-variants", Interval: cfg.Images.Interval(), Run: images.ProcessPending},
+		{Name: "delete-expired-revocations", Interval: time.Hour, Run: tokens.CleanupExpired},
+		{Name: "purge-deleted-files", Interval: cfg.SoftDelete.Interval(), Run: files.PurgeDeleted},
+	}
+}
@@ -0,0 +1,149 @@
+// Package imaging renders resized and re-encoded variants of uploaded images.
+// Decoding supports JPEG, PNG, GIF (first frame) and WebP, encoding JPEG, PNG and lossless WebP.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"slices"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	FormatJPEG string = "jpeg"
+	FormatPNG  string = "png"
+	FormatWebP string = "webp"
+	FormatGIF  string = "gif"
+)
+
+// DefaultMaxPixels protects decoding from images whose header claims huge dimensions (50 MP)
+const DefaultMaxPixels int = 50_000_000
+
+const defaultJPEGQuality int = 85
+
+var (
+	ErrUnsupportedFormat = errors.New("image format is not supported")
+	ErrTooManyPixels     = errors.New("image dimensions are too large")
+)
+
+var supportedTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+
+// Variant describes a derived image. The source is scaled down to fit inside Width x Height
+// keeping its aspect ratio, a zero dimension is unconstrained and images are never enlarged.
+// An empty Format keeps the source format, GIF sources become PNG.
+type Variant struct {
+	Name    string
+	Width   int
+	Height  int
+	Format  string // jpeg, png or webp
+	Quality int    // JPEG quality 1-100 (default: 85)
+}
+
+// Image is an encoded variant
+type Image struct {
+	Content   []byte
+	MimeType  string
+	Extension string
+	Width     int
+	Height    int
+}
+
+// Supported reports whether variants can be rendered from a MIME type
+func Supported(mimeType string) bool {
+	return slices.Contains(supportedTypes, mimeType)
+}
+
+// Decode checks the declared dimensions before decoding the whole image.
+// maxPixels <= 0 uses DefaultMaxPixels.
+func Decode(content io.ReadSeeker, maxPixels int) (image.Image, string, error) {
+	if maxPixels <= 0 {
+		maxPixels = DefaultMaxPixels
+	}
+	config, format, err := image.DecodeConfig(content)
+	if errors.Is(err, image.ErrFormat) {
+		return nil, "", ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, "", ErrTooManyPixels
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return nil, "", err
+	}
+	img, format, err := image.Decode(content)
+	if err != nil {
+		return nil, "", err
+	}
+	return img, format, nil
+}
+
+// Render scales and encodes a decoded image as described by the variant
+func Render(src image.Image, sourceFormat string, v Variant) (Image, error) {
+	format := v.Format
+	if format == "" {
+		format = sourceFormat
+	}
+	if format == FormatGIF {
+		format = FormatPNG
+	}
+
+	width, height := fit(src.Bounds().Dx(), src.Bounds().Dy(), v.Width, v.Height)
+	var img image.Image = src
+	if width != src.Bounds().Dx() || height != src.Bounds().Dy() {
+		dst := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Over, nil)
+		img = dst
+	}
+
+	var buf bytes.Buffer
+	var err error
+	res := Image{Width: width, Height: height}
+	switch format {
+	case FormatJPEG:
+		quality := v.Quality
+		if quality <= 0 || quality > 100 {
+			quality = defaultJPEGQuality
+		}
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+		res.MimeType, res.Extension = "image/jpeg", ".jpg"
+	case FormatPNG:
+		err = png.Encode(&buf, img)
+		res.MimeType, res.Extension = "image/png", ".png"
+	case FormatWebP:
+		err = nativewebp.Encode(&buf, img, nil)
+		res.MimeType, res.Extension = "image/webp", ".webp"
+	default:
+		return Image{}, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+	if err != nil {
+		return Image{}, err
+	}
+	res.Content = buf.Bytes()
+	return res, nil
+}
+
+// fit returns the largest size inside the box keeping the aspect ratio, without upscaling
+func fit(width, height, maxWidth, maxHeight int) (int, int) {
+	scale := 1.0
+	if maxWidth > 0 && width > maxWidth {
+		scale = min(scale, float64(maxWidth)/float64(width))
+	}
+	if maxHeight > 0 && height > maxHeight {
+		scale = min(scale, float64(maxHeight)/float64(height))
+	}
+	if scale == 1.0 {
+		return width, height
+	}
+	return max(1, int(float64(width)*scale+0.5)), max(1, int(float64(height)*scale+0.5))
+}
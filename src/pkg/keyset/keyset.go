@@ -0,0 +1,231 @@
+// Package keyset pages through sorted rows with opaque cursors instead of OFFSET.
+//
+// A cursor holds the sort key values of the row a page ended (or started) at, the next
+// page continues strictly after those values. The row id is always the last sort key,
+// so the order is total and no row is skipped or repeated when rows are inserted.
+package keyset
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/minisource/go-common/filter"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	DefaultPageSize int = 10
+	MaxPageSize     int = 100
+)
+
+const idField string = "Id"
+
+var ErrInvalidCursor = errors.New("cursor is malformed or was issued for another sort")
+var ErrInvalidSort = errors.New("keyset pages can only be sorted by known columns that are never null")
+
+// Request asks for the page after Cursor, or the first page when it is empty
+type Request struct {
+	filter.DynamicFilter
+	PageSize  int    `json:"pageSize"`
+	Cursor    string `json:"cursor"`
+	WithTotal bool   `json:"withTotal"` // Also count the matching rows, costs a full scan
+}
+
+// Limit is the page size bounded to [1, MaxPageSize]
+func (r Request) Limit() int {
+	switch {
+	case r.PageSize <= 0:
+		return DefaultPageSize
+	case r.PageSize > MaxPageSize:
+		return MaxPageSize
+	}
+	return r.PageSize
+}
+
+// Page is a window of rows, an empty cursor means there is nothing further in that direction
+type Page[T any] struct {
+	PageSize   int    `json:"pageSize"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	PrevCursor string `json:"prevCursor,omitempty"`
+	TotalRows  *int64 `json:"totalRows,omitempty"`
+	Items      *[]T   `json:"items"`
+}
+
+// Map converts the items of a page and keeps its cursors
+func Map[T any, U any](page *Page[T], mapper func(T) U) *Page[U] {
+	items := make([]U, 0, len(*page.Items))
+	for _, item := range *page.Items {
+		items = append(items, mapper(item))
+	}
+	return &Page[U]{
+		PageSize:   page.PageSize,
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+		TotalRows:  page.TotalRows,
+		Items:      &items,
+	}
+}
+
+type key struct {
+	column string
+	desc   bool
+	index  []int
+	typ    reflect.Type
+}
+
+// Sort is the resolved order of a keyset request, ending with the id
+type Sort []key
+
+// cursor is the JSON behind the opaque string, Sort ties it to the order it was issued for
+type cursor struct {
+	Sort     string            `json:"s"`
+	Values   []json.RawMessage `json:"v"`
+	Backward bool              `json:"b,omitempty"`
+}
+
+// ParseSort resolves the requested sort against the fields of T. Nullable fields are refused,
+// NULL cannot be compared with the cursor values.
+func ParseSort[T any](sorts *[]filter.Sort) (Sort, error) {
+	entity := reflect.TypeOf(*new(T))
+	var keys Sort
+	hasId := false
+	if sorts != nil {
+		for _, s := range *sorts {
+			field, ok := entity.FieldByNameFunc(func(name string) bool {
+				return strings.EqualFold(name, s.ColId)
+			})
+			if !ok || !orderable(field.Type) || !stored(field) {
+				return nil, ErrInvalidSort
+			}
+			direction := strings.ToLower(s.Sort)
+			if direction != "asc" && direction != "desc" {
+				return nil, ErrInvalidSort
+			}
+			keys = append(keys, newKey(field, direction == "desc"))
+			hasId = hasId || field.Name == idField
+		}
+	}
+	if !hasId {
+		field, ok := entity.FieldByName(idField)
+		if !ok {
+			return nil, ErrInvalidSort
+		}
+		keys = append(keys, newKey(field, false))
+	}
+	return keys, nil
+}
+
+func newKey(field reflect.StructField, desc bool) key {
+	column := schema.ParseTagSetting(field.Tag.Get("gorm"), ";")["COLUMN"]
+	if column == "" {
+		column = schema.NamingStrategy{}.ColumnName("", field.Name)
+	}
+	return key{column: column, desc: desc, index: field.Index, typ: field.Type}
+}
+
+// stored is false for fields that are only read from computed columns, such as the search rank
+func stored(field reflect.StructField) bool {
+	_, ignored := schema.ParseTagSetting(field.Tag.Get("gorm"), ";")["-"]
+	return !ignored
+}
+
+func orderable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return t == reflect.TypeOf(time.Time{})
+}
+
+func (s Sort) String() string {
+	return s.Order(false)
+}
+
+// Columns are the sort keys, a projected query must select them to build cursors
+func (s Sort) Columns() []string {
+	columns := make([]string, 0, len(s))
+	for _, k := range s {
+		columns = append(columns, k.column)
+	}
+	return columns
+}
+
+// Order is the ORDER BY clause, reversed to read the rows before a cursor
+func (s Sort) Order(backward bool) string {
+	columns := make([]string, 0, len(s))
+	for _, k := range s {
+		direction := "asc"
+		if k.desc != backward {
+			direction = "desc"
+		}
+		columns = append(columns, k.column+" "+direction)
+	}
+	return strings.Join(columns, ", ")
+}
+
+// After is the condition for the rows past values in the read direction:
+// (a > ?) or (a = ? and b > ?) ..., with < for descending keys
+func (s Sort) After(values []interface{}, backward bool) (string, []interface{}) {
+	var terms []string
+	var args []interface{}
+	for i, k := range s {
+		var term []string
+		for _, prior := range s[:i] {
+			term = append(term, prior.column+" = ?")
+		}
+		operator := ">"
+		if k.desc != backward {
+			operator = "<"
+		}
+		term = append(term, k.column+" "+operator+" ?")
+		terms = append(terms, "("+strings.Join(term, " and ")+")")
+		args = append(args, values[:i+1]...)
+	}
+	return "(" + strings.Join(terms, " or ") + ")", args
+}
+
+// Cursor points past row, backward cursors read the page before it
+func (s Sort) Cursor(row interface{}, backward bool) (string, error) {
+	value := reflect.Indirect(reflect.ValueOf(row))
+	c := cursor{Sort: s.String(), Backward: backward}
+	for _, k := range s {
+		raw, err := json.Marshal(value.FieldByIndex(k.index).Interface())
+		if err != nil {
+			return "", err
+		}
+		c.Values = append(c.Values, raw)
+	}
+	body, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(body), nil
+}
+
+// Decode reads the key values of a cursor issued by Cursor for the same sort
+func (s Sort) Decode(encoded string) (values []interface{}, backward bool, err error) {
+	body, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, false, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(body, &c); err != nil || c.Sort != s.String() || len(c.Values) != len(s) {
+		return nil, false, ErrInvalidCursor
+	}
+	for i, k := range s {
+		value := reflect.New(k.typ)
+		if err := json.Unmarshal(c.Values[i], value.Interface()); err != nil {
+			return nil, false, fmt.Errorf("%w: %s", ErrInvalidCursor, k.column)
+		}
+		values = append(values, value.Elem().Interface())
+	}
+	return values, c.Backward, nil
+}
@@ -0,0 +1,263 @@
+// Package mapgen writes typed mapping functions between structs, so the usecases convert
+// entities and DTOs with plain assignments instead of a JSON round trip at run time.
+// Fields are matched by name, through embedded structs. See cmd/mapgen.
+package mapgen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"path"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+var ErrUnmappable = errors.New("cannot map field")
+
+var majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// Func is a function to generate, see Map and Columns
+type Func struct {
+	Name string
+	From reflect.Type
+	To   reflect.Type // Nil for Columns
+}
+
+// Map generates func name(from TFrom) TTo. A field of TTo is assigned the field of TFrom with its
+// name when the types are the same or convertible, or when another Func maps between them; this
+// includes pointers and slices of them. Fields of TTo without a source are left unset.
+func Map[TFrom any, TTo any](name string) Func {
+	return Func{Name: name, From: reflect.TypeFor[TFrom](), To: reflect.TypeFor[TTo]()}
+}
+
+// Columns generates func name(from TFrom) map[string]interface{}, the fields by Go field name
+// as the repositories update them
+func Columns[TFrom any](name string) Func {
+	return Func{Name: name, From: reflect.TypeFor[TFrom]()}
+}
+
+type generator struct {
+	pkgPath string
+	imports map[string]string // Path to name
+	funcs   map[[2]reflect.Type]string
+	body    bytes.Buffer
+}
+
+// Generate writes the source of the functions in the package at pkgPath
+func Generate(pkgPath string, funcs ...Func) ([]byte, error) {
+	g := &generator{pkgPath: pkgPath, imports: map[string]string{}, funcs: map[[2]reflect.Type]string{}}
+	for _, fn := range funcs {
+		if fn.From.Kind() != reflect.Struct || fn.To != nil && fn.To.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("%s must map between structs", fn.Name)
+		}
+		if fn.To != nil {
+			g.funcs[[2]reflect.Type{fn.From, fn.To}] = fn.Name
+		}
+	}
+	for _, fn := range funcs {
+		var err error
+		if fn.To == nil {
+			err = g.columns(fn)
+		} else {
+			err = g.mapping(fn)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var source bytes.Buffer
+	source.WriteString("// Code generated by cmd/mapgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&source, "package %s\n\n", path.Base(pkgPath))
+	if len(g.imports) > 0 {
+		paths := make([]string, 0, len(g.imports))
+		for importPath := range g.imports {
+			paths = append(paths, importPath)
+		}
+		sort.Strings(paths)
+		source.WriteString("import (\n")
+		for _, importPath := range paths {
+			if name := g.imports[importPath]; name != path.Base(importPath) {
+				fmt.Fprintf(&source, "\t%s %q\n", name, importPath)
+			} else {
+				fmt.Fprintf(&source, "\t%q\n", importPath)
+			}
+		}
+		source.WriteString(")\n")
+	}
+	source.Write(g.body.Bytes())
+	return format.Source(source.Bytes())
+}
+
+func (g *generator) mapping(fn Func) error {
+	var statements bytes.Buffer
+	var unset []string
+	for _, target := range fields(fn.To) {
+		source, ok := field(fn.From, target.Name)
+		if !ok {
+			unset = append(unset, target.Name)
+			continue
+		}
+		code, err := g.assign("to."+target.Name, "from."+target.Name, source.Type, target.Type)
+		if err != nil {
+			return fmt.Errorf("%w %s of %s in %s: %v", ErrUnmappable, target.Name, fn.To, fn.Name, err)
+		}
+		statements.WriteString(code)
+	}
+
+	fmt.Fprintf(&g.body, "\n// %s maps %s to %s", fn.Name, g.typeName(fn.From), g.typeName(fn.To))
+	if len(unset) > 0 {
+		fmt.Fprintf(&g.body, ", leaving %s unset", strings.Join(unset, ", "))
+	}
+	fmt.Fprintf(&g.body, "\nfunc %s(from %s) %s {\n\tvar to %s\n", fn.Name, g.typeName(fn.From), g.typeName(fn.To), g.typeName(fn.To))
+	g.body.Write(statements.Bytes())
+	g.body.WriteString("\treturn to\n}\n")
+	return nil
+}
+
+func (g *generator) columns(fn Func) error {
+	fmt.Fprintf(&g.body, "\n// %s returns the fields of %s by name\n", fn.Name, g.typeName(fn.From))
+	fmt.Fprintf(&g.body, "func %s(from %s) map[string]interface{} {\n\treturn map[string]interface{}{\n", fn.Name, g.typeName(fn.From))
+	for _, source := range fields(fn.From) {
+		fmt.Fprintf(&g.body, "\t\t%q: from.%s,\n", source.Name, source.Name)
+	}
+	g.body.WriteString("\t}\n}\n")
+	return nil
+}
+
+// assign is the code setting to from, it unwraps one pointer or slice
+func (g *generator) assign(to string, from string, fromType reflect.Type, toType reflect.Type) (string, error) {
+	if value, ok := g.convert(from, fromType, toType); ok {
+		return fmt.Sprintf("\t%s = %s\n", to, value), nil
+	}
+	switch {
+	case fromType.Kind() == reflect.Pointer && toType.Kind() == reflect.Pointer:
+		if value, ok := g.convert("*"+from, fromType.Elem(), toType.Elem()); ok {
+			return fmt.Sprintf("\tif %s != nil {\n\t\tvalue := %s\n\t\t%s = &value\n\t}\n", from, value, to), nil
+		}
+	case fromType.Kind() == reflect.Pointer:
+		if value, ok := g.convert("*"+from, fromType.Elem(), toType); ok {
+			return fmt.Sprintf("\tif %s != nil {\n\t\t%s = %s\n\t}\n", from, to, value), nil
+		}
+	case toType.Kind() == reflect.Pointer:
+		if value, ok := g.convert(from, fromType, toType.Elem()); ok {
+			return fmt.Sprintf("\t{\n\t\tvalue := %s\n\t\t%s = &value\n\t}\n", value, to), nil
+		}
+	case fromType.Kind() == reflect.Slice && toType.Kind() == reflect.Slice:
+		if value, ok := g.convert("item", fromType.Elem(), toType.Elem()); ok {
+			return fmt.Sprintf("\tif %s != nil {\n\t\t%s = make(%s, len(%s))\n\t\tfor i, item := range %s {\n\t\t\t%s[i] = %s\n\t\t}\n\t}\n",
+				from, to, g.typeName(toType), from, from, to, value), nil
+		}
+	}
+	return "", fmt.Errorf("no conversion from %s to %s", fromType, toType)
+}
+
+// convert is an expression of toType from the value of fromType
+func (g *generator) convert(value string, fromType reflect.Type, toType reflect.Type) (string, bool) {
+	switch {
+	case fromType == toType:
+		return value, true
+	case g.funcs[[2]reflect.Type{fromType, toType}] != "":
+		return fmt.Sprintf("%s(%s)", g.funcs[[2]reflect.Type{fromType, toType}], value), true
+	case fromType.ConvertibleTo(toType) && class(fromType) == class(toType):
+		return fmt.Sprintf("%s(%s)", g.typeName(toType), value), true
+	}
+	return "", false
+}
+
+// class groups the kinds a conversion keeps the meaning of, an int is not turned into a string
+func class(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	}
+	return t.Kind().String()
+}
+
+func (g *generator) typeName(t reflect.Type) string {
+	if t.Name() != "" {
+		if t.PkgPath() == "" || t.PkgPath() == g.pkgPath {
+			return t.Name()
+		}
+		return g.qualifier(t.PkgPath()) + "." + t.Name()
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return "*" + g.typeName(t.Elem())
+	case reflect.Slice:
+		return "[]" + g.typeName(t.Elem())
+	case reflect.Array:
+		return fmt.Sprintf("[%d]%s", t.Len(), g.typeName(t.Elem()))
+	case reflect.Map:
+		return "map[" + g.typeName(t.Key()) + "]" + g.typeName(t.Elem())
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return "interface{}"
+		}
+	}
+	return t.String()
+}
+
+// qualifier imports a package, its name is the last element of the path without a major version
+func (g *generator) qualifier(importPath string) string {
+	if name, ok := g.imports[importPath]; ok {
+		return name
+	}
+	elements := strings.Split(importPath, "/")
+	base := elements[len(elements)-1]
+	if majorVersion.MatchString(base) && len(elements) > 1 {
+		base = elements[len(elements)-2]
+	}
+	base, _, _ = strings.Cut(base, ".")
+	base = strings.ReplaceAll(base, "-", "_")
+
+	name := base
+	for n := 2; g.taken(name); n++ {
+		name = fmt.Sprintf("%s%d", base, n)
+	}
+	g.imports[importPath] = name
+	return name
+}
+
+func (g *generator) taken(name string) bool {
+	for _, imported := range g.imports {
+		if imported == name {
+			return true
+		}
+	}
+	return false
+}
+
+// fields are the exported fields of t, including the ones promoted from embedded structs
+// but not from embedded pointers, which may be nil
+func fields(t reflect.Type) []reflect.StructField {
+	var result []reflect.StructField
+	for _, f := range reflect.VisibleFields(t) {
+		if visible, ok := field(t, f.Name); ok && slices.Equal(visible.Index, f.Index) {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// field finds the exported field name of t, like a selector would
+func field(t reflect.Type, name string) (reflect.StructField, bool) {
+	f, ok := t.FieldByName(name)
+	if !ok || !f.IsExported() || f.Anonymous {
+		return f, false
+	}
+	owner := t
+	for _, i := range f.Index[:len(f.Index)-1] {
+		owner = owner.Field(i).Type
+		if owner.Kind() != reflect.Struct {
+			return f, false
+		}
+	}
+	return f, true
+}
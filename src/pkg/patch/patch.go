@@ -0,0 +1,124 @@
+// Package patch decodes JSON Merge Patch (RFC 7396) documents for flat update DTOs.
+//
+// Members absent from the document are left untouched, members set to null reset
+// the field to its zero value (nil for pointers). A nested object replaces the
+// field as a whole, the DTOs map to columns and are not merged recursively.
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// MediaType is the content type of a merge patch, plain application/json is accepted too
+const MediaType string = "application/merge-patch+json"
+
+var ErrNotObject = errors.New("merge patch must be a JSON object")
+
+// Patch is a partial update of T, only the fields named in Present are applied
+type Patch[T any] struct {
+	Value   T
+	Present []string // Go field names of T
+}
+
+// Decode parses body into T and records which fields it sets.
+// Members that do not match a field of T are rejected.
+func Decode[T any](body []byte) (Patch[T], error) {
+	var patch Patch[T]
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil || members == nil {
+		return patch, ErrNotObject
+	}
+
+	fields := fieldsByJsonName(reflect.TypeOf(patch.Value))
+	for member := range members {
+		field, ok := lookup(fields, member)
+		if !ok {
+			return patch, fmt.Errorf("unknown field %q", member)
+		}
+		patch.Present = append(patch.Present, field)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if err := decoder.Decode(&patch.Value); err != nil {
+		return patch, err
+	}
+	return patch, nil
+}
+
+// Map converts the value with mapper and keeps the present fields,
+// the request and usecase DTOs of an entity share their field names
+func Map[T any, U any](patch Patch[T], mapper func(T) U) Patch[U] {
+	return Patch[U]{Value: mapper(patch.Value), Present: patch.Present}
+}
+
+// Columns returns the present fields and their values, keyed by Go field name like Mapper.ToColumns
+func (p Patch[T]) Columns() map[string]interface{} {
+	value := reflect.ValueOf(p.Value)
+	columns := make(map[string]interface{}, len(p.Present))
+	for _, name := range p.Present {
+		field := value.FieldByName(name)
+		if !field.IsValid() {
+			continue
+		}
+		if field.Kind() == reflect.Pointer {
+			if field.IsNil() {
+				columns[name] = nil
+				continue
+			}
+			field = field.Elem()
+		}
+		columns[name] = field.Interface()
+	}
+	return columns
+}
+
+// Has tells whether the document set the field
+func (p Patch[T]) Has(name string) bool {
+	for _, present := range p.Present {
+		if present == name {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldsByJsonName maps the JSON member names of a struct to its field names, promoted fields included
+func fieldsByJsonName(t reflect.Type) map[string]string {
+	fields := map[string]string{}
+	if t == nil || t.Kind() != reflect.Struct {
+		return fields
+	}
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Name
+	}
+	return fields
+}
+
+// lookup matches member names like encoding/json does, exact first and then case-insensitive
+func lookup(fields map[string]string, member string) (string, bool) {
+	if field, ok := fields[member]; ok {
+		return field, true
+	}
+	for name, field := range fields {
+		if strings.EqualFold(name, member) {
+			return field, true
+		}
+	}
+	return "", false
+}
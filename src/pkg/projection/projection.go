@@ -0,0 +1,206 @@
+// Package projection narrows reads to the fields a client asks for and loads the relations it expands.
+//
+// Fields are the JSON names of a response, they match the model fields by name case-insensitively,
+// like the JSON mapping between models and DTOs. Fields that are not columns, such as relations or
+// search ranks, select nothing. Relations are preload paths, e.g. Avatar.Variants.
+package projection
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm/schema"
+)
+
+var ErrField = errors.New("unknown field")
+var ErrExpand = errors.New("relation cannot be expanded")
+
+var schemas = &sync.Map{}
+
+// Parse splits a comma separated query parameter, nil when it is empty
+func Parse(list string) []string {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Check makes sure the response T has the requested fields, by their JSON names
+func Check[T any](fields []string) error {
+	var names []string
+	for _, field := range reflect.VisibleFields(reflect.TypeOf(*new(T))) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	for _, field := range fields {
+		if !contains(names, field) {
+			return fmt.Errorf("%w %s", ErrField, field)
+		}
+	}
+	return nil
+}
+
+// Columns lists the columns of T holding the requested fields, or every column when none are requested.
+// A field is matched by its name or column name. Primary keys and the foreign keys of belongs-to
+// relations are always kept so relations can be loaded, and so is the version the ETag is made of.
+func Columns[T any](fields []string) ([]string, error) {
+	s, err := schema.Parse(new(T), schemas, schema.NamingStrategy{})
+	if err != nil {
+		return nil, err
+	}
+	keep := map[string]bool{"version": true}
+	for _, field := range s.PrimaryFields {
+		keep[field.DBName] = true
+	}
+	for _, relation := range s.Relationships.BelongsTo {
+		for _, reference := range relation.References {
+			if reference.ForeignKey.Schema == s {
+				keep[reference.ForeignKey.DBName] = true
+			}
+		}
+	}
+
+	var columns []string
+	for _, field := range s.Fields {
+		// Read-only fields such as model.SearchHit are computed by the query, not stored
+		if field.DBName == "" || field.IgnoreMigration {
+			continue
+		}
+		if fields == nil || keep[field.DBName] || contains(fields, field.Name) || contains(fields, field.DBName) {
+			columns = append(columns, field.DBName)
+		}
+	}
+	return columns, nil
+}
+
+// Relations picks the relations to load: the defaults whose field is requested, every default when
+// no fields are, and the expanded ones, which must be defaults or listed as expandable
+func Relations(defaults []string, expandable []string, fields []string, expand []string) ([]string, error) {
+	allowed := append(append([]string{}, defaults...), expandable...)
+	var relations []string
+	for _, relation := range defaults {
+		if fields == nil || contains(fields, Root(relation)) {
+			relations = append(relations, relation)
+		}
+	}
+	for _, name := range expand {
+		relation, ok := find(allowed, name)
+		if !ok {
+			return nil, fmt.Errorf("%w %s", ErrExpand, name)
+		}
+		if _, loaded := find(relations, relation); !loaded {
+			relations = append(relations, relation)
+		}
+	}
+	return relations, nil
+}
+
+// Tag tells the representations of a row read with different fields and expansions apart, it is
+// empty when the whole response is read. The order of the fields matters, the one of the expansions does not.
+func Tag(fields []string, expand []string) string {
+	if fields == nil && expand == nil {
+		return ""
+	}
+	expand = slices.Sorted(slices.Values(expand))
+	sum := sha256.Sum256([]byte(strings.Join(fields, ",") + ";" + strings.Join(expand, ",")))
+	return hex.EncodeToString(sum[:4])
+}
+
+// Root is the field of the entity a relation path starts at, Avatar for Avatar.Variants
+func Root(relation string) string {
+	name, _, _ := strings.Cut(relation, ".")
+	return name
+}
+
+// Object is a response narrowed to some of its fields, in the order they were requested
+type Object []Field
+
+type Field struct {
+	Name  string
+	Value json.RawMessage
+}
+
+func (o Object) MarshalJSON() ([]byte, error) {
+	var body bytes.Buffer
+	body.WriteByte('{')
+	for i, field := range o {
+		if i > 0 {
+			body.WriteByte(',')
+		}
+		name, err := json.Marshal(field.Name)
+		if err != nil {
+			return nil, err
+		}
+		body.Write(name)
+		body.WriteByte(':')
+		body.Write(field.Value)
+	}
+	body.WriteByte('}')
+	return body.Bytes(), nil
+}
+
+// Trim keeps the requested fields of a response, fields it left out as empty stay out
+func Trim(v interface{}, fields []string) (Object, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(body, &values); err != nil {
+		return nil, err
+	}
+	object := Object{}
+	for _, name := range fields {
+		for key, value := range values {
+			if strings.EqualFold(key, name) && !object.has(key) {
+				object = append(object, Field{Name: key, Value: value})
+				break
+			}
+		}
+	}
+	return object, nil
+}
+
+func (o Object) has(name string) bool {
+	for _, field := range o {
+		if field.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(names []string, name string) bool {
+	_, ok := find(names, name)
+	return ok
+}
+
+// find matches names case-insensitively and returns the one found
+func find(names []string, name string) (string, bool) {
+	for _, known := range names {
+		if strings.EqualFold(known, name) {
+			return known, true
+		}
+	}
+	return "", false
+}
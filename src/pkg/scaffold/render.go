@@ -0,0 +1,174 @@
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/*.tmpl
+var files embed.FS
+
+var templates = template.Must(template.New("").Funcs(template.FuncMap{"lowerFirst": lowerFirst}).ParseFS(files, "templates/*.tmpl"))
+
+// migrationsFile registers the migrations, routesFile mounts the routers and mappersFile
+// lists the mappers cmd/mapgen writes
+const (
+	migrationsFile string = "infra/persistence/migration/migrations.go"
+	routesFile     string = "api/api.go"
+	mappersFile    string = "cmd/mapgen/main.go"
+)
+
+var (
+	moduleLine       = regexp.MustCompile(`(?m)^module\s+"?([^\s"]+)"?`)
+	migrationVersion = regexp.MustCompile(`\{Version: (\d+),`)
+)
+
+// File is a generated file, Path is relative to the module root
+type File struct {
+	Path string
+	Body []byte
+}
+
+// Insert adds Text to an existing file before the line starting with Before, unless Marker is already there
+type Insert struct {
+	Path   string
+	Marker string
+	Before string
+	Text   string
+}
+
+// Plan is what generating a resource writes
+type Plan struct {
+	Files   []File
+	Inserts []Insert
+}
+
+// data is what the templates see, Version is the number of the migration
+type data struct {
+	*Resource
+	Module  string
+	Version int
+}
+
+// Module reads the module path of the go.mod in root
+func Module(root string) (string, error) {
+	body, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return "", err
+	}
+	match := moduleLine.FindSubmatch(body)
+	if match == nil {
+		return "", fmt.Errorf("%s has no module path", filepath.Join(root, "go.mod"))
+	}
+	return string(match[1]), nil
+}
+
+// NewPlan renders the files of r for the module in root. A migration is only planned
+// the first time, once it is registered the schema changes need a migration by hand.
+func NewPlan(root string, r *Resource) (*Plan, error) {
+	module, err := Module(root)
+	if err != nil {
+		return nil, err
+	}
+	registry, err := os.ReadFile(filepath.Join(root, migrationsFile))
+	if err != nil {
+		return nil, err
+	}
+	migration := fmt.Sprintf("Name: %q", "add_"+r.Snake())
+	version := 0
+	if !bytes.Contains(registry, []byte(migration)) {
+		for _, match := range migrationVersion.FindAllSubmatch(registry, -1) {
+			if n, _ := strconv.Atoi(string(match[1])); n > version {
+				version = n
+			}
+		}
+		version++
+	}
+
+	plan := &Plan{}
+	targets := map[string]string{
+		"model":             "domain/model/%s.go",
+		"domain_repository": "domain/repository/%s_repository.go",
+		"infra_repository":  "infra/persistence/repository/postgres_%s_repository.go",
+		"usecase_dto":       "usecase/dto/%s.go",
+		"usecase":           "usecase/%s_usecase.go",
+		"api_dto":           "api/dto/%s.go",
+		"handler":           "api/handler/%s.go",
+		"router":            "api/router/%s.go",
+		"dependency":        "dependency/%s.go",
+		"test":              "tests/unit/%s_test.go",
+	}
+	for _, name := range []string{"model", "domain_repository", "infra_repository", "usecase_dto", "usecase", "api_dto", "handler", "router", "dependency", "test"} {
+		file, err := Render(name, r, module, version)
+		if err != nil {
+			return nil, err
+		}
+		file.Path = fmt.Sprintf(targets[name], r.Snake())
+		plan.Files = append(plan.Files, file)
+	}
+
+	if version > 0 {
+		file, err := Render("migration", r, module, version)
+		if err != nil {
+			return nil, err
+		}
+		file.Path = fmt.Sprintf("infra/persistence/migration/%d_Add%s.go", version, r.Name)
+		plan.Files = append(plan.Files, file)
+		plan.Inserts = append(plan.Inserts, Insert{
+			Path:   migrationsFile,
+			Marker: migration,
+			Before: "}",
+			Text:   fmt.Sprintf("\t{Version: %d, %s, Up: up%d, Down: down%d},\n", version, migration, version, version),
+		})
+	}
+	plan.Inserts = append(plan.Inserts, Insert{
+		Path:   mappersFile,
+		Marker: fmt.Sprintf("(%q)", "to"+r.Name+"Dto"),
+		Before: "\t// cmd/gen adds the mappers",
+		Text: fmt.Sprintf("\tmapgen.Map[dto.Create%s, model.%s](%q),\n\tmapgen.Columns[dto.Update%s](%q),\n\tmapgen.Map[model.%s, dto.%s](%q),\n\n",
+			r.Name, r.Name, "to"+r.Name+"Entity", r.Name, r.Var()+"Columns", r.Name, r.Name, "to"+r.Name+"Dto"),
+	})
+	plan.Inserts = append(plan.Inserts, Insert{
+		Path:   routesFile,
+		Marker: fmt.Sprintf("router.%s(", r.Name),
+		Before: "\tapp.Get(\"/metrics\"",
+		Text: fmt.Sprintf("\t// %s\n\t%s := v1.Group(%q, apimiddleware.Authentication(cfg))\n\trouter.%s(%s, cfg)\n\n",
+			r.Tag(), r.PluralVar(), r.Route, r.Name, r.PluralVar()),
+	})
+	return plan, nil
+}
+
+// Render executes the template of one layer and formats the result
+func Render(name string, r *Resource, module string, version int) (File, error) {
+	var body bytes.Buffer
+	if err := templates.ExecuteTemplate(&body, name+".tmpl", &data{Resource: r, Module: module, Version: version}); err != nil {
+		return File{}, err
+	}
+	formatted, err := format.Source(body.Bytes())
+	if err != nil {
+		return File{}, fmt.Errorf("%s of %s: %w", name, r.Name, err)
+	}
+	return File{Body: formatted}, nil
+}
+
+// apply inserts the text into body, it is a no-op when the marker is already there
+func (i Insert) apply(body []byte) ([]byte, error) {
+	if bytes.Contains(body, []byte(i.Marker)) {
+		return body, nil
+	}
+	lines := strings.SplitAfter(string(body), "\n")
+	for n := len(lines) - 1; n >= 0; n-- {
+		if strings.HasPrefix(lines[n], i.Before) {
+			return []byte(strings.Join(lines[:n], "") + i.Text + strings.Join(lines[n:], "")), nil
+		}
+	}
+	return nil, fmt.Errorf("%s has no line starting with %q to insert before", i.Path, i.Before)
+}
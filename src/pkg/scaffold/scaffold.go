@@ -0,0 +1,429 @@
+// Package scaffold generates the layers of a CRUD resource from a YAML definition, the way the
+// File resource is written by hand: model, repository, usecase, DTOs with mappers, handler,
+// router, dependency getter, migration and unit tests. See cmd/gen.
+package scaffold
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	BelongsTo string = "belongsTo"
+	HasMany   string = "hasMany"
+)
+
+// defaultSize is the length of string fields without a size
+const defaultSize int = 255
+
+var identifier = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+
+var ErrDefinition = errors.New("invalid resource definition")
+
+// Resource is the definition of a resource
+type Resource struct {
+	Name        string     `yaml:"name"`        // Go name of the model, e.g. OrderItem
+	Route       string     `yaml:"route"`       // Path under /api/v1, the kebab-case plural by default
+	Description string     `yaml:"description"` // Doc comment of the model
+	AdminWrites bool       `yaml:"adminWrites"` // Only administrators create, change and delete
+	Fields      []Field    `yaml:"fields"`
+	Relations   []Relation `yaml:"relations"`
+
+	Source string `yaml:"-"` // Definition file, named in the generated headers
+}
+
+type Field struct {
+	Name     string `yaml:"name"`
+	Type     string `yaml:"type"`     // string, text, int, int64, float64, bool, time or json
+	Size     int    `yaml:"size"`     // Length of a string, 255 by default
+	Required bool   `yaml:"required"` // Not null without a default, and required in requests
+	Validate string `yaml:"validate"` // More binding rules for requests, e.g. max=100,email
+	Index    bool   `yaml:"index"`
+	Search   string `yaml:"search"`   // Full-text weight A to D, see package search
+	ReadOnly bool   `yaml:"readOnly"` // Set on create, left out of updates
+}
+
+// Relation points at another resource, it must have the usual model, usecase DTO, XResponse
+// and ToXResponse. A has-many foreign key is a field of the other model.
+type Relation struct {
+	Name       string `yaml:"name"`       // Field of the model, e.g. Category
+	Model      string `yaml:"model"`      // Related model, e.g. Category
+	Kind       string `yaml:"kind"`       // belongsTo (default) or hasMany
+	ForeignKey string `yaml:"foreignKey"` // NameId by default for belongsTo, ResourceId for hasMany
+	Preload    bool   `yaml:"preload"`    // Loaded by every read, otherwise only with ?expand=
+
+	declared bool // The foreign key is one of the fields
+}
+
+// Constraint is the gorm constraint, a generated belongs-to key is nulled when the row it points at is deleted
+func (r Relation) Constraint() string {
+	if r.Kind == BelongsTo && !r.declared {
+		return "OnUpdate:NO ACTION;OnDelete:SET NULL"
+	}
+	return "OnUpdate:NO ACTION;OnDelete:NO ACTION"
+}
+
+// Load reads and checks a definition file
+func Load(path string) (*Resource, error) {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	resource := &Resource{}
+	decoder := yaml.NewDecoder(strings.NewReader(string(body)))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(resource); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	resource.Source = filepath.Base(path)
+	if err := resource.Check(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return resource, nil
+}
+
+// Check validates the definition and fills in the defaults
+func (r *Resource) Check() error {
+	if !identifier.MatchString(r.Name) {
+		return fmt.Errorf("%w: name %q must be an exported Go identifier", ErrDefinition, r.Name)
+	}
+	if r.Route == "" {
+		r.Route = strings.ReplaceAll(r.Table(), "_", "-")
+	}
+	r.Route = "/" + strings.Trim(r.Route, "/")
+	if len(r.Fields) == 0 {
+		return fmt.Errorf("%w: %s has no fields", ErrDefinition, r.Name)
+	}
+
+	names := map[string]bool{}
+	for _, reserved := range []string{"Id", "TenantId", "Version", "CreatedAt", "ModifiedAt", "DeletedAt", "CreatedBy", "ModifiedBy", "DeletedBy", "SearchRank", "SearchHighlights"} {
+		names[reserved] = true
+	}
+	for i := range r.Fields {
+		field := &r.Fields[i]
+		if !identifier.MatchString(field.Name) || names[field.Name] {
+			return fmt.Errorf("%w: field name %q is not an identifier or is taken", ErrDefinition, field.Name)
+		}
+		names[field.Name] = true
+		if _, ok := types[field.Type]; !ok {
+			return fmt.Errorf("%w: field %s has unknown type %q", ErrDefinition, field.Name, field.Type)
+		}
+		if field.Type == "string" && field.Size == 0 {
+			field.Size = defaultSize
+		}
+		if field.Search != "" {
+			field.Search = strings.ToUpper(field.Search)
+			if field.Type != "string" && field.Type != "text" || !strings.Contains("ABCD", field.Search) || len(field.Search) != 1 {
+				return fmt.Errorf("%w: field %s can only be searched with weight A to D if it is text", ErrDefinition, field.Name)
+			}
+		}
+	}
+	for i := range r.Relations {
+		relation := &r.Relations[i]
+		if !identifier.MatchString(relation.Name) || !identifier.MatchString(relation.Model) || names[relation.Name] {
+			return fmt.Errorf("%w: relation %q needs a free name and a model", ErrDefinition, relation.Name)
+		}
+		names[relation.Name] = true
+		switch relation.Kind {
+		case "", BelongsTo:
+			relation.Kind = BelongsTo
+			if relation.ForeignKey == "" {
+				relation.ForeignKey = relation.Name + "Id"
+			}
+		case HasMany:
+			if relation.ForeignKey == "" {
+				relation.ForeignKey = r.Name + "Id"
+			}
+		default:
+			return fmt.Errorf("%w: relation %s has unknown kind %q", ErrDefinition, relation.Name, relation.Kind)
+		}
+		if !identifier.MatchString(relation.ForeignKey) {
+			return fmt.Errorf("%w: relation %s has an invalid foreign key", ErrDefinition, relation.Name)
+		}
+		relation.declared = r.hasField(relation.ForeignKey)
+		if relation.Kind == BelongsTo && !relation.declared {
+			if names[relation.ForeignKey] {
+				return fmt.Errorf("%w: foreign key %s is taken", ErrDefinition, relation.ForeignKey)
+			}
+			names[relation.ForeignKey] = true
+		}
+	}
+	return nil
+}
+
+func (r *Resource) hasField(name string) bool {
+	for _, field := range r.Fields {
+		if field.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Plural is the name in plural as gorm inflects table names, OrderItems
+func (r *Resource) Plural() string {
+	words := strings.Split(r.Table(), "_")
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, "")
+}
+
+// Snake names the files, order_item
+func (r *Resource) Snake() string {
+	return schema.NamingStrategy{}.ColumnName("", r.Name)
+}
+
+// Table is the table gorm maps the model to, order_items
+func (r *Resource) Table() string {
+	return schema.NamingStrategy{}.TableName(r.Name)
+}
+
+// Var is the name of a local variable, orderItem
+func (r *Resource) Var() string {
+	return lowerFirst(r.Name)
+}
+
+// PluralVar is the name of a local variable holding several, orderItems
+func (r *Resource) PluralVar() string {
+	return lowerFirst(r.Plural())
+}
+
+// Words is the name for comments, order item
+func (r *Resource) Words() string {
+	return strings.ReplaceAll(r.Snake(), "_", " ")
+}
+
+// PluralWords is the plural for comments, order items
+func (r *Resource) PluralWords() string {
+	return strings.ReplaceAll(r.Table(), "_", " ")
+}
+
+// Tag groups the endpoints in the API documentation, Order Items
+func (r *Resource) Tag() string {
+	words := strings.Fields(r.PluralWords())
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+func (r *Resource) Searchable() bool {
+	for _, field := range r.Fields {
+		if field.Search != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchWords names the searched fields in the API documentation, the name and description
+func (r *Resource) SearchWords() string {
+	var words []string
+	for _, field := range r.Fields {
+		if field.Search != "" {
+			words = append(words, strings.ReplaceAll(schema.NamingStrategy{}.ColumnName("", field.Name), "_", " "))
+		}
+	}
+	if len(words) > 1 {
+		return "the " + strings.Join(words[:len(words)-1], ", ") + " and " + words[len(words)-1]
+	}
+	return "the " + strings.Join(words, "")
+}
+
+// Updatable are the fields of the update DTOs
+func (r *Resource) Updatable() []Field {
+	var fields []Field
+	for _, field := range r.Fields {
+		if !field.ReadOnly {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// ForeignKeys are the belongs-to keys the definition does not declare as fields
+func (r *Resource) ForeignKeys() []string {
+	var keys []string
+	for _, relation := range r.Relations {
+		relation.declared = r.hasField(relation.ForeignKey)
+		if relation.Kind == BelongsTo && !relation.declared {
+			keys = append(keys, relation.ForeignKey)
+		}
+	}
+	return keys
+}
+
+// Preloads lists the relations loaded by every read, Expandable the ones loaded on request
+func (r *Resource) Preloads() []string {
+	return r.relations(true)
+}
+
+func (r *Resource) Expandable() []string {
+	return r.relations(false)
+}
+
+func (r *Resource) relations(preload bool) []string {
+	var names []string
+	for _, relation := range r.Relations {
+		if relation.Preload == preload {
+			names = append(names, relation.Name)
+		}
+	}
+	return names
+}
+
+// fieldType maps a definition type to the Go type of the model, its gorm tag
+// and the swagger type of requests and responses
+type fieldType struct {
+	goType   string
+	optional string // Go type when not required, empty when it is the same
+	gorm     string
+	swagger  string
+}
+
+var types = map[string]fieldType{
+	"string":  {goType: "string", gorm: "type:string"},
+	"text":    {goType: "string", gorm: "type:text"},
+	"int":     {goType: "int"},
+	"int64":   {goType: "int64"},
+	"float64": {goType: "float64"},
+	"bool":    {goType: "bool"},
+	"time":    {goType: "time.Time", optional: "*time.Time", gorm: "type:TIMESTAMP with time zone"},
+	"json":    {goType: "json.RawMessage", gorm: "type:jsonb", swagger: "object"},
+}
+
+var defaults = map[string]string{
+	"string":  "''",
+	"text":    "''",
+	"int":     "0",
+	"int64":   "0",
+	"float64": "0",
+	"bool":    "false",
+	"json":    "'{}'",
+}
+
+// GoType is the type of the field in the model and the DTOs
+func (f Field) GoType() string {
+	t := types[f.Type]
+	if !f.Required && t.optional != "" {
+		return t.optional
+	}
+	return t.goType
+}
+
+// Gorm is the gorm tag of the model field
+func (f Field) Gorm() string {
+	var settings []string
+	if f.Type == "string" {
+		settings = append(settings, fmt.Sprintf("size:%d", f.Size))
+	}
+	if t := types[f.Type].gorm; t != "" {
+		settings = append(settings, t)
+	}
+	switch {
+	case f.Required:
+		settings = append(settings, "not null")
+	case f.Type == "time":
+		settings = append(settings, "null")
+	default:
+		settings = append(settings, "not null", "default:"+defaults[f.Type])
+	}
+	if f.Index {
+		settings = append(settings, "index")
+	}
+	return strings.Join(settings, ";")
+}
+
+// JSON is the name of the field in requests and responses
+func (f Field) JSON() string {
+	return lowerFirst(f.Name)
+}
+
+// Binding is the binding tag of the request field, empty without rules
+func (f Field) Binding() string {
+	var rules []string
+	if f.Required {
+		rules = append(rules, "required")
+	}
+	if f.Validate != "" {
+		rules = append(rules, f.Validate)
+	}
+	return strings.Join(rules, ",")
+}
+
+// Swagger is the swaggertype of the field, empty when swag can tell
+func (f Field) Swagger() string {
+	return types[f.Type].swagger
+}
+
+// Sample is a Go literal of the field for the generated tests, empty for optional times
+func (f Field) Sample() string {
+	switch f.Type {
+	case "string", "text":
+		return fmt.Sprintf("%q", strings.ReplaceAll(schema.NamingStrategy{}.ColumnName("", f.Name), "_", " "))
+	case "int", "int64":
+		return "7"
+	case "float64":
+		return "1.5"
+	case "bool":
+		return "true"
+	case "json":
+		return `json.RawMessage(` + "`" + `{"key":"value"}` + "`" + `)`
+	case "time":
+		if f.Required {
+			return "time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)"
+		}
+	}
+	return ""
+}
+
+// Uses tells whether a field has the type, the templates import time and encoding/json by it
+func (r *Resource) Uses(fieldType string) bool {
+	for _, field := range r.Fields {
+		if field.Type == fieldType {
+			return true
+		}
+	}
+	return false
+}
+
+// Samples tells whether the generated tests set a field of the type, of any type when empty
+func (r *Resource) Samples(fieldType string) bool {
+	for _, field := range r.Fields {
+		if (fieldType == "" || field.Type == fieldType) && field.Sample() != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Requires tells whether a create request can be rejected for a missing field
+func (r *Resource) Requires() bool {
+	for _, field := range r.Fields {
+		if field.Required {
+			return true
+		}
+	}
+	return false
+}
+
+// lowerFirst lowers the leading capitals, OrderItem becomes orderItem and ID id
+func lowerFirst(name string) string {
+	runes := []rune(name)
+	for i := range runes {
+		if !unicode.IsUpper(runes[i]) || i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
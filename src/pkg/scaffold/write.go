@@ -0,0 +1,103 @@
+package scaffold
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// What Write did to a file
+const (
+	Created   string = "created"
+	Updated   string = "updated"
+	Unchanged string = "unchanged"
+	Kept      string = "kept" // Edited by hand since it was generated, or never generated
+	Inserted  string = "inserted"
+)
+
+// header marks a generated file with the checksum of its body, a file whose body no
+// longer matches was edited and is not overwritten
+var header = regexp.MustCompile(`^// Generated by cmd/gen from \S+, checksum ([0-9a-f]{12})\. Edited files are not regenerated\.\n\n`)
+
+// Action is what Write did, or would do on a dry run, to a file
+type Action struct {
+	Path   string
+	Action string
+}
+
+// Write writes the plan under root. Files edited since they were generated are kept
+// unless force is set, inserts are skipped when already there.
+func Write(root string, source string, plan *Plan, force bool, dryRun bool) ([]Action, error) {
+	var actions []Action
+	for _, file := range plan.Files {
+		path := filepath.Join(root, file.Path)
+		body := stamp(source, file.Body)
+		existing, err := os.ReadFile(path)
+		action := Created
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			return actions, err
+		case bytes.Equal(existing, body):
+			action = Unchanged
+		case !generated(existing) && !force:
+			action = Kept
+		default:
+			action = Updated
+		}
+		actions = append(actions, Action{Path: file.Path, Action: action})
+		if dryRun || action == Unchanged || action == Kept {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return actions, err
+		}
+		if err := os.WriteFile(path, body, 0o644); err != nil {
+			return actions, err
+		}
+	}
+
+	for _, insert := range plan.Inserts {
+		path := filepath.Join(root, insert.Path)
+		existing, err := os.ReadFile(path)
+		if err != nil {
+			return actions, err
+		}
+		body, err := insert.apply(existing)
+		if err != nil {
+			return actions, err
+		}
+		if bytes.Equal(body, existing) {
+			actions = append(actions, Action{Path: insert.Path, Action: Unchanged})
+			continue
+		}
+		actions = append(actions, Action{Path: insert.Path, Action: Inserted})
+		if dryRun {
+			continue
+		}
+		if err := os.WriteFile(path, body, 0o644); err != nil {
+			return actions, err
+		}
+	}
+	return actions, nil
+}
+
+// stamp prefixes body with the header
+func stamp(source string, body []byte) []byte {
+	return append([]byte(fmt.Sprintf("// Generated by cmd/gen from %s, checksum %s. Edited files are not regenerated.\n\n", source, checksum(body))), body...)
+}
+
+// generated tells whether a file still has the body it was generated with
+func generated(content []byte) bool {
+	match := header.FindSubmatch(content)
+	return match != nil && string(match[1]) == checksum(content[len(match[0]):])
+}
+
+func checksum(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])[:12]
+}
@@ -0,0 +1,98 @@
+// Package search builds the PostgreSQL full-text search of models with searchable fields.
+//
+// Fields are marked with a `search` tag holding their weight, A ranks highest:
+//
+//	Name        string `search:"A"`
+//	Description string `search:"B"`
+//
+// The marked columns are combined into a generated tsvector column with a GIN index,
+// see the AddFileSearch migration. Queries use the websearch syntax: quoted phrases,
+// "or" and a leading - to exclude a word.
+package search
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm/schema"
+)
+
+// Column is the generated tsvector column of a searchable table
+const Column string = "search_vector"
+
+// Language is the text search configuration, simple does not stem and suits every language
+const Language string = "simple"
+
+const query string = "websearch_to_tsquery('" + Language + "', ?)"
+
+// Match keeps the rows matching the query bound to its placeholder
+const Match string = Column + " @@ " + query
+
+// RankOrder lists the best matches first, see Select
+const RankOrder string = "search_rank desc"
+
+// Snippets mark the matched words in the HTML-escaped text, see escaped
+const headlineOptions string = "StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2"
+
+var ErrNotSearchable = errors.New("this list has no searchable fields")
+
+type Field struct {
+	Column string
+	Weight string
+}
+
+// Fields returns the searchable fields of T, nil when it has none
+func Fields[T any]() []Field {
+	var fields []Field
+	for _, field := range reflect.VisibleFields(reflect.TypeOf(*new(T))) {
+		weight, ok := field.Tag.Lookup("search")
+		if !ok || field.Anonymous {
+			continue
+		}
+		if weight == "" {
+			weight = "D"
+		}
+		column := schema.ParseTagSetting(field.Tag.Get("gorm"), ";")["COLUMN"]
+		if column == "" {
+			column = schema.NamingStrategy{}.ColumnName("", field.Name)
+		}
+		fields = append(fields, Field{Column: column, Weight: strings.ToUpper(weight)})
+	}
+	return fields
+}
+
+// Vector is the expression of the generated column, NULL fields count as empty
+func Vector(fields []Field) string {
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		parts = append(parts, fmt.Sprintf("setweight(to_tsvector('%s', coalesce(%s, '')), '%s')", Language, field.Column, field.Weight))
+	}
+	return strings.Join(parts, " || ")
+}
+
+// Select adds the rank and the highlighted snippets of each field to the selected columns,
+// they are read into model.SearchHit
+func Select(fields []Field, q string) (string, []interface{}) {
+	hits, args := Hits(fields, q)
+	return "*, " + hits, args
+}
+
+// Hits is the rank and snippet part of Select, for queries that select some columns only
+func Hits(fields []Field, q string) (string, []interface{}) {
+	args := []interface{}{q}
+	snippets := make([]string, 0, len(fields))
+	for _, field := range fields {
+		snippets = append(snippets, fmt.Sprintf("'%s', ts_headline('%s', %s, %s, '%s')", field.Column, Language, escaped(field.Column), query, headlineOptions))
+		args = append(args, q)
+	}
+	hits := fmt.Sprintf("ts_rank(%s, %s) as search_rank, jsonb_build_object(%s) as search_highlights", Column, query, strings.Join(snippets, ", "))
+	return hits, args
+}
+
+// escaped is the column text with HTML escaped, so the markers are the only markup of a
+// snippet and stored text like <script> is returned inert
+func escaped(column string) string {
+	return fmt.Sprintf(`replace(replace(replace(replace(%s, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;')`, column)
+}
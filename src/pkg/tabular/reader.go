@@ -0,0 +1,248 @@
+package tabular
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// maxLine bounds a JSON Lines record
+const maxLine int = 1 << 20
+
+var ErrNoHeader = errors.New("the file has no header row")
+
+// Record is a row by column name, the value of a CSV or XLSX cell is a JSON string.
+// Empty cells are left out.
+type Record map[string]json.RawMessage
+
+// Reader returns the rows of a file one at a time and io.EOF after the last one
+type Reader interface {
+	Next() (Record, error)
+	Close() error
+}
+
+func NewReader(format Format, r io.Reader) (Reader, error) {
+	switch format {
+	case CSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		header, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil, ErrNoHeader
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Spreadsheets often save CSV with a byte order mark
+		if len(header) > 0 {
+			header[0] = strings.TrimPrefix(header[0], "\ufeff")
+		}
+		return &csvReader{reader: reader, header: header}, nil
+	case JSONLines:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxLine)
+		return &jsonLinesReader{scanner: scanner}, nil
+	case XLSX:
+		return newXlsxReader(r)
+	}
+	return nil, ErrFormat
+}
+
+type csvReader struct {
+	reader *csv.Reader
+	header []string
+}
+
+func (c *csvReader) Next() (Record, error) {
+	for {
+		cells, err := c.reader.Read()
+		if err != nil {
+			return nil, err
+		}
+		if record := textRecord(c.header, cells); record != nil {
+			return record, nil
+		}
+	}
+}
+
+func (c *csvReader) Close() error {
+	return nil
+}
+
+type jsonLinesReader struct {
+	scanner *bufio.Scanner
+}
+
+func (j *jsonLinesReader) Next() (Record, error) {
+	for j.scanner.Scan() {
+		line := bytes.TrimSpace(j.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil || record == nil {
+			return Record{}, errors.New("the line is not a JSON object")
+		}
+		return record, nil
+	}
+	if err := j.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (j *jsonLinesReader) Close() error {
+	return nil
+}
+
+// xlsxReader reads the first sheet of the workbook
+type xlsxReader struct {
+	file   *excelize.File
+	rows   *excelize.Rows
+	header []string
+}
+
+func newXlsxReader(r io.Reader) (*xlsxReader, error) {
+	file, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, err
+	}
+	sheets := file.GetSheetList()
+	if len(sheets) == 0 {
+		file.Close()
+		return nil, ErrNoHeader
+	}
+	rows, err := file.Rows(sheets[0])
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	reader := &xlsxReader{file: file, rows: rows}
+	if !rows.Next() {
+		reader.Close()
+		return nil, ErrNoHeader
+	}
+	if reader.header, err = rows.Columns(); err != nil {
+		reader.Close()
+		return nil, err
+	}
+	return reader, nil
+}
+
+func (x *xlsxReader) Next() (Record, error) {
+	for x.rows.Next() {
+		// Raw values, a number format must not turn 1234 into "1,234"
+		cells, err := x.rows.Columns(excelize.Options{RawCellValue: true})
+		if err != nil {
+			return nil, err
+		}
+		if record := textRecord(x.header, cells); record != nil {
+			return record, nil
+		}
+	}
+	if err := x.rows.Error(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (x *xlsxReader) Close() error {
+	x.rows.Close()
+	return x.file.Close()
+}
+
+// textRecord names the non-empty cells by the header, nil for a blank row
+func textRecord(header []string, cells []string) Record {
+	var record Record
+	for i, cell := range cells {
+		if i >= len(header) || header[i] == "" || cell == "" {
+			continue
+		}
+		if record == nil {
+			record = Record{}
+		}
+		record[header[i]], _ = json.Marshal(cell)
+	}
+	return record
+}
+
+// Decode converts a record into T by the JSON names of its fields. Text cells are converted to
+// the type of their field, columns T does not have are ignored, so an export can be re-imported.
+func Decode[T any](record Record) (T, error) {
+	var value T
+	known := fields(reflect.TypeOf(value))
+	object := make(map[string]json.RawMessage, len(record))
+	for column, raw := range record {
+		field, ok := known.get(column)
+		if !ok {
+			continue
+		}
+		if raw, ok = literal(raw, field.Type); !ok {
+			return value, fmt.Errorf("%s: %s is not a valid %s", column, raw, field.Type)
+		}
+		object[column] = raw
+	}
+
+	body, err := json.Marshal(object)
+	if err != nil {
+		return value, err
+	}
+	if err := json.Unmarshal(body, &value); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return value, fmt.Errorf("%s: not a valid %s", typeErr.Field, typeErr.Type)
+		}
+		return value, err
+	}
+	return value, nil
+}
+
+// literal unquotes the text of a cell for numeric and boolean fields, "12" becomes 12.
+// Time fields also take the serial number of a spreadsheet date.
+func literal(raw json.RawMessage, t reflect.Type) (json.RawMessage, bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil || raw[0] != '"' {
+		return raw, true // Already a JSON literal
+	}
+	text = strings.TrimSpace(text)
+
+	switch t.Kind() {
+	case reflect.Bool:
+		// Spreadsheets store booleans as 1 and 0
+		value, err := strconv.ParseBool(text)
+		if err != nil {
+			return json.RawMessage(text), false
+		}
+		return json.RawMessage(strconv.FormatBool(value)), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if !json.Valid([]byte(text)) {
+			return json.RawMessage(text), false
+		}
+		return json.RawMessage(text), true
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		if serial, err := strconv.ParseFloat(text, 64); err == nil {
+			at, err := excelize.ExcelDateToTime(serial, false)
+			if err != nil {
+				return raw, false
+			}
+			raw, _ = json.Marshal(at.Round(time.Millisecond))
+		}
+	}
+	return raw, true
+}
@@ -0,0 +1,129 @@
+// Package tabular reads and writes rows as CSV, JSON Lines or XLSX for exports and imports.
+//
+// Columns are the JSON names of a DTO's fields. CSV and XLSX files start with a header row,
+// JSON Lines files hold one object per line.
+package tabular
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+type Format string
+
+const (
+	CSV       Format = "csv"
+	JSONLines Format = "jsonl"
+	XLSX      Format = "xlsx"
+)
+
+var ErrFormat = errors.New("format must be csv, jsonl or xlsx")
+var ErrColumn = errors.New("unknown column")
+
+// ParseFormat accepts a format name, case-insensitive
+func ParseFormat(name string) (Format, error) {
+	switch format := Format(strings.ToLower(name)); format {
+	case CSV, JSONLines, XLSX:
+		return format, nil
+	}
+	return "", ErrFormat
+}
+
+// FormatOf tells the format of a file by its extension
+func FormatOf(fileName string) (Format, error) {
+	return ParseFormat(strings.TrimPrefix(filepath.Ext(fileName), "."))
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case JSONLines:
+		return "application/jsonl"
+	}
+	return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+}
+
+// Columns lists the JSON names of the fields of T in declaration order
+func Columns[T any]() []string {
+	return fields(reflect.TypeOf(*new(T))).names
+}
+
+// Select checks the requested columns against T, none means all of them
+func Select[T any](requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return Columns[T](), nil
+	}
+	known := fields(reflect.TypeOf(*new(T)))
+	for _, column := range requested {
+		if _, ok := known.get(column); !ok {
+			return nil, fmt.Errorf("%w %s", ErrColumn, column)
+		}
+	}
+	return requested, nil
+}
+
+// Values reads the columns of a DTO, nil pointers are nil
+func Values(v interface{}, columns []string) []interface{} {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	known := fields(value.Type())
+	values := make([]interface{}, 0, len(columns))
+	for _, column := range columns {
+		field, ok := known.get(column)
+		if !ok {
+			values = append(values, nil)
+			continue
+		}
+		cell := value.FieldByIndex(field.Index)
+		if cell.Kind() == reflect.Pointer {
+			if cell.IsNil() {
+				values = append(values, nil)
+				continue
+			}
+			cell = cell.Elem()
+		}
+		values = append(values, cell.Interface())
+	}
+	return values
+}
+
+// fieldSet maps the JSON names of a struct's fields to the fields
+type fieldSet struct {
+	names  []string
+	fields map[string]reflect.StructField
+}
+
+func fields(t reflect.Type) fieldSet {
+	set := fieldSet{fields: map[string]reflect.StructField{}}
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		set.names = append(set.names, name)
+		set.fields[name] = field
+	}
+	return set
+}
+
+// get matches names case-insensitively like encoding/json
+func (s fieldSet) get(name string) (reflect.StructField, bool) {
+	if field, ok := s.fields[name]; ok {
+		return field, true
+	}
+	for _, known := range s.names {
+		if strings.EqualFold(known, name) {
+			return s.fields[known], true
+		}
+	}
+	return reflect.StructField{}, false
+}
@@ -0,0 +1,149 @@
+package tabular
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const sheet string = "Sheet1"
+
+// Writer writes rows holding the values of the columns given to NewWriter, in that order
+type Writer interface {
+	Write(values []interface{}) error
+	// Close completes the file, it does not close the underlying writer
+	Close() error
+}
+
+func NewWriter(format Format, w io.Writer, columns []string) (Writer, error) {
+	switch format {
+	case CSV:
+		writer := csv.NewWriter(w)
+		return &csvWriter{writer: writer}, writer.Write(columns)
+	case JSONLines:
+		return &jsonLinesWriter{w: w, columns: columns}, nil
+	case XLSX:
+		file := excelize.NewFile()
+		stream, err := file.NewStreamWriter(sheet)
+		if err != nil {
+			return nil, err
+		}
+		header := make([]interface{}, 0, len(columns))
+		for _, column := range columns {
+			header = append(header, column)
+		}
+		writer := &xlsxWriter{w: w, file: file, stream: stream}
+		return writer, writer.Write(header)
+	}
+	return nil, ErrFormat
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func (c *csvWriter) Write(values []interface{}) error {
+	record := make([]string, 0, len(values))
+	for _, value := range values {
+		record = append(record, text(value))
+	}
+	return c.writer.Write(record)
+}
+
+func (c *csvWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+type jsonLinesWriter struct {
+	w       io.Writer
+	columns []string
+}
+
+// Write keeps the column order, which a map would lose
+func (j *jsonLinesWriter) Write(values []interface{}) error {
+	var line bytes.Buffer
+	line.WriteByte('{')
+	for i, column := range j.columns {
+		if i > 0 {
+			line.WriteByte(',')
+		}
+		key, _ := json.Marshal(column)
+		value, err := json.Marshal(values[i])
+		if err != nil {
+			return err
+		}
+		line.Write(key)
+		line.WriteByte(':')
+		line.Write(value)
+	}
+	line.WriteString("}\n")
+	_, err := j.w.Write(line.Bytes())
+	return err
+}
+
+func (j *jsonLinesWriter) Close() error {
+	return nil
+}
+
+// xlsxWriter keeps numbers, booleans and times typed so spreadsheets can compute with them
+type xlsxWriter struct {
+	w      io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func (x *xlsxWriter) Write(values []interface{}) error {
+	x.row++
+	cells := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		switch value.(type) {
+		case nil, string, bool, time.Time,
+			int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			cells = append(cells, value)
+		default:
+			cells = append(cells, text(value))
+		}
+	}
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	return x.stream.SetRow(cell, cells)
+}
+
+func (x *xlsxWriter) Close() error {
+	defer x.file.Close()
+	if err := x.stream.Flush(); err != nil {
+		return err
+	}
+	return x.file.Write(x.w)
+}
+
+// text formats a value for a text cell, values that are not scalars are written as JSON
+func text(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v)
+	}
+	body, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(body)
+}
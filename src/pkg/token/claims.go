@@ -0,0 +1,64 @@
+package token
+
+import (
+	"encoding/json"
+	"slices"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims are the claims of an access token. Field names follow the tokens
+// issued by Casdoor, Subject is used when the issuer has no id claim.
+type Claims struct {
+	jwt.RegisteredClaims
+	UserId           string `json:"id"`
+	Owner            string `json:"owner"` // Organization of the account, the default tenant
+	Name             string `json:"name"`
+	DisplayName      string `json:"displayName"`
+	Email            string `json:"email"`
+	Phone            string `json:"phone"`
+	Roles            Roles  `json:"roles"`
+	IsAdmin          bool   `json:"isAdmin"`
+	IsForbidden      bool   `json:"isForbidden"`
+	IsDeleted        bool   `json:"isDeleted"`
+	TokenType        string `json:"tokenType"`
+	RefreshTokenType string `json:"TokenType"`
+}
+
+// Identity is the issuer's id of the user
+func (c Claims) Identity() string {
+	if c.UserId != "" {
+		return c.UserId
+	}
+	return c.Subject
+}
+
+func (c Claims) IsRefreshToken() bool {
+	return c.RefreshTokenType == "refresh-token" || c.TokenType == "refresh-token"
+}
+
+func (c Claims) HasRole(role string) bool {
+	return slices.Contains(c.Roles, role)
+}
+
+// Roles are role names, decoded from either ["admin"] or [{"name": "admin"}]
+type Roles []string
+
+func (r *Roles) UnmarshalJSON(data []byte) error {
+	var names []string
+	if err := json.Unmarshal(data, &names); err == nil {
+		*r = names
+		return nil
+	}
+	var objects []struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(data, &objects); err != nil {
+		return err
+	}
+	*r = make(Roles, 0, len(objects))
+	for _, object := range objects {
+		*r = append(*r, object.Name)
+	}
+	return nil
+}
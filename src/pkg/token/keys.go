@@ -0,0 +1,268 @@
+package token
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwksMinRefetch limits how often an unknown key id triggers a new download
+const jwksMinRefetch = time.Minute
+
+var ErrKeyNotFound = errors.New("no key to verify the token")
+
+// KeySource returns the key that verifies a token
+type KeySource interface {
+	Key(ctx context.Context, token *jwt.Token) (interface{}, error)
+}
+
+// StaticKey verifies tokens with a fixed public key or HMAC secret
+type StaticKey struct {
+	publicKey interface{}
+	secret    []byte
+}
+
+// NewStaticKey parses a PEM certificate or public key, the secret is used for HS* tokens.
+// At least one of them must be set.
+func NewStaticKey(certificate string, secret string) (*StaticKey, error) {
+	key := &StaticKey{}
+	if secret != "" {
+		key.secret = []byte(secret)
+	}
+	if certificate != "" {
+		publicKey, err := parsePublicKey([]byte(certificate))
+		if err != nil {
+			return nil, err
+		}
+		key.publicKey = publicKey
+	}
+	if key.publicKey == nil && key.secret == nil {
+		return nil, errors.New("a certificate, public key or secret is required")
+	}
+	return key, nil
+}
+
+func (k *StaticKey) Key(_ context.Context, token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if k.secret == nil {
+			return nil, ErrKeyNotFound
+		}
+		return k.secret, nil
+	}
+	if k.publicKey == nil {
+		return nil, ErrKeyNotFound
+	}
+	return k.publicKey, nil
+}
+
+// HasSecret reports whether HMAC signed tokens can be verified
+func (k *StaticKey) HasSecret() bool {
+	return k.secret != nil
+}
+
+func parsePublicKey(data []byte) (interface{}, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("certificate is not PEM encoded")
+	}
+	switch block.Type {
+	case "CERTIFICATE":
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return certificate.PublicKey, nil
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	}
+}
+
+// JWKS verifies tokens with the keys published by the issuer, keys are cached
+// and downloaded again after the refresh interval or when a token names an unknown key.
+type JWKS struct {
+	url     string
+	refresh time.Duration
+	client  *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]interface{}
+	fetchedAt time.Time
+}
+
+func NewJWKS(url string, refresh time.Duration) *JWKS {
+	if refresh <= 0 {
+		refresh = time.Hour
+	}
+	return &JWKS{url: url, refresh: refresh, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (j *JWKS) Key(ctx context.Context, token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	stale := time.Since(j.fetchedAt) > j.refresh
+	key, ok := j.lookup(kid)
+	if ok && !stale {
+		return key, nil
+	}
+	if stale || time.Since(j.fetchedAt) > jwksMinRefetch {
+		if err := j.fetch(ctx); err != nil {
+			// Keep verifying with the cached keys while the issuer is unreachable
+			if ok {
+				return key, nil
+			}
+			return nil, err
+		}
+		key, ok = j.lookup(kid)
+	}
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return key, nil
+}
+
+// lookup finds a key by id, a token without kid matches when there is a single key
+func (j *JWKS) lookup(kid string) (interface{}, bool) {
+	if kid == "" && len(j.keys) == 1 {
+		for _, key := range j.keys {
+			return key, true
+		}
+	}
+	key, ok := j.keys[kid]
+	return key, ok
+}
+
+func (j *JWKS) fetch(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("jwks request failed with status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return err
+	}
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			continue // Unsupported key types do not prevent using the others
+		}
+		keys[k.Kid] = key
+	}
+	j.keys = keys
+	j.fetchedAt = time.Now()
+	return nil
+}
+
+type jwk struct {
+	Kty string   `json:"kty"`
+	Kid string   `json:"kid"`
+	Use string   `json:"use"`
+	Crv string   `json:"crv"`
+	N   string   `json:"n"`
+	E   string   `json:"e"`
+	X   string   `json:"x"`
+	Y   string   `json:"y"`
+	X5c []string `json:"x5c"`
+}
+
+func (k jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		if k.N == "" && len(k.X5c) > 0 {
+			return k.certificateKey()
+		}
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+}
+
+func (k jwk) certificateKey() (interface{}, error) {
+	der, err := base64.StdEncoding.DecodeString(k.X5c[0])
+	if err != nil {
+		return nil, err
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return certificate.PublicKey, nil
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,71 @@
+// Package token verifies JWT access tokens against a pluggable key source,
+// a static key or the JWKS endpoint of the issuer.
+package token
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrInvalidToken = errors.New("token is invalid")
+	ErrExpiredToken = errors.New("token is expired")
+)
+
+// AsymmetricMethods are accepted by default, HMAC methods have to be enabled explicitly
+var AsymmetricMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+var HMACMethods = []string{"HS256", "HS384", "HS512"}
+
+type Options struct {
+	Issuer   string        // Expected iss claim, not checked when empty
+	Audience string        // Expected aud claim, not checked when empty
+	Leeway   time.Duration // Tolerated clock skew
+	Methods  []string      // Accepted signing algorithms (default: AsymmetricMethods)
+}
+
+type Verifier struct {
+	keys    KeySource
+	options []jwt.ParserOption
+}
+
+func NewVerifier(keys KeySource, opts Options) *Verifier {
+	methods := opts.Methods
+	if len(methods) == 0 {
+		methods = AsymmetricMethods
+	}
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(opts.Leeway),
+	}
+	if opts.Issuer != "" {
+		options = append(options, jwt.WithIssuer(opts.Issuer))
+	}
+	if opts.Audience != "" {
+		options = append(options, jwt.WithAudience(opts.Audience))
+	}
+	return &Verifier{keys: keys, options: options}
+}
+
+// Verify checks the signature and registered claims of an access token
+func (v *Verifier) Verify(ctx context.Context, raw string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (interface{}, error) {
+		return v.keys.Key(ctx, t)
+	}, v.options...)
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, ErrExpiredToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidToken, err.Error())
+	}
+	if claims.IsRefreshToken() {
+		return nil, fmt.Errorf("%w: refresh tokens cannot be used for authentication", ErrInvalidToken)
+	}
+	return claims, nil
+}
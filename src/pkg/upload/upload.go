@@ -0,0 +1,164 @@
+// Package upload validates uploaded content against a policy:
+// size limits, MIME type detection from the actual bytes, type and
+// extension allow/deny lists and SHA-256 checksums.
+package upload
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// DefaultMaxSize is used when a policy does not set MaxSize (10 MB)
+const DefaultMaxSize int64 = 10 << 20
+
+// MaxFileNameLength is the size in characters of the columns holding client file names
+const MaxFileNameLength int = 255
+
+var (
+	ErrTooLarge            = errors.New("file is too large")
+	ErrEmpty               = errors.New("file is empty")
+	ErrTypeNotAllowed      = errors.New("file type is not allowed")
+	ErrExtensionNotAllowed = errors.New("file extension is not allowed")
+	ErrChecksumMismatch    = errors.New("file checksum does not match")
+)
+
+// Policy restricts what can be uploaded on a route.
+// Types are MIME types ("application/pdf") or wildcards ("image/*"),
+// extensions include the leading dot (".pdf"). Empty allow lists allow everything.
+type Policy struct {
+	MaxSize           int64
+	AllowedTypes      []string
+	DeniedTypes       []string
+	AllowedExtensions []string
+}
+
+// Inspection is what was learned from the content itself
+type Inspection struct {
+	MimeType  string
+	Extension string
+	Size      int64
+	Checksum  string // hex encoded SHA-256
+}
+
+func (p Policy) Limit() int64 {
+	if p.MaxSize <= 0 {
+		return DefaultMaxSize
+	}
+	return p.MaxSize
+}
+
+// Inspect reads the whole content once to detect its type and checksum, validates it
+// against the policy and rewinds the content so it can be stored afterwards.
+// expectedChecksum is optional and compared case-insensitively when set.
+func (p Policy) Inspect(content io.ReadSeeker, filename string, expectedChecksum string) (Inspection, error) {
+	mime, err := mimetype.DetectReader(content)
+	if err != nil {
+		return Inspection{}, err
+	}
+	if _, err = content.Seek(0, io.SeekStart); err != nil {
+		return Inspection{}, err
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, io.LimitReader(content, p.Limit()+1))
+	if err != nil {
+		return Inspection{}, err
+	}
+	if _, err = content.Seek(0, io.SeekStart); err != nil {
+		return Inspection{}, err
+	}
+
+	inspection := Inspection{
+		MimeType:  mime.String(),
+		Extension: mime.Extension(),
+		Size:      size,
+		Checksum:  hex.EncodeToString(hash.Sum(nil)),
+	}
+	if inspection.Extension == "" {
+		inspection.Extension = strings.ToLower(filepath.Ext(filename))
+	}
+
+	switch {
+	case size == 0:
+		return inspection, ErrEmpty
+	case size > p.Limit():
+		return inspection, fmt.Errorf("%w, limit is %d bytes", ErrTooLarge, p.Limit())
+	case !p.AllowsType(mime):
+		return inspection, fmt.Errorf("%w: %s", ErrTypeNotAllowed, inspection.MimeType)
+	case !p.AllowsExtension(filename):
+		return inspection, fmt.Errorf("%w: %s", ErrExtensionNotAllowed, filepath.Ext(filename))
+	case expectedChecksum != "" && !strings.EqualFold(expectedChecksum, inspection.Checksum):
+		return inspection, ErrChecksumMismatch
+	}
+	return inspection, nil
+}
+
+// AllowsType checks the detected type and its parents, so "text/*" or "text/plain"
+// also match a more specific detection such as text/csv
+func (p Policy) AllowsType(mime *mimetype.MIME) bool {
+	for m := mime; m != nil; m = m.Parent() {
+		if matchesAny(m.String(), p.DeniedTypes) {
+			return false
+		}
+	}
+	if len(p.AllowedTypes) == 0 {
+		return true
+	}
+	for m := mime; m != nil; m = m.Parent() {
+		if matchesAny(m.String(), p.AllowedTypes) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Policy) AllowsExtension(filename string) bool {
+	if len(p.AllowedExtensions) == 0 {
+		return true
+	}
+	ext := strings.ToLower(filepath.Ext(filename))
+	for _, allowed := range p.AllowedExtensions {
+		if strings.ToLower(allowed) == ext {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAny(mime string, patterns []string) bool {
+	// Drop parameters such as "; charset=utf-8"
+	mime, _, _ = strings.Cut(mime, ";")
+	mime = strings.ToLower(strings.TrimSpace(mime))
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == mime {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok && strings.HasPrefix(mime, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// FileName is the base name of a client file name, made valid UTF-8 and shortened to
+// MaxFileNameLength characters on a character boundary. The extension is kept.
+func FileName(name string) string {
+	name = strings.ToValidUTF8(filepath.Base(name), "\uFFFD")
+	runes := []rune(name)
+	if len(runes) <= MaxFileNameLength {
+		return name
+	}
+	extension := []rune(filepath.Ext(name))
+	if len(extension) >= MaxFileNameLength {
+		return string(runes[:MaxFileNameLength])
+	}
+	return string(runes[:MaxFileNameLength-len(extension)]) + string(extension)
+}
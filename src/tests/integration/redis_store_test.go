@@ -0,0 +1,70 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	infraratelimit "github.com/minisource/template_go/infra/ratelimit"
+	"github.com/redis/go-redis/v9"
+)
+
+// Runs against a Redis server, e.g. the one from docker-compose.dev.yml:
+// REDIS_ADDR=localhost:6382 go test ./tests/integration/...
+func TestRedisStore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+	addr := envOrDefault("REDIS_ADDR", "")
+	if addr == "" {
+		t.Skip("REDIS_ADDR is not set")
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: addr, Password: envOrDefault("REDIS_PASSWORD", "")})
+	defer client.Close()
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Fatalf("Failed to connect to redis: %v", err)
+	}
+
+	store := infraratelimit.NewRedisStore(client)
+	key := "otp:test:" + time.Now().Format("20060102150405.000000")
+	defer store.Delete(ctx, key)
+
+	t.Run("Increment", func(t *testing.T) {
+		for want := int64(1); want <= 3; want++ {
+			count, ttl, err := store.Increment(ctx, key, time.Minute)
+			if err != nil {
+				t.Fatalf("Increment failed: %v", err)
+			}
+			if count != want {
+				t.Fatalf("Increment = %d, want %d", count, want)
+			}
+			if ttl <= 0 || ttl > time.Minute {
+				t.Fatalf("TTL = %v, want within (0, 1m]", ttl)
+			}
+		}
+	})
+
+	t.Run("TTL", func(t *testing.T) {
+		ttl, err := store.TTL(ctx, key)
+		if err != nil || ttl <= 0 {
+			t.Fatalf("TTL = (%v, %v), want a positive duration", ttl, err)
+		}
+		ttl, err = store.TTL(ctx, key+":missing")
+		if err != nil || ttl != 0 {
+			t.Fatalf("TTL of missing key = (%v, %v), want 0", ttl, err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		if err := store.Delete(ctx, key); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+		count, _, err := store.Increment(ctx, key, time.Minute)
+		if err != nil || count != 1 {
+			t.Fatalf("Increment after Delete = (%d, %v), want 1", count, err)
+		}
+	})
+}
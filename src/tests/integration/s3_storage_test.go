@@ -0,0 +1,82 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/domain/storage"
+	infrastorage "github.com/minisource/template_go/infra/storage"
+)
+
+// Runs against an S3 compatible service, e.g. the MinIO container from docker-compose.dev.yml:
+// STORAGE_S3_ENDPOINT=localhost:9000 go test ./tests/integration/...
+func TestS3Storage(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+	endpoint := os.Getenv("STORAGE_S3_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("STORAGE_S3_ENDPOINT is not set")
+	}
+
+	s, err := infrastorage.NewS3Storage(&config.S3StorageConfig{
+		Endpoint:     endpoint,
+		Bucket:       "integration-test",
+		AccessKey:    envOrDefault("STORAGE_S3_ACCESS_KEY", "minioadmin"),
+		SecretKey:    envOrDefault("STORAGE_S3_SECRET_KEY", "minioadmin"),
+		CreateBucket: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+
+	ctx := context.Background()
+	key := "uploads/" + time.Now().Format("20060102150405.000000") + ".txt"
+	content := "hello s3"
+
+	if err = s.Put(ctx, key, strings.NewReader(content), int64(len(content)), "text/plain"); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	info, err := s.Stat(ctx, key)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Size != int64(len(content)) || info.ContentType != "text/plain" {
+		t.Errorf("Unexpected object info %+v", info)
+	}
+
+	reader, err := s.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	data, _ := io.ReadAll(reader)
+	reader.Close()
+	if string(data) != content {
+		t.Errorf("Expected %q, got %q", content, string(data))
+	}
+
+	if _, err = s.Presign(ctx, key, time.Minute); err != nil {
+		t.Errorf("Presign failed: %v", err)
+	}
+
+	if err = s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err = s.Stat(ctx, key); !errors.Is(err, storage.ErrObjectNotFound) {
+		t.Errorf("Expected ErrObjectNotFound, got %v", err)
+	}
+}
+
+func envOrDefault(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,41 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/minisource/template_go/domain/model"
+	"github.com/minisource/template_go/pkg/search"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Runs against a PostgreSQL server, e.g. the one from docker/docker-compose.yml:
+// POSTGRES_DSN="host=localhost user=postgres password=admin dbname=car_sale_db port=5432 sslmode=disable" go test ./tests/integration/...
+func TestSearchHighlightsAreEscaped(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+	dsn := envOrDefault("POSTGRES_DSN", "")
+	if dsn == "" {
+		t.Skip("POSTGRES_DSN is not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to postgres: %v", err)
+	}
+
+	stored := `Quarterly report <script>alert("x")</script> & notes`
+	hits, args := search.Hits([]search.Field{{Column: "description", Weight: "B"}}, "report")
+	args = append(args, stored, stored)
+	var hit model.SearchHit
+	if err := db.Raw("SELECT "+hits+" FROM (SELECT ?::text AS description, to_tsvector('simple', ?::text) AS search_vector) rows", args...).
+		Scan(&hit).Error; err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+
+	want := "Quarterly <mark>report</mark> &lt;script&gt;alert(&quot;x&quot;)&lt;/script&gt; &amp; notes"
+	if hit.SearchHighlights["description"] != want {
+		t.Fatalf("Expected %q, got %q", want, hit.SearchHighlights["description"])
+	}
+}
@@ -0,0 +1,99 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/minisource/template_go/api/dto"
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/domain/model"
+	"github.com/minisource/template_go/domain/repository"
+	"github.com/minisource/template_go/pkg/keyset"
+	"github.com/minisource/template_go/usecase"
+	usecaseDto "github.com/minisource/template_go/usecase/dto"
+	"github.com/minisource/go-common/filter"
+	"github.com/minisource/go-common/logging"
+)
+
+func TestAuditChanges(t *testing.T) {
+	when := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	before := map[string]interface{}{
+		"description": "old",
+		"size":        int64(10),
+		"preferences": []byte(`{"theme":"dark"}`),
+		"avatar_id":   nil,
+		"synced_at":   when,
+	}
+	after := map[string]interface{}{
+		"description": "new",
+		"size":        int64(10),
+		"preferences": []byte(`{"theme":"light"}`),
+		"avatar_id":   int64(4),
+		"synced_at":   when,
+	}
+
+	changed := model.AuditChanges(before, after)
+	if len(changed) != 3 {
+		t.Fatalf("AuditChanges() = %v, want description, preferences and avatar_id", changed)
+	}
+	if got := changed["description"]; got.Before != "old" || got.After != "new" {
+		t.Errorf("description = %+v", got)
+	}
+	if got := changed["avatar_id"]; got.Before != nil || got.After != int64(4) {
+		t.Errorf("avatar_id = %+v", got)
+	}
+
+	// jsonb values stay JSON instead of base64 bytes
+	raw, err := json.Marshal(changed["preferences"])
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if string(raw) != `{"before":{"theme":"dark"},"after":{"theme":"light"}}` {
+		t.Errorf("preferences = %s", raw)
+	}
+}
+
+func TestAuditLogIsNotAudited(t *testing.T) {
+	var entry any = model.AuditLog{}
+	if _, ok := entry.(model.Unaudited); !ok {
+		t.Fatal("AuditLog must be Unaudited, recording it would recurse")
+	}
+	if _, ok := entry.(model.Global); ok {
+		t.Fatal("AuditLog must be scoped by tenant")
+	}
+}
+
+type fakeAuditLogRepository struct {
+	entries []model.AuditLog
+}
+
+func (r *fakeAuditLogRepository) GetByFilter(_ context.Context, req filter.PaginationInputWithFilter) (int64, *[]model.AuditLog, error) {
+	return int64(len(r.entries)), &r.entries, nil
+}
+
+func (r *fakeAuditLogRepository) GetByCursor(_ context.Context, req keyset.Request) (*keyset.Page[model.AuditLog], error) {
+	return &keyset.Page[model.AuditLog]{Items: &r.entries}, nil
+}
+
+func TestAuditLogIsForAdmins(t *testing.T) {
+	var _ repository.AuditLogRepository = &fakeAuditLogRepository{}
+	cfg := &config.Config{Logger: logging.LoggerConfig{FilePath: os.TempDir() + "/", Encoding: "json", Level: "error", Logger: "zap"}}
+	audit := usecase.NewAuditUsecase(cfg, &fakeAuditLogRepository{})
+
+	if _, err := audit.GetByFilter(userContext(7), filter.PaginationInputWithFilter{}); err == nil {
+		t.Fatal("GetByFilter() for a user succeeded")
+	}
+	if _, err := audit.GetByCursor(userContext(7), keyset.Request{}); err == nil {
+		t.Fatal("GetByCursor() for a user succeeded")
+	}
+}
+
+func TestAuditLogResponse(t *testing.T) {
+	response := dto.ToAuditLogResponse(usecaseDto.AuditLog{Id: 1, Action: model.AuditUpdate, Entity: "File", EntityId: 9, CreatedBy: 7, Ip: "10.0.0.1"})
+	if response.UserId != 7 || response.EntityId != 9 || response.Action != "update" || response.Ip != "10.0.0.1" {
+		t.Fatalf("ToAuditLogResponse() = %+v", response)
+	}
+}
@@ -0,0 +1,76 @@
+package unit
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/domain/model"
+	"github.com/minisource/template_go/pkg/patch"
+	"github.com/minisource/template_go/usecase"
+	usecaseDto "github.com/minisource/template_go/usecase/dto"
+	"github.com/minisource/go-common/logging"
+)
+
+func TestBulkDeleteBestEffortKeepsSucceededItems(t *testing.T) {
+	files, repo, _ := newTrashFileUsecase(config.SoftDeleteConfig{})
+
+	// File 9 does not belong to the caller
+	results, err := files.BulkDelete(userContext(7), false, []int{1, 9})
+	if err != nil {
+		t.Fatalf("BulkDelete() error = %v", err)
+	}
+	if len(results) != 2 || results[0].Err != nil || results[0].Value != 1 {
+		t.Fatalf("results[0] = %+v, want file 1 deleted", results[0])
+	}
+	if results[1].Err == nil || errors.Is(results[1].Err, usecase.ErrBulkRolledBack) {
+		t.Fatalf("results[1] = %+v, want the ownership error", results[1])
+	}
+	if !slices.Equal(repo.deleted, []int{2, 3, 1}) {
+		t.Fatalf("deleted %v", repo.deleted)
+	}
+}
+
+func TestBulkAtomicReportsRolledBackItems(t *testing.T) {
+	files, _, _ := newTrashFileUsecase(config.SoftDeleteConfig{})
+
+	results, err := files.BulkDelete(userContext(7), true, []int{9, 1})
+	if err != nil {
+		t.Fatalf("BulkDelete() error = %v", err)
+	}
+	// Every item is tried, so all the failures are reported at once
+	if results[0].Err == nil || errors.Is(results[0].Err, usecase.ErrBulkRolledBack) {
+		t.Fatalf("results[0] = %+v, want the ownership error", results[0])
+	}
+	if !errors.Is(results[1].Err, usecase.ErrBulkRolledBack) || results[1].Value != 0 {
+		t.Fatalf("results[1] = %+v, want rolled back", results[1])
+	}
+}
+
+func TestBulkRunsItemsInSavepoints(t *testing.T) {
+	users := &fakeUserRepository{user: model.User{BaseModel: model.BaseModel{Id: 7, Version: 3}}}
+	unitOfWork := &fakeUnitOfWork{}
+	cfg := &config.Config{Logger: logging.LoggerConfig{FilePath: os.TempDir() + "/", Encoding: "json", Level: "error", Logger: "zap"}}
+	base := usecase.NewBaseUsecase[model.User, usecaseDto.UpdateUserProfile, usecaseDto.UpdateUserProfile, usecaseDto.UserProfile](cfg, users, unitOfWork, nil)
+
+	locale, err := patch.Decode[usecaseDto.UpdateUserProfile]([]byte(`{"Locale":"en-US"}`))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	items := []usecase.BulkPatchItem[usecaseDto.UpdateUserProfile]{{Id: 7, Patch: locale}, {Id: 7, Version: 3, Patch: locale}}
+	results, err := base.BulkPatch(userContext(7), true, items)
+	if err != nil {
+		t.Fatalf("BulkPatch() error = %v", err)
+	}
+	for i, result := range results {
+		if result.Err != nil {
+			t.Fatalf("results[%d] error = %v", i, result.Err)
+		}
+	}
+	// One transaction around a savepoint per item
+	if unitOfWork.runs != 3 {
+		t.Fatalf("unit of work ran %d times, want 3", unitOfWork.runs)
+	}
+}
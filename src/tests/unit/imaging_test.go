@@ -0,0 +1,80 @@
+package unit
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/minisource/template_go/pkg/imaging"
+)
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestImagingRender(t *testing.T) {
+	source, format, err := imaging.Decode(bytes.NewReader(encodePNG(t, 400, 200)), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != imaging.FormatPNG {
+		t.Fatalf("format = %q", format)
+	}
+
+	tests := []struct {
+		name     string
+		variant  imaging.Variant
+		mimeType string
+		width    int
+		height   int
+	}{
+		{"fit in box", imaging.Variant{Width: 100, Height: 100, Format: imaging.FormatWebP}, "image/webp", 100, 50},
+		{"width only", imaging.Variant{Width: 200, Format: imaging.FormatJPEG}, "image/jpeg", 200, 100},
+		{"never enlarged", imaging.Variant{Width: 1000, Height: 1000}, "image/png", 400, 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := imaging.Render(source, format, tt.variant)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if img.MimeType != tt.mimeType || img.Width != tt.width || img.Height != tt.height {
+				t.Fatalf("got %s %dx%d, want %s %dx%d", img.MimeType, img.Width, img.Height, tt.mimeType, tt.width, tt.height)
+			}
+			decoded, _, err := image.DecodeConfig(bytes.NewReader(img.Content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decoded.Width != tt.width || decoded.Height != tt.height {
+				t.Fatalf("encoded %dx%d, want %dx%d", decoded.Width, decoded.Height, tt.width, tt.height)
+			}
+		})
+	}
+}
+
+func TestImagingDecodeLimits(t *testing.T) {
+	if _, _, err := imaging.Decode(bytes.NewReader(encodePNG(t, 100, 100)), 5000); !errors.Is(err, imaging.ErrTooManyPixels) {
+		t.Fatalf("expected ErrTooManyPixels, got %v", err)
+	}
+	if _, _, err := imaging.Decode(bytes.NewReader([]byte("not an image")), 0); !errors.Is(err, imaging.ErrUnsupportedFormat) {
+		t.Fatalf("expected ErrUnsupportedFormat, got %v", err)
+	}
+	if imaging.Supported("application/pdf") || !imaging.Supported("image/webp") {
+		t.Fatal("unexpected Supported result")
+	}
+}
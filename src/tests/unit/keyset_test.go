@@ -0,0 +1,131 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/domain/model"
+	"github.com/minisource/template_go/pkg/keyset"
+	"github.com/minisource/go-common/filter"
+)
+
+func TestKeysetSortEndsWithId(t *testing.T) {
+	tests := []struct {
+		name  string
+		sorts *[]filter.Sort
+		want  string
+	}{
+		{"default", nil, "id asc"},
+		{"id is the tie breaker", &[]filter.Sort{{ColId: "createdAt", Sort: "desc"}}, "created_at desc, id asc"},
+		{"explicit id is kept", &[]filter.Sort{{ColId: "Id", Sort: "DESC"}, {ColId: "name", Sort: "asc"}}, "id desc, name asc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sort, err := keyset.ParseSort[model.File](tt.sorts)
+			if err != nil {
+				t.Fatalf("ParseSort() error = %v", err)
+			}
+			if got := sort.Order(false); got != tt.want {
+				t.Fatalf("Order() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeysetSortRejectsNullableColumns(t *testing.T) {
+	for _, column := range []string{"modifiedAt", "parentId", "variants", "unknown"} {
+		if _, err := keyset.ParseSort[model.File](&[]filter.Sort{{ColId: column, Sort: "asc"}}); !errors.Is(err, keyset.ErrInvalidSort) {
+			t.Errorf("ParseSort(%s) error = %v, want ErrInvalidSort", column, err)
+		}
+	}
+	if _, err := keyset.ParseSort[model.File](&[]filter.Sort{{ColId: "name", Sort: "up"}}); !errors.Is(err, keyset.ErrInvalidSort) {
+		t.Errorf("ParseSort() with direction up error = %v, want ErrInvalidSort", err)
+	}
+}
+
+func TestKeysetAfter(t *testing.T) {
+	sort, err := keyset.ParseSort[model.File](&[]filter.Sort{{ColId: "size", Sort: "desc"}})
+	if err != nil {
+		t.Fatalf("ParseSort() error = %v", err)
+	}
+
+	where, args := sort.After([]interface{}{int64(10), 4}, false)
+	if where != "((size < ?) or (size = ? and id > ?))" || len(args) != 3 {
+		t.Fatalf("After() = %q %v", where, args)
+	}
+	if where, _ := sort.After([]interface{}{int64(10), 4}, true); where != "((size > ?) or (size = ? and id < ?))" {
+		t.Fatalf("After() backward = %q", where)
+	}
+	if order := sort.Order(true); order != "size asc, id desc" {
+		t.Fatalf("Order() backward = %q", order)
+	}
+}
+
+func TestKeysetCursorRoundTrip(t *testing.T) {
+	sort, err := keyset.ParseSort[model.File](&[]filter.Sort{{ColId: "createdAt", Sort: "desc"}})
+	if err != nil {
+		t.Fatalf("ParseSort() error = %v", err)
+	}
+	created := time.Date(2026, 3, 4, 5, 6, 7, 891011000, time.UTC)
+	row := model.File{BaseModel: model.BaseModel{Id: 42, CreatedAt: created}}
+
+	cursor, err := sort.Cursor(row, true)
+	if err != nil {
+		t.Fatalf("Cursor() error = %v", err)
+	}
+	values, backward, err := sort.Decode(cursor)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if !backward || len(values) != 2 || !values[0].(time.Time).Equal(created) || values[1] != 42 {
+		t.Fatalf("Decode() = %v, %v", values, backward)
+	}
+
+	// A cursor only continues the sort it was issued for
+	other, _ := keyset.ParseSort[model.File](nil)
+	if _, _, err := other.Decode(cursor); !errors.Is(err, keyset.ErrInvalidCursor) {
+		t.Fatalf("Decode() with another sort error = %v, want ErrInvalidCursor", err)
+	}
+	if _, _, err := sort.Decode("not a cursor"); !errors.Is(err, keyset.ErrInvalidCursor) {
+		t.Fatalf("Decode() of garbage error = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestKeysetLimit(t *testing.T) {
+	for size, want := range map[int]int{0: keyset.DefaultPageSize, -1: keyset.DefaultPageSize, 25: 25, 1000: keyset.MaxPageSize} {
+		if got := (keyset.Request{PageSize: size}).Limit(); got != want {
+			t.Errorf("Limit(%d) = %d, want %d", size, got, want)
+		}
+	}
+}
+
+func (r *trashFileRepository) GetByCursorCreatedBy(_ context.Context, req keyset.Request, createdBy int) (*keyset.Page[model.File], error) {
+	var files []model.File
+	for _, file := range r.files {
+		if file.CreatedBy == createdBy {
+			files = append(files, file)
+		}
+	}
+	return &keyset.Page[model.File]{PageSize: req.Limit(), NextCursor: "next", Items: &files}, nil
+}
+
+func TestFileGetByCursorListsOwnUploads(t *testing.T) {
+	files, _, _ := newTrashFileUsecase(config.SoftDeleteConfig{})
+
+	page, err := files.GetByCursor(userContext(7), keyset.Request{})
+	if err != nil {
+		t.Fatalf("GetByCursor() error = %v", err)
+	}
+	if len(*page.Items) != 1 || (*page.Items)[0].Id != 1 || page.NextCursor != "next" {
+		t.Fatalf("GetByCursor() = %+v", page)
+	}
+
+	page, err = files.GetByCursor(userContext(8), keyset.Request{})
+	if err != nil || len(*page.Items) != 0 {
+		t.Fatalf("GetByCursor() for another user = %+v, %v", page, err)
+	}
+}
@@ -0,0 +1,66 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/domain/storage"
+	infrastorage "github.com/minisource/template_go/infra/storage"
+)
+
+func TestLocalStorageRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	s, err := infrastorage.NewLocalStorage(&config.LocalStorageConfig{RootDirectory: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+
+	content := "hello storage"
+	if err = s.Put(ctx, "uploads/hello.txt", strings.NewReader(content), int64(len(content)), "text/plain"); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	info, err := s.Stat(ctx, "uploads/hello.txt")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Size != int64(len(content)) {
+		t.Errorf("Expected size %d, got %d", len(content), info.Size)
+	}
+
+	reader, err := s.Get(ctx, "uploads/hello.txt")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	data, _ := io.ReadAll(reader)
+	reader.Close()
+	if string(data) != content {
+		t.Errorf("Expected %q, got %q", content, string(data))
+	}
+
+	if err = s.Delete(ctx, "uploads/hello.txt"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err = s.Get(ctx, "uploads/hello.txt"); !errors.Is(err, storage.ErrObjectNotFound) {
+		t.Errorf("Expected ErrObjectNotFound, got %v", err)
+	}
+}
+
+func TestLocalStorageRejectsInvalidKeys(t *testing.T) {
+	s, err := infrastorage.NewLocalStorage(&config.LocalStorageConfig{RootDirectory: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+
+	for _, key := range []string{"", "../secret", "uploads/../../secret", "/absolute", "uploads//double"} {
+		t.Run(key, func(t *testing.T) {
+			if _, err := s.Stat(context.Background(), key); !errors.Is(err, storage.ErrInvalidObjectKey) {
+				t.Errorf("Expected ErrInvalidObjectKey for %q, got %v", key, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package unit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/domain/model"
+	"github.com/minisource/template_go/pkg/mapgen"
+	"github.com/minisource/template_go/usecase"
+	usecaseDto "github.com/minisource/template_go/usecase/dto"
+	"github.com/minisource/go-common/logging"
+	"github.com/minisource/go-common/service_errors"
+)
+
+type mapgenLabel string
+
+type mapgenPart struct {
+	Id   int
+	Name string
+}
+
+type mapgenEntity struct {
+	mapgenPart
+	Title   *string
+	Label   mapgenLabel
+	Parts   []mapgenPart
+	Deleted sql.NullTime
+	Count   int64
+}
+
+type mapgenItem struct {
+	Name string
+}
+
+type mapgenOutput struct {
+	Id      int
+	Name    string
+	Title   string
+	Label   string
+	Parts   []mapgenItem
+	Deleted sql.NullTime
+	Count   int
+	Extra   bool
+}
+
+func TestMapgenGeneratesTypedAssignments(t *testing.T) {
+	source, err := mapgen.Generate("example.com/shop/usecase",
+		mapgen.Map[mapgenPart, mapgenItem]("toItem"),
+		mapgen.Map[mapgenEntity, mapgenOutput]("toOutput"),
+		mapgen.Columns[mapgenPart]("partColumns"),
+	)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	code := string(source)
+	for _, want := range []string{
+		"\t\"github.com/minisource/template_go/tests/unit\"\n",
+		// Promoted from the embedded struct
+		"\tto.Id = from.Id\n\tto.Name = from.Name\n",
+		"\tif from.Title != nil {\n\t\tto.Title = *from.Title\n\t}\n",
+		"\tto.Label = string(from.Label)\n",
+		"\t\t\tto.Parts[i] = toItem(item)\n",
+		"\tto.Deleted = from.Deleted\n",
+		"\tto.Count = int(from.Count)\n",
+		"// toOutput maps unit.mapgenEntity to unit.mapgenOutput, leaving Extra unset\n",
+		"\t\t\"Name\": from.Name,\n",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code lacks %q:\n%s", want, code)
+		}
+	}
+}
+
+func TestMapgenRejectsUnmappableFields(t *testing.T) {
+	// Parts has no mapping without toItem
+	_, err := mapgen.Generate("example.com/shop/usecase", mapgen.Map[mapgenEntity, mapgenOutput]("toOutput"))
+	if !errors.Is(err, mapgen.ErrUnmappable) || !strings.Contains(err.Error(), "Parts") {
+		t.Fatalf("Generate() error = %v", err)
+	}
+}
+
+// failingMapper cannot convert anything
+type failingMapper struct{}
+
+func (failingMapper) ToEntity(req usecaseDto.UpdateUserProfile) (model.User, error) {
+	return model.User{}, errors.New("broken mapper")
+}
+
+func (failingMapper) ToColumns(req usecaseDto.UpdateUserProfile) (map[string]interface{}, error) {
+	return nil, errors.New("broken mapper")
+}
+
+func (failingMapper) ToResponse(entity model.User) (usecaseDto.UserProfile, error) {
+	return usecaseDto.UserProfile{}, errors.New("broken mapper")
+}
+
+func TestMapperErrorsAreServiceErrors(t *testing.T) {
+	users := &fakeUserRepository{user: model.User{BaseModel: model.BaseModel{Id: 7}}}
+	cfg := &config.Config{Logger: logging.LoggerConfig{FilePath: os.TempDir() + "/", Encoding: "json", Level: "error", Logger: "zap"}}
+	base := usecase.NewBaseUsecase[model.User, usecaseDto.UpdateUserProfile, usecaseDto.UpdateUserProfile, usecaseDto.UserProfile](cfg, users, &fakeUnitOfWork{}, failingMapper{})
+
+	var serviceErr *service_errors.ServiceError
+	if _, err := base.GetById(context.Background(), 7); !errors.As(err, &serviceErr) || serviceErr.Err == nil {
+		t.Fatalf("GetById() error = %v, want a ServiceError with the cause", err)
+	}
+	if _, err := base.Update(context.Background(), 7, 0, usecaseDto.UpdateUserProfile{}); !errors.As(err, &serviceErr) {
+		t.Fatalf("Update() error = %v, want a ServiceError", err)
+	}
+	if users.updated != nil {
+		t.Fatal("Update() wrote although the mapper failed")
+	}
+}
+
+func TestFileMapperKeepsEmbeddedFields(t *testing.T) {
+	files, _, _ := newTrashFileUsecase(config.SoftDeleteConfig{})
+
+	file, err := files.GetById(userContext(7), 1)
+	if err != nil {
+		t.Fatalf("GetById() error = %v", err)
+	}
+	// Id and Name are fields of the embedded IdName
+	if file.Id != 1 || file.Name != "a.png" || file.Directory != "uploads" {
+		t.Fatalf("GetById() = %+v", file)
+	}
+	if len(file.Variants) != 2 || file.Variants[1].Id != 3 {
+		t.Fatalf("variants = %+v", file.Variants)
+	}
+}
@@ -0,0 +1,113 @@
+package unit
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/minisource/template_go/infra/persistence/migration"
+	"gorm.io/gorm"
+)
+
+func noop(tx *gorm.DB) error {
+	return nil
+}
+
+var registered = []migration.Migration{
+	{Version: 1, Name: "init", Up: noop, Down: noop},
+	{Version: 2, Name: "add_file", Up: noop, Down: noop},
+	{Version: 3, Name: "add_index", Up: noop, Down: noop},
+	{Version: 5, Name: "add_tag", Up: noop, Down: noop},
+}
+
+// appliedSet fakes the schema_migrations rows
+func appliedSet(versions ...int) map[int]migration.SchemaMigration {
+	applied := map[int]migration.SchemaMigration{}
+	for _, version := range versions {
+		applied[version] = migration.SchemaMigration{Version: version}
+	}
+	return applied
+}
+
+// describe renders steps as signed versions, negative ones are rollbacks
+func describe(steps []migration.Step) []int {
+	versions := []int{}
+	for _, step := range steps {
+		if step.Down {
+			versions = append(versions, -step.Migration.Version)
+		} else {
+			versions = append(versions, step.Migration.Version)
+		}
+	}
+	return versions
+}
+
+func TestMigrationPlan(t *testing.T) {
+	tests := []struct {
+		name    string
+		applied []int
+		version int
+		want    []int
+	}{
+		{"up from nothing", nil, 5, []int{1, 2, 3, 5}},
+		{"up to a version", []int{1}, 3, []int{2, 3}},
+		{"fills a gap", []int{1, 3}, 5, []int{2, 5}},
+		{"down to a version", []int{1, 2, 3, 5}, 2, []int{-5, -3}},
+		{"down to nothing", []int{1, 2}, 0, []int{-2, -1}},
+		{"at the version", []int{1, 2, 3}, 3, []int{}},
+		{"nothing to roll back", nil, 0, []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps, err := migration.Plan(registered, appliedSet(tt.applied...), tt.version)
+			if err != nil {
+				t.Fatalf("Plan() error = %v", err)
+			}
+			if got := describe(steps); !slices.Equal(got, tt.want) {
+				t.Fatalf("Plan() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrationPlanRejects(t *testing.T) {
+	if _, err := migration.Plan(registered, appliedSet(1), 4); err == nil {
+		t.Fatal("Plan() to an unknown version succeeded")
+	}
+	unordered := []migration.Migration{registered[1], registered[0]}
+	if _, err := migration.Plan(unordered, appliedSet(), 2); err == nil {
+		t.Fatal("Plan() of unordered migrations succeeded")
+	}
+	if _, err := migration.Plan(nil, appliedSet(), 0); err == nil {
+		t.Fatal("Plan() without migrations succeeded")
+	}
+}
+
+func TestMigrationPlanStopsAtIrreversible(t *testing.T) {
+	baseline := append([]migration.Migration{{Version: 1, Name: "init", Up: noop}}, registered[1:]...)
+	if _, err := migration.Plan(baseline, appliedSet(1, 2, 3), 0); !errors.Is(err, migration.ErrIrreversible) {
+		t.Fatalf("Plan() past the baseline error = %v, want %v", err, migration.ErrIrreversible)
+	}
+	steps, err := migration.Plan(baseline, appliedSet(1, 2, 3), 1)
+	if err != nil || !slices.Equal(describe(steps), []int{-3, -2}) {
+		t.Fatalf("Plan() to the baseline = %v, %v", describe(steps), err)
+	}
+}
+
+func TestMigrationPrevious(t *testing.T) {
+	tests := []struct {
+		applied []int
+		want    int
+	}{
+		{nil, 0},
+		{[]int{1}, 0},
+		{[]int{1, 3, 5}, 3},
+		{[]int{5, 2, 1}, 2},
+	}
+	for _, tt := range tests {
+		if got := migration.Previous(appliedSet(tt.applied...)); got != tt.want {
+			t.Errorf("Previous(%v) = %d, want %d", tt.applied, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/minisource/template_go/config"
+	infraratelimit "github.com/minisource/template_go/infra/ratelimit"
+	"github.com/minisource/template_go/usecase"
+)
+
+func newOtpLimiter() *usecase.OtpLimiter {
+	cfg := &config.Config{OtpLimit: config.OtpLimitConfig{
+		SendPerPhone: 2,
+		SendPerIp:    3,
+		SendWindow:   time.Minute,
+		MaxAttempts:  3,
+		LockDuration: time.Minute,
+	}}
+	return usecase.NewOtpLimiter(cfg, infraratelimit.NewMemoryStore())
+}
+
+func assertLimited(t *testing.T, err error) {
+	t.Helper()
+	var limitErr *usecase.OtpLimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("expected OtpLimitError, got %v", err)
+	}
+	if limitErr.RetryAfter <= 0 || limitErr.RetryAfter > time.Minute {
+		t.Fatalf("RetryAfter = %v, want within (0, 1m]", limitErr.RetryAfter)
+	}
+}
+
+func TestOtpLimiterSendPerPhone(t *testing.T) {
+	ctx := context.Background()
+	limiter := newOtpLimiter()
+
+	for i := 0; i < 2; i++ {
+		if err := limiter.AllowSend(ctx, "+989120000001", ""); err != nil {
+			t.Fatalf("send %d: unexpected error %v", i+1, err)
+		}
+	}
+	assertLimited(t, limiter.AllowSend(ctx, "+989120000001", ""))
+
+	// Other phone numbers have their own counter
+	if err := limiter.AllowSend(ctx, "+989120000002", ""); err != nil {
+		t.Fatalf("unexpected error for another phone: %v", err)
+	}
+}
+
+func TestOtpLimiterSendPerIp(t *testing.T) {
+	ctx := context.Background()
+	limiter := newOtpLimiter()
+
+	phones := []string{"+989120000001", "+989120000002", "+989120000003"}
+	for _, phone := range phones {
+		if err := limiter.AllowSend(ctx, phone, "10.0.0.1"); err != nil {
+			t.Fatalf("send to %s: unexpected error %v", phone, err)
+		}
+	}
+	assertLimited(t, limiter.AllowSend(ctx, "+989120000004", "10.0.0.1"))
+
+	if err := limiter.AllowSend(ctx, "+989120000004", "10.0.0.2"); err != nil {
+		t.Fatalf("unexpected error for another ip: %v", err)
+	}
+}
+
+func TestOtpLimiterThrottledIpDoesNotCountPhone(t *testing.T) {
+	ctx := context.Background()
+	limiter := newOtpLimiter()
+	victim := "+989120000009"
+
+	for _, phone := range []string{"+989120000001", "+989120000002", "+989120000003"} {
+		if err := limiter.AllowSend(ctx, phone, "10.0.0.1"); err != nil {
+			t.Fatalf("send to %s: unexpected error %v", phone, err)
+		}
+	}
+	for i := 0; i < 5; i++ {
+		assertLimited(t, limiter.AllowSend(ctx, victim, "10.0.0.1"))
+	}
+
+	// The victim still has all of its sends from another client
+	for i := 0; i < 2; i++ {
+		if err := limiter.AllowSend(ctx, victim, "10.0.0.2"); err != nil {
+			t.Fatalf("victim send %d: unexpected error %v", i+1, err)
+		}
+	}
+}
+
+func TestOtpLimiterLocksAfterWrongCodes(t *testing.T) {
+	ctx := context.Background()
+	limiter := newOtpLimiter()
+	phone := "+989120000001"
+
+	for i := 0; i < 2; i++ {
+		if err := limiter.RecordFailure(ctx, phone); err != nil {
+			t.Fatalf("failure %d: unexpected error %v", i+1, err)
+		}
+	}
+	if err := limiter.CheckLocked(ctx, phone); err != nil {
+		t.Fatalf("locked before MaxAttempts: %v", err)
+	}
+
+	assertLimited(t, limiter.RecordFailure(ctx, phone))
+	assertLimited(t, limiter.CheckLocked(ctx, phone))
+	// A locked phone number does not receive new codes either
+	assertLimited(t, limiter.AllowSend(ctx, phone, ""))
+}
+
+func TestOtpLimiterResetForgetsFailures(t *testing.T) {
+	ctx := context.Background()
+	limiter := newOtpLimiter()
+	phone := "+989120000001"
+
+	for i := 0; i < 2; i++ {
+		if err := limiter.RecordFailure(ctx, phone); err != nil {
+			t.Fatalf("failure %d: unexpected error %v", i+1, err)
+		}
+	}
+	if err := limiter.Reset(ctx, phone); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := limiter.RecordFailure(ctx, phone); err != nil {
+			t.Fatalf("failure %d after reset: unexpected error %v", i+1, err)
+		}
+	}
+}
+
+func TestMemoryStoreWindowExpires(t *testing.T) {
+	ctx := context.Background()
+	store := infraratelimit.NewMemoryStore()
+
+	count, ttl, err := store.Increment(ctx, "key", 20*time.Millisecond)
+	if err != nil || count != 1 || ttl <= 0 {
+		t.Fatalf("Increment = (%d, %v, %v), want (1, >0, nil)", count, ttl, err)
+	}
+	if count, _, _ = store.Increment(ctx, "key", 20*time.Millisecond); count != 2 {
+		t.Fatalf("second Increment = %d, want 2", count)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if ttl, _ := store.TTL(ctx, "key"); ttl != 0 {
+		t.Fatalf("TTL after window = %v, want 0", ttl)
+	}
+	if count, _, _ = store.Increment(ctx, "key", 20*time.Millisecond); count != 1 {
+		t.Fatalf("Increment after window = %d, want 1", count)
+	}
+}
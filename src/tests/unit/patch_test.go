@@ -0,0 +1,118 @@
+package unit
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/minisource/template_go/api/dto"
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/domain/model"
+	"github.com/minisource/template_go/pkg/patch"
+	"github.com/minisource/template_go/usecase"
+	usecaseDto "github.com/minisource/template_go/usecase/dto"
+	"github.com/minisource/go-common/logging"
+)
+
+type patchRequest struct {
+	Name     string  `json:"name"`
+	Note     *string `json:"note"`
+	Count    int     `json:"count,omitempty"`
+	Internal string  `json:"-"`
+}
+
+func TestDecodeMergePatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{"absent fields are untouched", `{"name":"a"}`, map[string]interface{}{"Name": "a"}, false},
+		{"null resets a pointer", `{"note":null}`, map[string]interface{}{"Note": nil}, false},
+		{"null resets a value to zero", `{"name":null}`, map[string]interface{}{"Name": ""}, false},
+		{"pointer is dereferenced", `{"note":"x","count":0}`, map[string]interface{}{"Note": "x", "Count": 0}, false},
+		{"member names ignore case", `{"NAME":"a"}`, map[string]interface{}{"Name": "a"}, false},
+		{"empty patch", `{}`, map[string]interface{}{}, false},
+		{"unknown member", `{"id":1}`, nil, true},
+		{"hidden field", `{"Internal":"x"}`, nil, true},
+		{"not an object", `["name"]`, nil, true},
+		{"null document", `null`, nil, true},
+		{"wrong type", `{"count":"1"}`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := patch.Decode[patchRequest]([]byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			columns := p.Columns()
+			if len(columns) != len(tt.want) {
+				t.Fatalf("Columns() = %v, want %v", columns, tt.want)
+			}
+			for name, value := range tt.want {
+				if got, ok := columns[name]; !ok || got != value {
+					t.Errorf("Columns()[%s] = %v, want %v", name, got, value)
+				}
+			}
+		})
+	}
+}
+
+func TestMapKeepsPresentFields(t *testing.T) {
+	p, err := patch.Decode[dto.UpdateFileRequest]([]byte(`{"description":"new"}`))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	mapped := patch.Map(p, dto.ToUpdateFile)
+	if !mapped.Has("Description") {
+		t.Fatal("mapped patch lost the Description field")
+	}
+	if got := mapped.Columns()["Description"]; got != "new" {
+		t.Fatalf("Description = %v, want new", got)
+	}
+}
+
+func newUserBaseUsecase() (*usecase.BaseUsecase[model.User, usecaseDto.UpdateUserProfile, usecaseDto.UpdateUserProfile, usecaseDto.UserProfile], *fakeUserRepository) {
+	users := &fakeUserRepository{user: model.User{BaseModel: model.BaseModel{Id: 7, Version: 3}}}
+	cfg := &config.Config{Logger: logging.LoggerConfig{FilePath: os.TempDir() + "/", Encoding: "json", Level: "error", Logger: "zap"}}
+	return usecase.NewBaseUsecase[model.User, usecaseDto.UpdateUserProfile, usecaseDto.UpdateUserProfile, usecaseDto.UserProfile](cfg, users, &fakeUnitOfWork{}, nil), users
+}
+
+func TestPatchWritesOnlyPresentFields(t *testing.T) {
+	base, users := newUserBaseUsecase()
+	p, err := patch.Decode[usecaseDto.UpdateUserProfile]([]byte(`{"Locale":"en-US","AvatarId":null}`))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	if _, err := base.Patch(userContext(7), 7, 3, p); err != nil {
+		t.Fatalf("Patch() error = %v", err)
+	}
+	if len(users.updated) != 2 || users.updated["Locale"] != "en-US" {
+		t.Fatalf("updated %v, want Locale and AvatarId only", users.updated)
+	}
+	if value, ok := users.updated["AvatarId"]; !ok || value != nil {
+		t.Fatalf("AvatarId = %v, want nil", value)
+	}
+}
+
+func TestEmptyPatchChecksVersion(t *testing.T) {
+	base, users := newUserBaseUsecase()
+	empty := patch.Patch[usecaseDto.UpdateUserProfile]{}
+
+	profile, err := base.Patch(userContext(7), 7, 3, empty)
+	if err != nil || profile.Version != 3 {
+		t.Fatalf("Patch() = %v, %v, want the unchanged profile", profile, err)
+	}
+	if _, err := base.Patch(userContext(7), 7, 2, empty); !errors.Is(err, usecase.ErrVersionConflict) {
+		t.Fatalf("Patch() error = %v, want ErrVersionConflict", err)
+	}
+	if users.updated != nil {
+		t.Fatalf("empty patch wrote %v", users.updated)
+	}
+}
@@ -0,0 +1,71 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/minisource/template_go/constant"
+	"github.com/minisource/template_go/domain/principal"
+)
+
+func TestPrincipalContext(t *testing.T) {
+	if _, ok := principal.FromContext(context.Background()); ok {
+		t.Fatal("FromContext() found a principal in an empty context")
+	}
+	// Lookups by the old string key must not resolve the principal
+	ctx := context.WithValue(context.Background(), "UserId", float64(3))
+	if _, ok := principal.UserId(ctx); ok {
+		t.Fatal("UserId() resolved a plain string key")
+	}
+
+	ctx = principal.WithPrincipal(context.Background(), principal.Principal{UserId: 3, Roles: []string{"editor"}})
+	userId, ok := principal.UserId(ctx)
+	if !ok || userId != 3 {
+		t.Fatalf("UserId() = (%d, %v), want (3, true)", userId, ok)
+	}
+	p, _ := principal.FromContext(ctx)
+	if !p.HasRole("editor") || p.IsAdmin() {
+		t.Fatalf("roles of %+v resolved wrongly", p)
+	}
+}
+
+func TestPrincipalSetLocal(t *testing.T) {
+	locals := map[interface{}]interface{}{}
+	set := func(key interface{}, value ...interface{}) interface{} {
+		locals[key] = value[0]
+		return value[0]
+	}
+	principal.SetLocal(set, principal.Principal{UserId: 5})
+
+	// fasthttp resolves context values from the request locals the same way
+	ctx := localsContext{Context: context.Background(), locals: locals}
+	if userId, ok := principal.UserId(ctx); !ok || userId != 5 {
+		t.Fatalf("UserId() = (%d, %v), want (5, true)", userId, ok)
+	}
+}
+
+type localsContext struct {
+	context.Context
+	locals map[interface{}]interface{}
+}
+
+func (c localsContext) Value(key interface{}) interface{} {
+	return c.locals[key]
+}
+
+func TestSystemPrincipal(t *testing.T) {
+	system := principal.System("cleanup")
+	if !system.System || system.UserId != principal.SystemUserId || !system.IsAdmin() {
+		t.Fatalf("System() = %+v, want an admin system principal", system)
+	}
+
+	owner := system.For(9)
+	if owner.UserId != 9 || !owner.System || system.UserId != principal.SystemUserId {
+		t.Fatalf("For(9) = %+v, original %+v", owner, system)
+	}
+
+	admin := principal.Principal{UserId: 1, Roles: []string{constant.AdminRoleName}}
+	if !admin.IsAdmin() {
+		t.Fatal("IsAdmin() is false for the admin role")
+	}
+}
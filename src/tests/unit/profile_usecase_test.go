@@ -0,0 +1,136 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/domain/model"
+	"github.com/minisource/template_go/domain/principal"
+	"github.com/minisource/template_go/domain/repository"
+	"github.com/minisource/template_go/usecase"
+	"github.com/minisource/template_go/usecase/dto"
+	"github.com/minisource/go-common/logging"
+)
+
+// fakeUserRepository keeps a single user and records the last update
+type fakeUserRepository struct {
+	repository.UserRepository
+	user    model.User
+	updated map[string]interface{}
+}
+
+func (r *fakeUserRepository) Update(_ context.Context, id int, version int, columns map[string]interface{}) (model.User, error) {
+	r.updated = columns
+	return model.User{}, nil
+}
+
+func (r *fakeUserRepository) GetById(_ context.Context, id int) (model.User, error) {
+	if id != r.user.Id {
+		return model.User{}, errors.New("record not found")
+	}
+	return r.user, nil
+}
+
+type fakeFileRepository struct {
+	repository.FileRepository
+	files map[int]model.File
+}
+
+func (r *fakeFileRepository) GetById(_ context.Context, id int) (model.File, error) {
+	file, ok := r.files[id]
+	if !ok {
+		return model.File{}, errors.New("record not found")
+	}
+	return file, nil
+}
+
+func newProfileUsecase() (*usecase.ProfileUsecase, *fakeUserRepository) {
+	users := &fakeUserRepository{user: model.User{BaseModel: model.BaseModel{Id: 7}, UserId: "abc", Preferences: json.RawMessage(`{}`)}}
+	parent := 10
+	files := &fakeFileRepository{files: map[int]model.File{
+		10: {BaseModel: model.BaseModel{Id: 10, CreatedBy: 7}, MimeType: "image/png"},
+		11: {BaseModel: model.BaseModel{Id: 11, CreatedBy: 8}, MimeType: "image/png"},
+		12: {BaseModel: model.BaseModel{Id: 12, CreatedBy: 7}, MimeType: "application/pdf"},
+		13: {BaseModel: model.BaseModel{Id: 13, CreatedBy: 7}, MimeType: "image/webp", ParentId: &parent},
+	}}
+	cfg := &config.Config{Logger: logging.LoggerConfig{FilePath: os.TempDir() + "/", Encoding: "json", Level: "error", Logger: "zap"}}
+	return usecase.NewProfileUsecase(cfg, users, &fakeUnitOfWork{}, files), users
+}
+
+func userContext(id int) context.Context {
+	return principal.WithPrincipal(context.Background(), principal.Principal{UserId: id})
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
+
+func TestProfileUpdateValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     dto.UpdateUserProfile
+		wantErr error
+	}{
+		{"valid locale", dto.UpdateUserProfile{Locale: ptr("fa-IR")}, nil},
+		{"invalid locale", dto.UpdateUserProfile{Locale: ptr("not a locale")}, usecase.ErrInvalidLocale},
+		{"valid timezone", dto.UpdateUserProfile{Timezone: ptr("Asia/Tehran")}, nil},
+		{"unknown timezone", dto.UpdateUserProfile{Timezone: ptr("Mars/Olympus")}, usecase.ErrInvalidTimezone},
+		{"local timezone", dto.UpdateUserProfile{Timezone: ptr("Local")}, usecase.ErrInvalidTimezone},
+		{"preferences object", dto.UpdateUserProfile{Preferences: json.RawMessage(`{"theme":"dark"}`)}, nil},
+		{"preferences array", dto.UpdateUserProfile{Preferences: json.RawMessage(`["dark"]`)}, usecase.ErrInvalidPreferences},
+		{"preferences null", dto.UpdateUserProfile{Preferences: json.RawMessage(`null`)}, usecase.ErrInvalidPreferences},
+		{"own image avatar", dto.UpdateUserProfile{AvatarId: ptr(10)}, nil},
+		{"remove avatar", dto.UpdateUserProfile{AvatarId: ptr(0)}, nil},
+		{"other user's avatar", dto.UpdateUserProfile{AvatarId: ptr(11)}, usecase.ErrInvalidAvatar},
+		{"non image avatar", dto.UpdateUserProfile{AvatarId: ptr(12)}, usecase.ErrInvalidAvatar},
+		{"variant avatar", dto.UpdateUserProfile{AvatarId: ptr(13)}, usecase.ErrInvalidAvatar},
+		{"missing avatar", dto.UpdateUserProfile{AvatarId: ptr(99)}, usecase.ErrInvalidAvatar},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profiles, _ := newProfileUsecase()
+			_, err := profiles.UpdateMe(userContext(7), tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateMe() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProfileUpdateColumns(t *testing.T) {
+	profiles, users := newProfileUsecase()
+
+	_, err := profiles.UpdateMe(userContext(7), dto.UpdateUserProfile{
+		DisplayName: ptr("  Sara  "),
+		Locale:      ptr("en-us"),
+		AvatarId:    ptr(0),
+	})
+	if err != nil {
+		t.Fatalf("UpdateMe() error = %v", err)
+	}
+
+	if len(users.updated) != 3 {
+		t.Fatalf("updated %v, want only the fields that were set", users.updated)
+	}
+	if users.updated["DisplayName"] != "Sara" {
+		t.Errorf("DisplayName = %v, want trimmed value", users.updated["DisplayName"])
+	}
+	if users.updated["Locale"] != "en-US" {
+		t.Errorf("Locale = %v, want canonical tag en-US", users.updated["Locale"])
+	}
+	if value, ok := users.updated["AvatarId"]; !ok || value != nil {
+		t.Errorf("AvatarId = %v, want nil to remove the avatar", value)
+	}
+}
+
+func TestProfileRequiresUser(t *testing.T) {
+	profiles, _ := newProfileUsecase()
+	if _, err := profiles.GetMe(context.Background()); err == nil {
+		t.Fatal("GetMe() without a user in the context succeeded")
+	}
+}
@@ -0,0 +1,107 @@
+package unit
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/minisource/template_go/api/dto"
+	"github.com/minisource/template_go/domain/model"
+	"github.com/minisource/template_go/pkg/projection"
+)
+
+func TestProjectionColumns(t *testing.T) {
+	columns, err := projection.Columns[model.File]([]string{"name", "originalName", "variants", "rank"})
+	if err != nil {
+		t.Fatalf("Columns() error = %v", err)
+	}
+	// Relations and search ranks are not columns, the primary key and version are always read
+	if !slices.Equal(columns, []string{"id", "version", "name", "original_name"}) {
+		t.Fatalf("Columns() = %v", columns)
+	}
+
+	// The avatar can only be loaded with its foreign key
+	columns, _ = projection.Columns[model.User]([]string{"displayName"})
+	if !slices.Equal(columns, []string{"id", "version", "display_name", "avatar_id"}) {
+		t.Fatalf("Columns() of a user = %v", columns)
+	}
+
+	all, _ := projection.Columns[model.File](nil)
+	if slices.Contains(all, "search_rank") || !slices.Contains(all, "tenant_id") {
+		t.Fatalf("Columns() of every field = %v", all)
+	}
+}
+
+func TestProjectionRelations(t *testing.T) {
+	defaults := []string{"Avatar"}
+	expandable := []string{"Avatar.Variants"}
+
+	tests := []struct {
+		name   string
+		fields []string
+		expand []string
+		want   []string
+	}{
+		{"whole rows", nil, nil, []string{"Avatar"}},
+		{"relation not requested", []string{"displayName"}, nil, nil},
+		{"relation requested", []string{"avatar"}, nil, []string{"Avatar"}},
+		{"expanded", []string{"displayName"}, []string{"avatar.variants"}, []string{"Avatar.Variants"}},
+		{"default expanded once", nil, []string{"avatar"}, []string{"Avatar"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := projection.Relations(defaults, expandable, tt.fields, tt.expand)
+			if err != nil || !slices.Equal(got, tt.want) {
+				t.Fatalf("Relations() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+
+	if _, err := projection.Relations(defaults, expandable, nil, []string{"Tenant"}); !errors.Is(err, projection.ErrExpand) {
+		t.Fatalf("Relations() of an unlisted relation error = %v, want ErrExpand", err)
+	}
+}
+
+func TestProjectionTag(t *testing.T) {
+	if tag := projection.Tag(nil, nil); tag != "" {
+		t.Fatalf("Tag() of the whole response = %q", tag)
+	}
+	fields := projection.Tag([]string{"id", "name"}, nil)
+	expanded := projection.Tag(nil, []string{"Variants"})
+	if fields == "" || expanded == "" || fields == expanded {
+		t.Fatalf("Tag() = %q and %q, projections must be told apart", fields, expanded)
+	}
+	// The response follows the order of the fields, not the one of the expansions
+	if projection.Tag([]string{"name", "id"}, nil) == fields {
+		t.Fatal("Tag() ignores the order of the fields")
+	}
+	if projection.Tag(nil, []string{"Avatar", "Variants"}) != projection.Tag(nil, []string{"Variants", "Avatar"}) {
+		t.Fatal("Tag() depends on the order of the expansions")
+	}
+}
+
+func TestProjectionTrim(t *testing.T) {
+	response := dto.FileResponse{Id: 3, Name: "a.png", Size: 10}
+	object, err := projection.Trim(response, []string{"size", "ID", "variants"})
+	if err != nil {
+		t.Fatalf("Trim() error = %v", err)
+	}
+	body, _ := json.Marshal(object)
+	// Requested order, empty fields left out by the response stay out
+	if string(body) != `{"size":10,"id":3}` {
+		t.Fatalf("Trim() = %s", body)
+	}
+}
+
+func TestProjectionCheck(t *testing.T) {
+	if err := projection.Check[dto.FileResponse]([]string{"name", "Highlights"}); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if err := projection.Check[dto.FileResponse]([]string{"name", "path"}); !errors.Is(err, projection.ErrField) {
+		t.Fatalf("Check() of an unknown field error = %v, want ErrField", err)
+	}
+	if got := projection.Parse(" name, ,size "); !slices.Equal(got, []string{"name", "size"}) {
+		t.Fatalf("Parse() = %v", got)
+	}
+}
@@ -0,0 +1,45 @@
+package unit
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/minisource/template_go/api/helper"
+)
+
+func TestParseRange(t *testing.T) {
+	const size = 1000
+	tests := []struct {
+		name          string
+		header        string
+		expected      helper.ByteRange
+		ok            bool
+		unsatisfiable bool
+	}{
+		{"first bytes", "bytes=0-99", helper.ByteRange{Start: 0, End: 99}, true, false},
+		{"open ended", "bytes=900-", helper.ByteRange{Start: 900, End: 999}, true, false},
+		{"suffix", "bytes=-100", helper.ByteRange{Start: 900, End: 999}, true, false},
+		{"suffix larger than size", "bytes=-5000", helper.ByteRange{Start: 0, End: 999}, true, false},
+		{"end past size", "bytes=500-5000", helper.ByteRange{Start: 500, End: 999}, true, false},
+		{"start past size", "bytes=1000-", helper.ByteRange{}, true, true},
+		{"multiple ranges", "bytes=0-10,20-30", helper.ByteRange{}, false, false},
+		{"other unit", "items=0-10", helper.ByteRange{}, false, false},
+		{"malformed", "bytes=abc", helper.ByteRange{}, false, false},
+		{"reversed", "bytes=10-5", helper.ByteRange{}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, ok, err := helper.ParseRange(tt.header, size)
+			if tt.unsatisfiable != errors.Is(err, helper.ErrRangeUnsatisfiable) {
+				t.Fatalf("Expected unsatisfiable=%v, got error %v", tt.unsatisfiable, err)
+			}
+			if ok != tt.ok {
+				t.Fatalf("Expected ok=%v, got %v", tt.ok, ok)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, result)
+			}
+		})
+	}
+}
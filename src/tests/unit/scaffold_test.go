@@ -0,0 +1,191 @@
+package unit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/minisource/template_go/pkg/scaffold"
+)
+
+func orderItem() *scaffold.Resource {
+	return &scaffold.Resource{
+		Name: "OrderItem",
+		Fields: []scaffold.Field{
+			{Name: "Title", Type: "string", Required: true, Search: "a"},
+			{Name: "Quantity", Type: "int", Validate: "gte=1"},
+			{Name: "ShippedAt", Type: "time"},
+			{Name: "ParentId", Type: "int"},
+		},
+		Relations: []scaffold.Relation{
+			{Name: "Photo", Model: "File"},
+			{Name: "Parent", Model: "OrderItem", ForeignKey: "ParentId"},
+			{Name: "Notes", Model: "Note", Kind: scaffold.HasMany},
+		},
+	}
+}
+
+func TestScaffoldCheckFillsDefaults(t *testing.T) {
+	r := orderItem()
+	if err := r.Check(); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if r.Route != "/order-items" || r.Table() != "order_items" || r.Plural() != "OrderItems" {
+		t.Fatalf("route %s table %s plural %s", r.Route, r.Table(), r.Plural())
+	}
+	if r.Fields[0].Size != 255 || r.Fields[0].Search != "A" {
+		t.Fatalf("Title = %+v", r.Fields[0])
+	}
+	// A declared foreign key is a field, only the others are added to the model
+	if !slices.Equal(r.ForeignKeys(), []string{"PhotoId"}) || r.Relations[2].ForeignKey != "OrderItemId" {
+		t.Fatalf("ForeignKeys() = %v, has-many key %s", r.ForeignKeys(), r.Relations[2].ForeignKey)
+	}
+	if r.Fields[2].GoType() != "*time.Time" || r.Fields[1].Gorm() != "not null;default:0" {
+		t.Fatalf("ShippedAt %s, Quantity %s", r.Fields[2].GoType(), r.Fields[1].Gorm())
+	}
+}
+
+func TestScaffoldCheckRejectsDefinitions(t *testing.T) {
+	tests := map[string]func(r *scaffold.Resource){
+		"reserved field":      func(r *scaffold.Resource) { r.Fields[0].Name = "Version" },
+		"unknown type":        func(r *scaffold.Resource) { r.Fields[0].Type = "uuid" },
+		"searched number":     func(r *scaffold.Resource) { r.Fields[1].Search = "B" },
+		"lowercase name":      func(r *scaffold.Resource) { r.Name = "orderItem" },
+		"taken relation name": func(r *scaffold.Resource) { r.Relations[0].Name = "Title" },
+		"unknown kind":        func(r *scaffold.Resource) { r.Relations[0].Kind = "manyToMany" },
+	}
+	for name, change := range tests {
+		r := orderItem()
+		change(r)
+		if err := r.Check(); !errors.Is(err, scaffold.ErrDefinition) {
+			t.Errorf("%s: Check() error = %v", name, err)
+		}
+	}
+}
+
+// newScaffoldRoot is a module with the files the generator inserts into
+func newScaffoldRoot(t *testing.T) string {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/shop\n\ngo 1.24\n",
+		"infra/persistence/migration/migrations.go": "package migration\n\nvar migrations = []Migration{\n\t{Version: 1, Name: \"init\", Up: up1, Down: down1},\n\t{Version: 12, Name: \"add_file_search\", Up: up12, Down: down12},\n}\n",
+		"api/api.go": "package api\n\nfunc RegisterRoutes() {\n\tapp.Get(\"/metrics\", nil)\n}\n",
+		"cmd/mapgen/main.go": "package main\n\nvar mappers = []mapgen.Func{\n\t// cmd/gen adds the mappers of new resources above this line\n}\n",
+	}
+	for path, body := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(root, path)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, path), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestScaffoldPlanRendersEveryLayer(t *testing.T) {
+	r := orderItem()
+	if err := r.Check(); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	root := newScaffoldRoot(t)
+
+	plan, err := scaffold.NewPlan(root, r)
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+	var paths []string
+	for _, file := range plan.Files {
+		paths = append(paths, file.Path)
+		if file.Path == "api/router/order_item.go" && !strings.Contains(string(file.Body), `"example.com/shop/api/handler"`) {
+			t.Errorf("the router does not import the handlers of the module:\n%s", file.Body)
+		}
+	}
+	for _, path := range []string{"domain/model/order_item.go", "api/handler/order_item.go", "tests/unit/order_item_test.go", "infra/persistence/migration/13_AddOrderItem.go"} {
+		if !slices.Contains(paths, path) {
+			t.Errorf("plan lacks %s, has %v", path, paths)
+		}
+	}
+
+	if _, err := scaffold.Write(root, "order_item.yml", plan, false, false); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	routes, _ := os.ReadFile(filepath.Join(root, "api/api.go"))
+	if !strings.Contains(string(routes), "orderItems := v1.Group(\"/order-items\", apimiddleware.Authentication(cfg))\n\trouter.OrderItem(orderItems, cfg)\n\n\tapp.Get") {
+		t.Fatalf("api.go = %s", routes)
+	}
+	mappers, _ := os.ReadFile(filepath.Join(root, "cmd/mapgen/main.go"))
+	if !strings.Contains(string(mappers), "\tmapgen.Map[model.OrderItem, dto.OrderItem](\"toOrderItemDto\"),\n\n\t// cmd/gen adds") {
+		t.Fatalf("cmd/mapgen/main.go = %s", mappers)
+	}
+
+	// Once registered, the migration is not generated again
+	plan, err = scaffold.NewPlan(root, r)
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+	for _, file := range plan.Files {
+		if strings.Contains(file.Path, "migration") {
+			t.Fatalf("regenerated %s", file.Path)
+		}
+	}
+}
+
+func TestScaffoldWriteKeepsEditedFiles(t *testing.T) {
+	r := orderItem()
+	if err := r.Check(); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	root := newScaffoldRoot(t)
+	plan, err := scaffold.NewPlan(root, r)
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+	if _, err := scaffold.Write(root, "order_item.yml", plan, false, false); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	edited := filepath.Join(root, "api/handler/order_item.go")
+	body, _ := os.ReadFile(edited)
+	body = append(body, "\n// A hand written endpoint\n"...)
+	if err := os.WriteFile(edited, body, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r.Fields[1].Validate = "gte=0"
+
+	actions, err := scaffold.Write(root, "order_item.yml", plan, false, false)
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	want := map[string]string{
+		"api/handler/order_item.go":                 scaffold.Kept,
+		"domain/model/order_item.go":                scaffold.Unchanged,
+		"infra/persistence/migration/migrations.go": scaffold.Unchanged,
+	}
+	for _, action := range actions {
+		if expected, ok := want[action.Path]; ok && action.Action != expected {
+			t.Errorf("%s %s, want %s", action.Path, action.Action, expected)
+		}
+	}
+	if current, _ := os.ReadFile(edited); string(current) != string(body) {
+		t.Fatal("the edited handler was overwritten")
+	}
+
+	// A changed definition updates the files still as generated, force the edited ones
+	plan, err = scaffold.NewPlan(root, r)
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+	actions, err = scaffold.Write(root, "order_item.yml", plan, true, false)
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	for _, action := range actions {
+		if action.Path == "api/dto/order_item.go" && action.Action != scaffold.Updated || action.Path == "api/handler/order_item.go" && action.Action != scaffold.Updated {
+			t.Errorf("%s %s, want updated", action.Path, action.Action)
+		}
+	}
+}
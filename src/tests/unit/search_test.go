@@ -0,0 +1,88 @@
+package unit
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/minisource/template_go/api/dto"
+	"github.com/minisource/template_go/domain/model"
+	"github.com/minisource/template_go/pkg/keyset"
+	"github.com/minisource/template_go/pkg/search"
+	usecaseDto "github.com/minisource/template_go/usecase/dto"
+	"github.com/minisource/go-common/filter"
+)
+
+func TestSearchFieldsOfFile(t *testing.T) {
+	fields := search.Fields[model.File]()
+	want := []search.Field{{Column: "name", Weight: "A"}, {Column: "original_name", Weight: "A"}, {Column: "description", Weight: "B"}}
+	if len(fields) != len(want) {
+		t.Fatalf("Fields() = %v, want %v", fields, want)
+	}
+	for i := range want {
+		if fields[i] != want[i] {
+			t.Errorf("Fields()[%d] = %v, want %v", i, fields[i], want[i])
+		}
+	}
+	if fields := search.Fields[model.AuditLog](); fields != nil {
+		t.Errorf("Fields() of an unsearchable model = %v", fields)
+	}
+}
+
+func TestSearchVector(t *testing.T) {
+	vector := search.Vector([]search.Field{{Column: "name", Weight: "A"}, {Column: "description", Weight: "B"}})
+	want := "setweight(to_tsvector('simple', coalesce(name, '')), 'A') || setweight(to_tsvector('simple', coalesce(description, '')), 'B')"
+	if vector != want {
+		t.Fatalf("Vector() = %s", vector)
+	}
+}
+
+func TestSearchSelectBindsTheQuery(t *testing.T) {
+	selected, args := search.Select(search.Fields[model.File](), "report -draft")
+	if placeholders := strings.Count(selected, "?"); placeholders != len(args) || len(args) != 4 {
+		t.Fatalf("Select() has %d placeholders for %d args", placeholders, len(args))
+	}
+	for _, arg := range args {
+		if arg != "report -draft" {
+			t.Fatalf("Select() args = %v", args)
+		}
+	}
+	if strings.Contains(selected, "report") {
+		t.Fatal("Select() inlined the query")
+	}
+}
+
+func TestSearchSelectEscapesSnippets(t *testing.T) {
+	selected, _ := search.Select([]search.Field{{Column: "description", Weight: "B"}}, "script")
+	escaped := `replace(replace(replace(replace(description, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;')`
+	if !strings.Contains(selected, "ts_headline('simple', "+escaped+", ") {
+		t.Fatalf("Select() headlines the raw column: %s", selected)
+	}
+}
+
+func TestHighlightsScan(t *testing.T) {
+	var highlights model.Highlights
+	if err := highlights.Scan([]byte(`{"name":"<mark>report</mark>.pdf"}`)); err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if highlights["name"] != "<mark>report</mark>.pdf" {
+		t.Fatalf("Scan() = %v", highlights)
+	}
+	if err := highlights.Scan(nil); err != nil || highlights != nil {
+		t.Fatalf("Scan(nil) = %v, %v", highlights, err)
+	}
+}
+
+func TestFileResponseCarriesSearchHit(t *testing.T) {
+	file := usecaseDto.File{SearchHit: usecaseDto.SearchHit{SearchRank: 0.5, SearchHighlights: map[string]string{"name": "<mark>a</mark>"}}}
+	response := dto.ToFileResponse(file)
+	if response.Rank != 0.5 || response.Highlights["name"] != "<mark>a</mark>" {
+		t.Fatalf("ToFileResponse() = %+v", response)
+	}
+}
+
+func TestKeysetSortRejectsSearchRank(t *testing.T) {
+	if _, err := keyset.ParseSort[model.File](&[]filter.Sort{{ColId: "searchRank", Sort: "desc"}}); !errors.Is(err, keyset.ErrInvalidSort) {
+		t.Fatalf("ParseSort() error = %v, the rank is not a column", err)
+	}
+}
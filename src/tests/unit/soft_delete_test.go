@@ -0,0 +1,140 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/constant"
+	"github.com/minisource/template_go/domain/model"
+	"github.com/minisource/template_go/domain/principal"
+	"github.com/minisource/template_go/domain/repository"
+	"github.com/minisource/template_go/domain/storage"
+	"github.com/minisource/template_go/usecase"
+	"github.com/minisource/go-common/logging"
+)
+
+// trashFileRepository keeps files in memory and records deletes and purges
+type trashFileRepository struct {
+	repository.FileRepository
+	files   map[int]model.File
+	deleted []int
+	purged  [][]model.File // Batches returned by Purge, in order
+	before  time.Time
+}
+
+func (r *trashFileRepository) GetById(_ context.Context, id int) (model.File, error) {
+	return r.files[id], nil
+}
+
+func (r *trashFileRepository) Delete(_ context.Context, id int) error {
+	r.deleted = append(r.deleted, id)
+	return nil
+}
+
+func (r *trashFileRepository) Purge(_ context.Context, before time.Time, limit int) ([]model.File, error) {
+	r.before = before
+	if len(r.purged) == 0 {
+		return nil, nil
+	}
+	batch := r.purged[0]
+	r.purged = r.purged[1:]
+	return batch, nil
+}
+
+type recordingStorage struct {
+	storage.Storage
+	removed []string
+}
+
+func (s *recordingStorage) Delete(_ context.Context, key string) error {
+	s.removed = append(s.removed, key)
+	return nil
+}
+
+func newTrashFileUsecase(softDelete config.SoftDeleteConfig) (*usecase.FileUsecase, *trashFileRepository, *recordingStorage) {
+	files := &trashFileRepository{files: map[int]model.File{
+		1: {
+			BaseModel: model.BaseModel{Id: 1, CreatedBy: 7},
+			Directory: "uploads", Name: "a.png",
+			Variants: []model.File{{BaseModel: model.BaseModel{Id: 2}}, {BaseModel: model.BaseModel{Id: 3}}},
+		},
+	}}
+	blobs := &recordingStorage{}
+	cfg := &config.Config{
+		Logger:     logging.LoggerConfig{FilePath: os.TempDir() + "/", Encoding: "json", Level: "error", Logger: "zap"},
+		SoftDelete: softDelete,
+	}
+	return usecase.NewFileUsecase(cfg, files, &fakeUnitOfWork{}, blobs), files, blobs
+}
+
+func TestFileDeleteKeepsBlobs(t *testing.T) {
+	files, repo, blobs := newTrashFileUsecase(config.SoftDeleteConfig{})
+
+	if err := files.Delete(userContext(7), 1); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	// Variants first, a purge must never find a live variant of a purged original
+	if !slices.Equal(repo.deleted, []int{2, 3, 1}) {
+		t.Fatalf("deleted %v, want variants before the original", repo.deleted)
+	}
+	if len(blobs.removed) != 0 {
+		t.Fatalf("removed blobs %v, a deleted file must stay restorable", blobs.removed)
+	}
+}
+
+func TestPurgeDeletedRemovesBlobs(t *testing.T) {
+	files, repo, blobs := newTrashFileUsecase(config.SoftDeleteConfig{RetentionDays: 7, BatchSize: 2})
+	repo.purged = [][]model.File{
+		{{Directory: "uploads", Name: "a"}, {Directory: "uploads", Name: "b"}},
+		{{Directory: "uploads", Name: "c"}},
+		{{Directory: "uploads", Name: "never"}},
+	}
+
+	ctx := principal.WithPrincipal(context.Background(), principal.System("test"))
+	if err := files.PurgeDeleted(ctx); err != nil {
+		t.Fatalf("PurgeDeleted() error = %v", err)
+	}
+	// A short batch means nothing is left
+	if !slices.Equal(blobs.removed, []string{"uploads/a", "uploads/b", "uploads/c"}) {
+		t.Fatalf("removed %v", blobs.removed)
+	}
+	if age := time.Since(repo.before); age < 7*24*time.Hour || age > 7*24*time.Hour+time.Minute {
+		t.Fatalf("purged rows deleted before %v, want 7 days ago", repo.before)
+	}
+}
+
+func TestIncludeDeletedIsForAdmins(t *testing.T) {
+	if _, err := usecase.IncludeDeleted(context.Background()); err == nil {
+		t.Fatal("IncludeDeleted() without a caller succeeded")
+	}
+	if _, err := usecase.IncludeDeleted(userContext(7)); err == nil {
+		t.Fatal("IncludeDeleted() for a user succeeded")
+	}
+
+	admin := principal.WithPrincipal(context.Background(), principal.Principal{UserId: 1, Roles: []string{constant.AdminRoleName}})
+	ctx, err := usecase.IncludeDeleted(admin)
+	if err != nil {
+		t.Fatalf("IncludeDeleted() error = %v", err)
+	}
+	if !repository.IncludesDeleted(ctx) || repository.IncludesDeleted(admin) {
+		t.Fatal("only the returned context may include deleted rows")
+	}
+}
+
+func TestFileRestoreIsForAdmins(t *testing.T) {
+	files, _, _ := newTrashFileUsecase(config.SoftDeleteConfig{})
+	if _, err := files.Restore(userContext(7), 1); err == nil {
+		t.Fatal("Restore() by the owner succeeded, restoring is for administrators")
+	}
+}
+
+func TestSoftDeleteDefaults(t *testing.T) {
+	var cfg config.SoftDeleteConfig
+	if cfg.Retention() != 30*24*time.Hour || cfg.Interval() != time.Hour || cfg.Batch() != 100 {
+		t.Fatalf("defaults = %v, %v, %d", cfg.Retention(), cfg.Interval(), cfg.Batch())
+	}
+}
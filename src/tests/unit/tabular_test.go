@@ -0,0 +1,128 @@
+package unit
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minisource/template_go/pkg/tabular"
+)
+
+type tabularRow struct {
+	Id      int       `json:"id"`
+	Name    string    `json:"name"`
+	Size    *int64    `json:"size"`
+	Public  bool      `json:"public"`
+	At      time.Time `json:"at"`
+	Private string    `json:"-"`
+}
+
+func TestTabularRoundTrip(t *testing.T) {
+	size := int64(2048)
+	at := time.Date(2026, 5, 6, 7, 8, 9, 0, time.UTC)
+	rows := []tabularRow{
+		{Id: 1, Name: "report, final.pdf", Size: &size, Public: true, At: at},
+		{Id: 2, Name: "notes.txt", At: at},
+	}
+
+	for _, format := range []tabular.Format{tabular.CSV, tabular.JSONLines, tabular.XLSX} {
+		t.Run(string(format), func(t *testing.T) {
+			columns := tabular.Columns[tabularRow]()
+			var buffer bytes.Buffer
+			writer, err := tabular.NewWriter(format, &buffer, columns)
+			if err != nil {
+				t.Fatalf("NewWriter() error = %v", err)
+			}
+			for _, row := range rows {
+				if err := writer.Write(tabular.Values(row, columns)); err != nil {
+					t.Fatalf("Write() error = %v", err)
+				}
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			reader, err := tabular.NewReader(format, &buffer)
+			if err != nil {
+				t.Fatalf("NewReader() error = %v", err)
+			}
+			defer reader.Close()
+			var got []tabularRow
+			for {
+				record, err := reader.Next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Next() error = %v", err)
+				}
+				row, err := tabular.Decode[tabularRow](record)
+				if err != nil {
+					t.Fatalf("Decode() error = %v", err)
+				}
+				got = append(got, row)
+			}
+
+			if len(got) != 2 || got[0].Name != rows[0].Name || got[0].Size == nil || *got[0].Size != size ||
+				!got[0].Public || !got[0].At.Equal(at) || got[1].Id != 2 || got[1].Size != nil {
+				t.Fatalf("read %+v", got)
+			}
+		})
+	}
+}
+
+func TestTabularDecode(t *testing.T) {
+	reader, err := tabular.NewReader(tabular.CSV, strings.NewReader("\ufeffID,name,size,unknown\n 7 ,a.txt,12,x\n\n,,,\nx,b.txt,,\n"))
+	if err != nil {
+		t.Fatalf("NewReader() error = %v", err)
+	}
+
+	// Headers match case-insensitively and columns the row does not have are ignored
+	record, _ := reader.Next()
+	row, err := tabular.Decode[tabularRow](record)
+	if err != nil || row.Id != 7 || row.Name != "a.txt" || *row.Size != 12 {
+		t.Fatalf("Decode() = %+v, %v", row, err)
+	}
+
+	// Blank rows are skipped
+	record, _ = reader.Next()
+	if _, err := tabular.Decode[tabularRow](record); err == nil || !strings.Contains(err.Error(), "ID") {
+		t.Fatalf("Decode() of a text id error = %v", err)
+	}
+	if _, err := reader.Next(); !errors.Is(err, io.EOF) {
+		t.Fatalf("Next() error = %v, want io.EOF", err)
+	}
+
+	if _, err := tabular.NewReader(tabular.CSV, strings.NewReader("")); !errors.Is(err, tabular.ErrNoHeader) {
+		t.Fatalf("NewReader() of an empty file error = %v, want ErrNoHeader", err)
+	}
+}
+
+func TestTabularSelect(t *testing.T) {
+	columns, err := tabular.Select[tabularRow]([]string{"name", "ID"})
+	if err != nil || !slices.Equal(columns, []string{"name", "ID"}) {
+		t.Fatalf("Select() = %v, %v", columns, err)
+	}
+	if _, err := tabular.Select[tabularRow]([]string{"Private"}); !errors.Is(err, tabular.ErrColumn) {
+		t.Fatalf("Select() of a hidden field error = %v, want ErrColumn", err)
+	}
+	if columns, _ := tabular.Select[tabularRow](nil); !slices.Equal(columns, []string{"id", "name", "size", "public", "at"}) {
+		t.Fatalf("Select() of all columns = %v", columns)
+	}
+}
+
+func TestTabularFormat(t *testing.T) {
+	if format, err := tabular.FormatOf("export.XLSX"); err != nil || format != tabular.XLSX {
+		t.Fatalf("FormatOf() = %v, %v", format, err)
+	}
+	if _, err := tabular.FormatOf("export.xls"); !errors.Is(err, tabular.ErrFormat) {
+		t.Fatalf("FormatOf(xls) error = %v, want ErrFormat", err)
+	}
+	if _, err := tabular.ParseFormat("json"); !errors.Is(err, tabular.ErrFormat) {
+		t.Fatalf("ParseFormat(json) error = %v, want ErrFormat", err)
+	}
+}
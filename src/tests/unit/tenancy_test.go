@@ -0,0 +1,54 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/minisource/template_go/domain/model"
+	"github.com/minisource/template_go/domain/principal"
+	"gorm.io/gorm"
+)
+
+func createStatement(ctx context.Context, dest interface{}) *gorm.DB {
+	return &gorm.DB{Statement: &gorm.Statement{Context: ctx, Dest: dest}}
+}
+
+func TestBaseModelStampsTenant(t *testing.T) {
+	ctx := principal.WithPrincipal(context.Background(), principal.Principal{UserId: 4, TenantId: "acme"})
+
+	file := &model.File{}
+	if err := file.BeforeCreate(createStatement(ctx, file)); err != nil {
+		t.Fatalf("BeforeCreate() error = %v", err)
+	}
+	if file.TenantId != "acme" || file.CreatedBy != 4 {
+		t.Fatalf("file tenant %q created by %d, want acme and 4", file.TenantId, file.CreatedBy)
+	}
+
+	// A tenant chosen by the caller is kept, e.g. a job writing into the tenant of the row it read
+	other := &model.File{BaseModel: model.BaseModel{TenantId: "globex"}}
+	other.BeforeCreate(createStatement(ctx, other))
+	if other.TenantId != "globex" {
+		t.Fatalf("preset tenant replaced by %q", other.TenantId)
+	}
+}
+
+func TestGlobalModelHasNoTenant(t *testing.T) {
+	ctx := principal.WithPrincipal(context.Background(), principal.Principal{UserId: 4, TenantId: "acme"})
+
+	user := &model.User{}
+	user.BeforeCreate(createStatement(ctx, user))
+	if user.TenantId != "" {
+		t.Fatalf("global model got tenant %q", user.TenantId)
+	}
+	if _, ok := interface{}(user).(model.Global); !ok {
+		t.Fatal("model.User must opt out of tenancy")
+	}
+}
+
+func TestBaseModelWithoutPrincipal(t *testing.T) {
+	file := &model.File{}
+	file.BeforeCreate(createStatement(context.Background(), file))
+	if file.TenantId != "" || file.CreatedBy != principal.SystemUserId {
+		t.Fatalf("file tenant %q created by %d, want no tenant and the system user", file.TenantId, file.CreatedBy)
+	}
+}
@@ -0,0 +1,132 @@
+package unit
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/minisource/template_go/pkg/token"
+)
+
+func signToken(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	if kid != "" {
+		tok.Header["kid"] = kid
+	}
+	raw, err := tok.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func accessClaims(expiresIn time.Duration) jwt.MapClaims {
+	return jwt.MapClaims{
+		"id":    "user-1",
+		"name":  "alice",
+		"roles": []map[string]string{{"name": "editor"}},
+		"exp":   time.Now().Add(expiresIn).Unix(),
+	}
+}
+
+func TestStaticKeyVerifier(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicPem := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	static, err := token.NewStaticKey(publicPem, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier := token.NewVerifier(static, token.Options{})
+
+	claims, err := verifier.Verify(context.Background(), signToken(t, key, "", accessClaims(time.Hour)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Identity() != "user-1" || !claims.HasRole("editor") {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+
+	if _, err := verifier.Verify(context.Background(), signToken(t, key, "", accessClaims(-time.Hour))); !errors.Is(err, token.ErrExpiredToken) {
+		t.Fatalf("expected ErrExpiredToken, got %v", err)
+	}
+
+	refresh := accessClaims(time.Hour)
+	refresh["TokenType"] = "refresh-token"
+	if _, err := verifier.Verify(context.Background(), signToken(t, key, "", refresh)); !errors.Is(err, token.ErrInvalidToken) {
+		t.Fatalf("refresh token must be rejected, got %v", err)
+	}
+
+	// The public key must not be accepted as an HMAC secret
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims(time.Hour)).SignedString([]byte(publicPem))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifier.Verify(context.Background(), forged); !errors.Is(err, token.ErrInvalidToken) {
+		t.Fatalf("HS256 token must be rejected, got %v", err)
+	}
+}
+
+func TestJWKSVerifier(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "key-1",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+	defer server.Close()
+
+	verifier := token.NewVerifier(token.NewJWKS(server.URL, time.Hour), token.Options{})
+	for i := 0; i < 2; i++ {
+		if _, err := verifier.Verify(context.Background(), signToken(t, key, "key-1", accessClaims(time.Hour))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if requests != 1 {
+		t.Fatalf("keys downloaded %d times, want 1", requests)
+	}
+
+	if _, err := verifier.Verify(context.Background(), signToken(t, key, "unknown", accessClaims(time.Hour))); !errors.Is(err, token.ErrInvalidToken) {
+		t.Fatalf("expected ErrInvalidToken for unknown key id, got %v", err)
+	}
+}
+
+func TestRolesDecoding(t *testing.T) {
+	var claims token.Claims
+	if err := json.Unmarshal([]byte(`{"sub":"abc","roles":["admin","viewer"]}`), &claims); err != nil {
+		t.Fatal(err)
+	}
+	if claims.Identity() != "abc" || !claims.HasRole("viewer") {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+}
@@ -0,0 +1,107 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/domain/model"
+	"github.com/minisource/template_go/domain/repository"
+	"github.com/minisource/template_go/usecase"
+	"github.com/minisource/go-common/logging"
+)
+
+type txMarker struct{}
+
+// fakeUnitOfWork marks the context it passes on, so the repository can tell calls made in it
+type fakeUnitOfWork struct {
+	runs int
+}
+
+func (u *fakeUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	u.runs++
+	return fn(context.WithValue(ctx, txMarker{}, true))
+}
+
+// registeringUserRepository records its calls, calls made in a unit of work end with "+tx"
+type registeringUserRepository struct {
+	repository.UserRepository
+	exists []bool // Answers of ExistsUserId, in order
+	calls  []string
+}
+
+func (r *registeringUserRepository) call(ctx context.Context, name string) {
+	if inTx, _ := ctx.Value(txMarker{}).(bool); inTx {
+		name += "+tx"
+	}
+	r.calls = append(r.calls, name)
+}
+
+func (r *registeringUserRepository) ExistsUserId(ctx context.Context, userId string) (bool, error) {
+	r.call(ctx, "exists")
+	exists := r.exists[0]
+	r.exists = r.exists[1:]
+	return exists, nil
+}
+
+func (r *registeringUserRepository) LockUserId(ctx context.Context, userId string) error {
+	r.call(ctx, "lock")
+	return nil
+}
+
+func (r *registeringUserRepository) GetByUserId(ctx context.Context, userId string) (model.User, error) {
+	r.call(ctx, "get")
+	return model.User{BaseModel: model.BaseModel{Id: 1}, UserId: userId}, nil
+}
+
+func (r *registeringUserRepository) CreateUser(ctx context.Context, u model.User) (model.User, error) {
+	r.call(ctx, "create")
+	u.Id = 2
+	return u, nil
+}
+
+func newRegisteringUsecase(exists ...bool) (*usecase.UserUsecase, *registeringUserRepository, *fakeUnitOfWork) {
+	users := &registeringUserRepository{exists: exists}
+	unitOfWork := &fakeUnitOfWork{}
+	cfg := &config.Config{Logger: logging.LoggerConfig{FilePath: os.TempDir() + "/", Encoding: "json", Level: "error", Logger: "zap"}}
+	return usecase.NewUserUsecase(cfg, users, unitOfWork, nil), users, unitOfWork
+}
+
+func TestEnsureUserKnownUserSkipsTransaction(t *testing.T) {
+	users, repo, unitOfWork := newRegisteringUsecase(true)
+
+	user, err := users.EnsureUser(context.Background(), "abc")
+	if err != nil || user.Id != 1 {
+		t.Fatalf("EnsureUser() = %v, %v", user, err)
+	}
+	if unitOfWork.runs != 0 || !slices.Equal(repo.calls, []string{"exists", "get"}) {
+		t.Fatalf("calls %v in %d units of work, want a plain lookup", repo.calls, unitOfWork.runs)
+	}
+}
+
+func TestEnsureUserRegistersUnderLock(t *testing.T) {
+	users, repo, _ := newRegisteringUsecase(false, false)
+
+	user, err := users.EnsureUser(context.Background(), "abc")
+	if err != nil || user.Id != 2 {
+		t.Fatalf("EnsureUser() = %v, %v", user, err)
+	}
+	if !slices.Equal(repo.calls, []string{"exists", "lock+tx", "exists+tx", "create+tx"}) {
+		t.Fatalf("calls %v, want the check repeated under the lock", repo.calls)
+	}
+}
+
+func TestEnsureUserConcurrentRegistration(t *testing.T) {
+	// Another login registered the user while this one waited for the lock
+	users, repo, _ := newRegisteringUsecase(false, true)
+
+	user, err := users.EnsureUser(context.Background(), "abc")
+	if err != nil || user.Id != 1 {
+		t.Fatalf("EnsureUser() = %v, %v", user, err)
+	}
+	if slices.Contains(repo.calls, "create+tx") {
+		t.Fatalf("calls %v, the user was created twice", repo.calls)
+	}
+}
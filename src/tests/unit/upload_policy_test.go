@@ -0,0 +1,143 @@
+package unit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/pkg/upload"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x02\x00\x00\x00")
+
+func TestUploadPolicyInspect(t *testing.T) {
+	text := []byte("plain text content")
+	sum := sha256.Sum256(text)
+	checksum := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name     string
+		policy   upload.Policy
+		content  []byte
+		filename string
+		checksum string
+		expected error
+	}{
+		{"allowed by wildcard", upload.Policy{AllowedTypes: []string{"image/*"}}, pngHeader, "a.png", "", nil},
+		{"type sniffed from bytes", upload.Policy{AllowedTypes: []string{"image/*"}}, text, "fake.png", "", upload.ErrTypeNotAllowed},
+		{"denied type", upload.Policy{DeniedTypes: []string{"text/plain"}}, text, "a.txt", "", upload.ErrTypeNotAllowed},
+		{"extension not allowed", upload.Policy{AllowedExtensions: []string{".png"}}, text, "a.txt", "", upload.ErrExtensionNotAllowed},
+		{"too large", upload.Policy{MaxSize: 4}, text, "a.txt", "", upload.ErrTooLarge},
+		{"empty", upload.Policy{}, []byte{}, "a.txt", "", upload.ErrEmpty},
+		{"checksum matches", upload.Policy{}, text, "a.txt", checksum, nil},
+		{"checksum mismatch", upload.Policy{}, text, "a.txt", "deadbeef", upload.ErrChecksumMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := bytes.NewReader(tt.content)
+			inspection, err := tt.policy.Inspect(content, tt.filename, tt.checksum)
+			if !errors.Is(err, tt.expected) {
+				t.Fatalf("Expected error %v, got %v", tt.expected, err)
+			}
+			if err != nil {
+				return
+			}
+			if inspection.Size != int64(len(tt.content)) {
+				t.Errorf("Expected size %d, got %d", len(tt.content), inspection.Size)
+			}
+			// The content must be rewound for storing
+			rest, _ := io.ReadAll(content)
+			if !bytes.Equal(rest, tt.content) {
+				t.Errorf("Content was not rewound after inspection")
+			}
+		})
+	}
+}
+
+func TestUploadPolicyDetectsExtension(t *testing.T) {
+	inspection, err := upload.Policy{}.Inspect(bytes.NewReader(pngHeader), "photo.jpeg", "")
+	if err != nil {
+		t.Fatalf("Inspect failed: %v", err)
+	}
+	if inspection.MimeType != "image/png" || inspection.Extension != ".png" {
+		t.Errorf("Expected image/png with .png extension, got %s %s", inspection.MimeType, inspection.Extension)
+	}
+}
+
+func TestUploadRoutePolicyKeepsDefaultDenials(t *testing.T) {
+	uploads := config.UploadConfig{
+		Default: upload.Policy{MaxSize: 100, DeniedTypes: []string{"text/plain"}, AllowedExtensions: []string{".txt", ".png"}},
+		Routes: map[string]upload.Policy{
+			"files":  {AllowedTypes: []string{"text/*", "image/*"}},
+			"images": {MaxSize: 1000, DeniedTypes: []string{"image/gif"}, AllowedExtensions: []string{".png"}},
+		},
+	}
+
+	// The route denies nothing itself, the default denials still apply
+	files := uploads.Policy("files")
+	if _, err := files.Inspect(bytes.NewReader([]byte("plain text content")), "a.txt", ""); !errors.Is(err, upload.ErrTypeNotAllowed) {
+		t.Fatalf("Expected %v for a type denied by default, got %v", upload.ErrTypeNotAllowed, err)
+	}
+	if files.MaxSize != 100 || len(files.AllowedExtensions) != 2 {
+		t.Errorf("Expected the default size and extensions, got %+v", files)
+	}
+
+	images := uploads.Policy("images")
+	if images.MaxSize != 1000 || len(images.AllowedExtensions) != 1 || len(images.DeniedTypes) != 2 {
+		t.Errorf("Expected the route settings plus the default denials, got %+v", images)
+	}
+	if len(uploads.Default.DeniedTypes) != 1 {
+		t.Errorf("Merging changed the default policy: %+v", uploads.Default)
+	}
+}
+
+func TestUploadResumablePolicyOnlyChangesTheSize(t *testing.T) {
+	uploads := config.UploadConfig{
+		Default:   upload.Policy{MaxSize: 100, DeniedTypes: []string{"application/x-sh"}},
+		Routes:    map[string]upload.Policy{"files": {AllowedTypes: []string{"image/*"}}},
+		Resumable: config.ResumableUploadConfig{MaxSize: 1000},
+	}
+	policy := uploads.ResumablePolicy("files")
+	if policy.MaxSize != 1000 || len(policy.AllowedTypes) != 1 || len(policy.DeniedTypes) != 1 {
+		t.Fatalf("Expected the files policy with the resumable size, got %+v", policy)
+	}
+	uploads.Resumable.MaxSize = 0
+	if policy := uploads.ResumablePolicy("files"); policy.MaxSize != 100 {
+		t.Errorf("Expected the route size without a resumable one, got %d", policy.MaxSize)
+	}
+}
+
+func TestUploadFileNameFitsTheColumn(t *testing.T) {
+	tests := []struct {
+		name      string
+		fileName  string
+		extension string
+	}{
+		{"ascii", "dir/" + strings.Repeat("a", 300) + ".pdf", ".pdf"},
+		{"multibyte", strings.Repeat("é", 300) + ".txt", ".txt"},
+		{"long extension", "a." + strings.Repeat("x", 300), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := upload.FileName(tt.fileName)
+			if count := utf8.RuneCountInString(name); count != upload.MaxFileNameLength || !utf8.ValidString(name) {
+				t.Fatalf("Expected %d valid characters, got %d", upload.MaxFileNameLength, count)
+			}
+			if !strings.HasSuffix(name, tt.extension) || strings.Contains(name, "/") {
+				t.Errorf("Expected the base name ending with %q, got %q", tt.extension, name)
+			}
+		})
+	}
+
+	if name := upload.FileName("dir/report.pdf"); name != "report.pdf" {
+		t.Errorf("Expected a short name unchanged, got %q", name)
+	}
+}
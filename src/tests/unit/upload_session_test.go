@@ -0,0 +1,199 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/domain/model"
+	"github.com/minisource/template_go/domain/repository"
+	"github.com/minisource/template_go/domain/storage"
+	"github.com/minisource/template_go/pkg/upload"
+	"github.com/minisource/template_go/usecase"
+	usecaseDto "github.com/minisource/template_go/usecase/dto"
+	"github.com/minisource/go-common/logging"
+)
+
+func TestUploadSessionParts(t *testing.T) {
+	tests := []struct {
+		name      string
+		totalSize int64
+		chunkSize int64
+		parts     int
+		lastSize  int64
+	}{
+		{"single short part", 10, 100, 1, 10},
+		{"exact multiple", 300, 100, 3, 100},
+		{"short last part", 250, 100, 3, 50},
+		{"one byte over", 101, 100, 2, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := model.UploadSession{TotalSize: tt.totalSize, ChunkSize: tt.chunkSize}
+			if got := session.TotalParts(); got != tt.parts {
+				t.Fatalf("TotalParts() = %d, want %d", got, tt.parts)
+			}
+			if got := session.PartSize(tt.parts); got != tt.lastSize {
+				t.Fatalf("PartSize(last) = %d, want %d", got, tt.lastSize)
+			}
+			if tt.parts > 1 {
+				if got := session.PartSize(1); got != tt.chunkSize {
+					t.Fatalf("PartSize(1) = %d, want %d", got, tt.chunkSize)
+				}
+			}
+		})
+	}
+}
+
+// staleSessionRepository holds one session and returns it as loaded before any request
+// changed it, like two completions that read the session at the same time
+type staleSessionRepository struct {
+	repository.UploadSessionRepository
+	loaded model.UploadSession
+	status string
+}
+
+func (r *staleSessionRepository) GetByKey(_ context.Context, key string) (model.UploadSession, error) {
+	return r.loaded, nil
+}
+
+func (r *staleSessionRepository) ChangeStatus(_ context.Context, id int, from string, to string, expiresAt time.Time) (bool, error) {
+	if r.status != from {
+		return false, nil
+	}
+	r.status = to
+	return true, nil
+}
+
+func (r *staleSessionRepository) Close(_ context.Context, id int, from string, status string, fileId *int) (bool, error) {
+	if r.status != from {
+		return false, nil
+	}
+	r.status = status
+	return true, nil
+}
+
+// memoryStorage keeps blobs in memory
+type memoryStorage struct {
+	storage.Storage
+	blobs map[string][]byte
+}
+
+func (s *memoryStorage) Put(_ context.Context, key string, content io.Reader, size int64, contentType string) error {
+	blob, err := io.ReadAll(content)
+	s.blobs[key] = blob
+	return err
+}
+
+func (s *memoryStorage) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(s.blobs[key])), nil
+}
+
+func (s *memoryStorage) Delete(_ context.Context, key string) error {
+	delete(s.blobs, key)
+	return nil
+}
+
+// createdFileRepository registers files without finding duplicates, neither completion sees the other's file
+type createdFileRepository struct {
+	repository.FileRepository
+	created []model.File
+}
+
+func (r *createdFileRepository) GetByChecksum(_ context.Context, checksum string, createdBy int) (*model.File, error) {
+	return nil, nil
+}
+
+func (r *createdFileRepository) Create(_ context.Context, file model.File) (model.File, error) {
+	file.Id = len(r.created) + 1
+	r.created = append(r.created, file)
+	return file, nil
+}
+
+func newUploadSessionUsecase(checksum string, uploads config.UploadConfig) (*usecase.UploadSessionUsecase, *staleSessionRepository, *createdFileRepository) {
+	sessions := &staleSessionRepository{status: model.UploadSessionPending, loaded: model.UploadSession{
+		BaseModel: model.BaseModel{Id: 1, CreatedBy: 7},
+		Key:       "session", FileName: "notes.txt", TotalSize: 18, ChunkSize: 18, Checksum: checksum,
+		Status: model.UploadSessionPending, ExpiresAt: time.Now().Add(time.Hour),
+		Parts:  []model.UploadPart{{UploadSessionId: 1, Number: 1, Size: 18}},
+	}}
+	blobs := &memoryStorage{blobs: map[string][]byte{"uploads/parts/session/00001": []byte("plain text content")}}
+	files := &createdFileRepository{}
+	cfg := &config.Config{
+		Logger: logging.LoggerConfig{FilePath: os.TempDir() + "/", Encoding: "json", Level: "error", Logger: "zap"},
+		Upload: uploads,
+	}
+	fileUsecase := usecase.NewFileUsecase(cfg, files, &fakeUnitOfWork{}, blobs)
+	return usecase.NewUploadSessionUsecase(cfg, sessions, fileUsecase, blobs), sessions, files
+}
+
+func TestUploadSessionCompletesOnce(t *testing.T) {
+	uploads, sessions, files := newUploadSessionUsecase("", config.UploadConfig{})
+
+	if _, err := uploads.Complete(userContext(7), "session"); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	// The second completion read the session while it was still pending
+	if _, err := uploads.Complete(userContext(7), "session"); !errors.Is(err, usecase.ErrUploadSessionClosed) {
+		t.Fatalf("second Complete() error = %v, want %v", err, usecase.ErrUploadSessionClosed)
+	}
+	if len(files.created) != 1 || sessions.status != model.UploadSessionCompleted {
+		t.Fatalf("registered %d files, session %s", len(files.created), sessions.status)
+	}
+}
+
+func TestUploadSessionCompleteReleasesClaim(t *testing.T) {
+	uploads, sessions, files := newUploadSessionUsecase("deadbeef", config.UploadConfig{})
+
+	if _, err := uploads.Complete(userContext(7), "session"); !errors.Is(err, upload.ErrChecksumMismatch) {
+		t.Fatalf("Complete() error = %v, want %v", err, upload.ErrChecksumMismatch)
+	}
+	// A failed completion can be retried once the part is sent again
+	if len(files.created) != 0 || sessions.status != model.UploadSessionPending {
+		t.Fatalf("registered %d files, session %s", len(files.created), sessions.status)
+	}
+}
+
+func TestUploadSessionUsesFilesRoutePolicy(t *testing.T) {
+	uploads := config.UploadConfig{
+		Routes: map[string]upload.Policy{
+			usecase.FilesUploadRoute: {AllowedTypes: []string{"image/*"}, AllowedExtensions: []string{".png", ".txt"}},
+		},
+	}
+	sessions, state, files := newUploadSessionUsecase("", uploads)
+
+	if _, err := sessions.Create(userContext(7), usecaseDto.CreateUploadSession{FileName: "archive.zip", TotalSize: 10}); !errors.Is(err, upload.ErrExtensionNotAllowed) {
+		t.Fatalf("Create() error = %v, want %v", err, upload.ErrExtensionNotAllowed)
+	}
+	// The parts hold plain text, which the files route does not take in a single request either
+	if _, err := sessions.Complete(userContext(7), "session"); !errors.Is(err, upload.ErrTypeNotAllowed) {
+		t.Fatalf("Complete() error = %v, want %v", err, upload.ErrTypeNotAllowed)
+	}
+	if len(files.created) != 0 || state.status != model.UploadSessionPending {
+		t.Fatalf("registered %d files, session %s", len(files.created), state.status)
+	}
+}
+
+func TestFileUploadShortensLongNames(t *testing.T) {
+	files := &createdFileRepository{}
+	cfg := &config.Config{Logger: logging.LoggerConfig{FilePath: os.TempDir() + "/", Encoding: "json", Level: "error", Logger: "zap"}}
+	uploads := usecase.NewFileUsecase(cfg, files, &fakeUnitOfWork{}, &memoryStorage{blobs: map[string][]byte{}})
+
+	_, err := uploads.Upload(userContext(7), usecaseDto.UploadFile{
+		Content:  bytes.NewReader([]byte("plain text content")),
+		FileName: strings.Repeat("n", 300) + ".txt",
+	})
+	if err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if name := files.created[0].OriginalName; len(name) != upload.MaxFileNameLength || !strings.HasSuffix(name, ".txt") {
+		t.Fatalf("OriginalName has %d characters: %s", len(name), name)
+	}
+}
@@ -0,0 +1,43 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/minisource/template_go/domain/model"
+	"github.com/minisource/template_go/usecase"
+	"github.com/minisource/template_go/usecase/dto"
+	"github.com/minisource/go-common/service_errors"
+)
+
+func TestCreatedRowsStartAtVersionOne(t *testing.T) {
+	// A value copied from another row must not leak into the new one
+	file := &model.File{BaseModel: model.BaseModel{Version: 7}}
+	if err := file.BeforeCreate(createStatement(context.Background(), file)); err != nil {
+		t.Fatalf("BeforeCreate() error = %v", err)
+	}
+	if file.Version != 1 {
+		t.Fatalf("Version = %d, want 1", file.Version)
+	}
+}
+
+func TestVersionedOutputs(t *testing.T) {
+	outputs := []interface{ GetVersion() int }{
+		dto.File{Versioned: dto.Versioned{Version: 3}},
+		dto.FileContent{File: dto.File{Versioned: dto.Versioned{Version: 3}}},
+		dto.UserProfile{Versioned: dto.Versioned{Version: 3}},
+	}
+	for _, output := range outputs {
+		if output.GetVersion() != 3 {
+			t.Fatalf("%T.GetVersion() = %d, want 3", output, output.GetVersion())
+		}
+	}
+}
+
+func TestVersionConflictIsServiceError(t *testing.T) {
+	var serviceErr *service_errors.ServiceError
+	if !errors.As(usecase.ErrVersionConflict, &serviceErr) {
+		t.Fatal("ErrVersionConflict must be a ServiceError")
+	}
+}
@@ -0,0 +1,65 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/domain/model"
+	"github.com/minisource/template_go/domain/repository"
+	"github.com/minisource/template_go/pkg/keyset"
+	"github.com/minisource/template_go/usecase/dto"
+	"github.com/minisource/go-common/filter"
+	"github.com/minisource/go-common/logging"
+	"github.com/minisource/go-common/service_errors"
+)
+
+// AuditUsecase lists the audit log for compliance reviews
+type AuditUsecase struct {
+	logger     logging.Logger
+	repository repository.AuditLogRepository
+}
+
+func NewAuditUsecase(cfg *config.Config, repository repository.AuditLogRepository) *AuditUsecase {
+	return &AuditUsecase{
+		logger:     logging.NewLogger(&cfg.Logger),
+		repository: repository,
+	}
+}
+
+// GetByFilter lists the entries of the caller's tenant, for administrators
+func (u *AuditUsecase) GetByFilter(ctx context.Context, req filter.PaginationInputWithFilter) (*filter.PagedList[dto.AuditLog], error) {
+	caller, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !caller.IsAdmin() {
+		return nil, &service_errors.ServiceError{EndUserMessage: service_errors.PermissionDenied}
+	}
+
+	count, entries, err := u.repository.GetByFilter(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return convertList(count, *entries, req, u.response)
+}
+
+// GetByCursor is GetByFilter with keyset cursors, the log only grows
+func (u *AuditUsecase) GetByCursor(ctx context.Context, req keyset.Request) (*keyset.Page[dto.AuditLog], error) {
+	caller, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !caller.IsAdmin() {
+		return nil, &service_errors.ServiceError{EndUserMessage: service_errors.PermissionDenied}
+	}
+
+	page, err := u.repository.GetByCursor(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return convertPage(page, u.response)
+}
+
+func (u *AuditUsecase) response(entry model.AuditLog) (dto.AuditLog, error) {
+	return toAuditLogDto(entry), nil
+}
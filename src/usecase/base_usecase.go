@@ -5,48 +5,100 @@ import (
 
 	"github.com/minisource/template_go/config"
 	"github.com/minisource/template_go/domain/repository"
-	"github.com/minisource/go-common/common"
+	"github.com/minisource/template_go/pkg/keyset"
+	"github.com/minisource/template_go/pkg/patch"
 	"github.com/minisource/go-common/filter"
 	"github.com/minisource/go-common/logging"
 )
 
+// ErrVersionConflict is returned by Update when the version sent by the client is stale
+var ErrVersionConflict = repository.ErrVersionConflict
+
+// Search makes the lists read with ctx return only the rows matching the full-text query q,
+// ranked with highlighted snippets
+func Search(ctx context.Context, q string) context.Context {
+	return repository.Search(ctx, q)
+}
+
+// Project makes the reads done with ctx load only the given fields of the entities
+func Project(ctx context.Context, fields []string) context.Context {
+	return repository.Project(ctx, fields)
+}
+
+// Expand makes the reads done with ctx load the given relations of the entities as well
+func Expand(ctx context.Context, relations []string) context.Context {
+	return repository.Expand(ctx, relations)
+}
+
 type BaseUsecase[TEntity any, TCreate any, TUpdate any, TResponse any] struct {
 	logger     logging.Logger
 	repository repository.BaseRepository[TEntity]
+	unitOfWork repository.UnitOfWork
+	mapper     Mapper[TEntity, TCreate, TUpdate, TResponse]
 }
 
-func NewBaseUsecase[TEntity any, TCreate any, TUpdate any, TResponse any](cfg *config.Config, repository repository.BaseRepository[TEntity]) *BaseUsecase[TEntity, TCreate, TUpdate, TResponse] {
+// NewBaseUsecase converts with mapper, a nil mapper converts through JSON
+func NewBaseUsecase[TEntity any, TCreate any, TUpdate any, TResponse any](cfg *config.Config, repository repository.BaseRepository[TEntity], unitOfWork repository.UnitOfWork, mapper Mapper[TEntity, TCreate, TUpdate, TResponse]) *BaseUsecase[TEntity, TCreate, TUpdate, TResponse] {
 	logger := logging.NewLogger(&cfg.Logger)
+	if mapper == nil {
+		mapper = JSONMapper[TEntity, TCreate, TUpdate, TResponse]{}
+	}
 	return &BaseUsecase[TEntity, TCreate, TUpdate, TResponse]{
 		repository: repository,
+		unitOfWork: unitOfWork,
 		logger:     logger,
+		mapper:     mapper,
 	}
 }
 
 func (u *BaseUsecase[TEntity, TCreate, TUpdate, TResponse]) Create(ctx context.Context, req TCreate) (TResponse, error) {
-	var response TResponse
-	entity, _ := common.TypeConverter[TEntity](req)
+	entity, err := u.mapper.ToEntity(req)
+	if err != nil {
+		return *new(TResponse), mappingError(err)
+	}
 
-	entity, err := u.repository.Create(ctx, entity)
+	entity, err = u.repository.Create(ctx, entity)
 	if err != nil {
-		return response, err
+		return *new(TResponse), err
 	}
+	return u.response(entity)
+}
 
-	response, _ = common.TypeConverter[TResponse](entity)
-	return response, nil
+// Update replaces every field of TUpdate when the entity is still at version, 0 updates unconditionally
+func (u *BaseUsecase[TEntity, TCreate, TUpdate, TResponse]) Update(ctx context.Context, id int, version int, req TUpdate) (TResponse, error) {
+	columns, err := u.mapper.ToColumns(req)
+	if err != nil {
+		return *new(TResponse), mappingError(err)
+	}
+
+	entity, err := u.repository.Update(ctx, id, version, columns)
+	if err != nil {
+		return *new(TResponse), err
+	}
+	return u.response(entity)
 }
 
-func (u *BaseUsecase[TEntity, TCreate, TUpdate, TResponse]) Update(ctx context.Context, id int, req TUpdate) (TResponse, error) {
+// Patch writes only the fields present in the merge patch, an empty patch returns the entity unchanged
+func (u *BaseUsecase[TEntity, TCreate, TUpdate, TResponse]) Patch(ctx context.Context, id int, version int, req patch.Patch[TUpdate]) (TResponse, error) {
 	var response TResponse
-	updateMap, _ := common.TypeConverter[map[string]interface{}](req)
+	columns := req.Columns()
+	if len(columns) == 0 {
+		response, err := u.GetById(ctx, id)
+		if err != nil {
+			return response, err
+		}
+		// Nothing to write, but a stale If-Match still has to fail
+		if v, ok := any(response).(interface{ GetVersion() int }); ok && version > 0 && v.GetVersion() != version {
+			return *new(TResponse), ErrVersionConflict
+		}
+		return response, nil
+	}
 
-	entity, err := u.repository.Update(ctx, id, updateMap)
+	entity, err := u.repository.Update(ctx, id, version, columns)
 	if err != nil {
 		return response, err
 	}
-	response, _ = common.TypeConverter[TResponse](entity)
-
-	return response, nil
+	return u.response(entity)
 }
 
 func (u *BaseUsecase[TEntity, TCreate, TUpdate, TResponse]) Delete(ctx context.Context, id int) error {
@@ -54,21 +106,107 @@ func (u *BaseUsecase[TEntity, TCreate, TUpdate, TResponse]) Delete(ctx context.C
 	return u.repository.Delete(ctx, id)
 }
 
+// Restore undoes Delete
+func (u *BaseUsecase[TEntity, TCreate, TUpdate, TResponse]) Restore(ctx context.Context, id int) (TResponse, error) {
+	var response TResponse
+	entity, err := u.repository.Restore(ctx, id)
+	if err != nil {
+		return response, err
+	}
+	return u.response(entity)
+}
+
+// BulkCreate creates every item in one transaction, see bulk for the modes
+func (u *BaseUsecase[TEntity, TCreate, TUpdate, TResponse]) BulkCreate(ctx context.Context, atomic bool, items []TCreate) ([]BulkResult[TResponse], error) {
+	return bulk(ctx, u.unitOfWork, atomic, items, u.Create)
+}
+
+// BulkPatch applies every merge patch in one transaction
+func (u *BaseUsecase[TEntity, TCreate, TUpdate, TResponse]) BulkPatch(ctx context.Context, atomic bool, items []BulkPatchItem[TUpdate]) ([]BulkResult[TResponse], error) {
+	return bulk(ctx, u.unitOfWork, atomic, items, func(ctx context.Context, item BulkPatchItem[TUpdate]) (TResponse, error) {
+		return u.Patch(ctx, item.Id, item.Version, item.Patch)
+	})
+}
+
+// BulkDelete deletes every id in one transaction, the results carry the ids
+func (u *BaseUsecase[TEntity, TCreate, TUpdate, TResponse]) BulkDelete(ctx context.Context, atomic bool, ids []int) ([]BulkResult[int], error) {
+	return bulk(ctx, u.unitOfWork, atomic, ids, func(ctx context.Context, id int) (int, error) {
+		return id, u.Delete(ctx, id)
+	})
+}
+
 func (u *BaseUsecase[TEntity, TCreate, TUpdate, TResponse]) GetById(ctx context.Context, id int) (TResponse, error) {
 	var response TResponse
 	entity, err := u.repository.GetById(ctx, id)
 	if err != nil {
 		return response, err
 	}
-	return common.TypeConverter[TResponse](entity)
+	return u.response(entity)
 }
 
 func (u *BaseUsecase[TEntity, TCreate, TUpdate, TResponse]) GetByFilter(ctx context.Context, req filter.PaginationInputWithFilter) (*filter.PagedList[TResponse], error) {
-	var response *filter.PagedList[TResponse]
 	count, entities, err := u.repository.GetByFilter(ctx, req)
 	if err != nil {
-		return response, err
+		return nil, err
+	}
+	return convertList(count, *entities, req, u.response)
+}
+
+// GetByCursor is GetByFilter with keyset cursors, for tables too large for COUNT and OFFSET
+func (u *BaseUsecase[TEntity, TCreate, TUpdate, TResponse]) GetByCursor(ctx context.Context, req keyset.Request) (*keyset.Page[TResponse], error) {
+	page, err := u.repository.GetByCursor(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return convertPage(page, u.response)
+}
+
+func (u *BaseUsecase[TEntity, TCreate, TUpdate, TResponse]) response(entity TEntity) (TResponse, error) {
+	response, err := u.mapper.ToResponse(entity)
+	if err != nil {
+		return response, mappingError(err)
+	}
+	return response, nil
+}
+
+// convertList maps the entities of a page to the usecase output
+func convertList[TEntity any, TResponse any](count int64, entities []TEntity, req filter.PaginationInputWithFilter, convert func(entity TEntity) (TResponse, error)) (*filter.PagedList[TResponse], error) {
+	items, err := convertItems(entities, convert)
+	if err != nil {
+		return nil, err
+	}
+	// Paginate only computes the page numbers here, the items are already converted
+	list, err := filter.Paginate[TResponse, TResponse](count, &[]TResponse{}, req.PageNumber, int64(req.PageSize))
+	if err != nil {
+		return nil, err
 	}
+	list.Items = &items
+	return list, nil
+}
 
-	return filter.Paginate[TEntity, TResponse](count, entities, req.PageNumber, int64(req.PageSize))
+// convertPage maps the entities of a keyset page to the usecase output
+func convertPage[TEntity any, TResponse any](page *keyset.Page[TEntity], convert func(entity TEntity) (TResponse, error)) (*keyset.Page[TResponse], error) {
+	items, err := convertItems(*page.Items, convert)
+	if err != nil {
+		return nil, err
+	}
+	return &keyset.Page[TResponse]{
+		PageSize:   page.PageSize,
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+		TotalRows:  page.TotalRows,
+		Items:      &items,
+	}, nil
+}
+
+func convertItems[TEntity any, TResponse any](entities []TEntity, convert func(entity TEntity) (TResponse, error)) ([]TResponse, error) {
+	items := make([]TResponse, 0, len(entities))
+	for _, entity := range entities {
+		item, err := convert(entity)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
 }
@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/minisource/template_go/domain/repository"
+	"github.com/minisource/template_go/pkg/patch"
+)
+
+// Bulk modes, a batch is all or nothing unless it asks for best effort
+const (
+	BulkAtomic     string = "atomic"
+	BulkBestEffort string = "bestEffort"
+)
+
+// ErrBulkRolledBack is the result of an item that succeeded in an atomic batch another item failed
+var ErrBulkRolledBack = errors.New("rolled back, another item of the batch failed")
+
+// errBulkFailed undoes an atomic batch once every item has been tried
+var errBulkFailed = errors.New("bulk operation failed")
+
+// BulkResult is the outcome of one item of a batch, Err is nil when the item was applied
+type BulkResult[T any] struct {
+	Value T
+	Err   error
+}
+
+// BulkPatchItem is the merge patch of one entity in a batch, version 0 skips the version check
+type BulkPatchItem[T any] struct {
+	Id      int
+	Version int
+	Patch   patch.Patch[T]
+}
+
+// bulk runs fn for every item in one unit of work, each item in a savepoint so a failure only
+// undoes that item. Atomic batches still try every item, so the caller learns all the failures
+// at once, and are then rolled back as a whole.
+func bulk[TItem any, TResult any](ctx context.Context, unitOfWork repository.UnitOfWork, atomic bool, items []TItem, fn func(ctx context.Context, item TItem) (TResult, error)) ([]BulkResult[TResult], error) {
+	results := make([]BulkResult[TResult], len(items))
+	err := unitOfWork.Do(ctx, func(ctx context.Context) error {
+		failed := false
+		for i, item := range items {
+			results[i].Err = unitOfWork.Do(ctx, func(ctx context.Context) error {
+				value, err := fn(ctx, item)
+				if err != nil {
+					return err
+				}
+				results[i].Value = value
+				return nil
+			})
+			failed = failed || results[i].Err != nil
+		}
+		if atomic && failed {
+			return errBulkFailed
+		}
+		return nil
+	})
+	if errors.Is(err, errBulkFailed) {
+		for i := range results {
+			if results[i].Err == nil {
+				results[i] = BulkResult[TResult]{Err: ErrBulkRolledBack}
+			}
+		}
+		return results, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,19 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditLog struct {
+	Id        int
+	Action    string
+	Entity    string
+	EntityId  int
+	CreatedBy int
+	CreatedAt time.Time
+	Username  string
+	Ip        string
+	RequestId string
+	Changes   json.RawMessage
+}
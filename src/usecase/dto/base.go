@@ -1,5 +1,28 @@
 package dto
 
+import (
+	"database/sql"
+	"io"
+	"time"
+
+	"github.com/minisource/template_go/pkg/upload"
+)
+
+// Versioned carries the row version, the generic handlers send it as ETag
+type Versioned struct {
+	Version int
+}
+
+func (v Versioned) GetVersion() int {
+	return v.Version
+}
+
+// SearchHit is set on the items of a list read with a full-text query
+type SearchHit struct {
+	SearchRank       float64
+	SearchHighlights map[string]string // Column name to snippet, matches wrapped in <mark>
+}
+
 type IdName struct {
 	Id   int
 	Name string
@@ -9,10 +32,27 @@ type Name struct {
 }
 
 type CreateFile struct {
-	Name        string
-	Directory   string
+	Name          string
+	OriginalName  string
+	Directory     string
+	Description   string
+	MimeType      string
+	Size          int64
+	Checksum      string
+	VariantStatus string
+}
+
+type UploadFile struct {
+	Content     io.ReadSeeker
+	FileName    string
 	Description string
-	MimeType    string
+	Checksum    string // Optional SHA-256 computed by the client
+	Policy      upload.Policy
+}
+
+type UploadResult struct {
+	File      File
+	Duplicate bool // The caller already uploaded this content, File is the existing record
 }
 
 type UpdateFile struct {
@@ -21,7 +61,26 @@ type UpdateFile struct {
 
 type File struct {
 	IdName
-	Directory   string
-	Description string
-	MimeType    string
+	Versioned
+	SearchHit
+	OriginalName  string
+	Directory     string
+	Description   string
+	MimeType      string
+	Size          int64
+	Checksum      string
+	ParentId      *int
+	Variant       string
+	Width         int
+	Height        int
+	VariantStatus string
+	Variants      []File
+	DeletedAt     sql.NullTime // Only set when deleted rows were included
+}
+
+type FileContent struct {
+	File
+	Size         int64
+	ETag         string
+	LastModified time.Time
 }
@@ -0,0 +1,33 @@
+package dto
+
+import (
+	"io"
+	"time"
+)
+
+type CreateUploadSession struct {
+	FileName    string
+	Description string
+	TotalSize   int64
+	Checksum    string // Optional SHA-256 of the whole file, verified on completion
+}
+
+type UploadPart struct {
+	Key     string
+	Number  int
+	Size    int64
+	Content io.Reader
+}
+
+type UploadSession struct {
+	Key           string
+	FileName      string
+	Description   string
+	TotalSize     int64
+	ChunkSize     int64
+	TotalParts    int
+	ReceivedParts []int
+	Status        string
+	ExpiresAt     time.Time
+	FileId        *int
+}
@@ -1,8 +1,38 @@
 package dto
 
+import (
+	"database/sql"
+	"encoding/json"
+)
+
 type TokenDetail struct {
 	AccessToken            string
 	RefreshToken           string
 	AccessTokenExpireTime  int64
 	RefreshTokenExpireTime int64
 }
+
+type UserProfile struct {
+	Versioned
+	Id          int
+	UserId      string
+	Username    string
+	DisplayName string
+	Email       string
+	Phone       string
+	AvatarId    *int
+	Avatar      *File
+	Locale      string
+	Timezone    string
+	Preferences json.RawMessage
+	SyncedAt    sql.NullTime
+}
+
+// UpdateUserProfile changes only the fields that are set, AvatarId 0 removes the avatar
+type UpdateUserProfile struct {
+	DisplayName *string
+	AvatarId    *int
+	Locale      *string
+	Timezone    *string
+	Preferences json.RawMessage // Replaces the stored preferences, must be a JSON object
+}
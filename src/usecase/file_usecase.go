@@ -2,21 +2,61 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/minisource/template_go/config"
 	"github.com/minisource/template_go/domain/model"
 	"github.com/minisource/template_go/domain/repository"
+	"github.com/minisource/template_go/domain/storage"
+	"github.com/minisource/template_go/pkg/imaging"
+	"github.com/minisource/template_go/pkg/keyset"
+	"github.com/minisource/template_go/pkg/patch"
+	"github.com/minisource/template_go/pkg/upload"
 	"github.com/minisource/template_go/usecase/dto"
 	"github.com/minisource/go-common/filter"
+	"github.com/minisource/go-common/logging"
+	"github.com/minisource/go-common/service_errors"
 )
 
+const uploadDirectory string = "uploads"
+
+// FilesUploadRoute is the name of the upload policy of files, whether sent at once or in parts
+const FilesUploadRoute string = "files"
+
+var (
+	ErrVariantNotFound = &service_errors.ServiceError{EndUserMessage: service_errors.RecordNotFound}
+	ErrVariantNotReady = errors.New("image variant is not generated yet")
+)
+
+// fileMapper converts with the functions cmd/mapgen writes
+var fileMapper = FuncMapper[model.File, dto.CreateFile, dto.UpdateFile, dto.File]{
+	Entity:   toFileEntity,
+	Columns:  fileColumns,
+	Response: toFileDto,
+}
+
 type FileUsecase struct {
-	base *BaseUsecase[model.File, dto.CreateFile, dto.UpdateFile, dto.File]
+	logger     logging.Logger
+	base       *BaseUsecase[model.File, dto.CreateFile, dto.UpdateFile, dto.File]
+	repository repository.FileRepository
+	storage    storage.Storage
+	images     config.ImageConfig
+	softDelete config.SoftDeleteConfig
 }
 
-func NewFileUsecase(cfg *config.Config, repository repository.FileRepository) *FileUsecase {
+func NewFileUsecase(cfg *config.Config, repository repository.FileRepository, unitOfWork repository.UnitOfWork, storage storage.Storage) *FileUsecase {
 	return &FileUsecase{
-		base: NewBaseUsecase[model.File, dto.CreateFile, dto.UpdateFile, dto.File](cfg, repository),
+		logger:     logging.NewLogger(&cfg.Logger),
+		base:       NewBaseUsecase[model.File, dto.CreateFile, dto.UpdateFile, dto.File](cfg, repository, unitOfWork, fileMapper),
+		repository: repository,
+		storage:    storage,
+		images:     cfg.Images,
+		softDelete: cfg.SoftDelete,
 	}
 }
 
@@ -25,22 +65,266 @@ func (u *FileUsecase) Create(ctx context.Context, req dto.CreateFile) (dto.File,
 	return u.base.Create(ctx, req)
 }
 
+// Upload validates the content against the route policy, stores it under a random name and registers the file.
+// Content the caller has already uploaded is not stored twice, the existing file is returned instead.
+func (u *FileUsecase) Upload(ctx context.Context, req dto.UploadFile) (dto.UploadResult, error) {
+	caller, err := currentUser(ctx)
+	if err != nil {
+		return dto.UploadResult{}, err
+	}
+
+	inspection, err := req.Policy.Inspect(req.Content, req.FileName, req.Checksum)
+	if err != nil {
+		return dto.UploadResult{}, err
+	}
+
+	existing, err := u.repository.GetByChecksum(ctx, inspection.Checksum, caller.UserId)
+	if err != nil {
+		return dto.UploadResult{}, err
+	}
+	if existing != nil {
+		return dto.UploadResult{File: toFileDto(*existing), Duplicate: true}, nil
+	}
+
+	// test.txt -> 0c4f3c1e-....txt
+	create := dto.CreateFile{
+		Name:         uuid.New().String() + inspection.Extension,
+		OriginalName: upload.FileName(req.FileName),
+		Directory:    uploadDirectory,
+		Description:  req.Description,
+		MimeType:     inspection.MimeType,
+		Size:         inspection.Size,
+		Checksum:     inspection.Checksum,
+	}
+	// Variants are rendered by a background job so the upload returns immediately
+	if len(u.images.Variants) > 0 && imaging.Supported(inspection.MimeType) {
+		create.VariantStatus = model.VariantStatusPending
+	}
+
+	key := fileKey(create.Directory, create.Name)
+	if err := u.storage.Put(ctx, key, req.Content, inspection.Size, inspection.MimeType); err != nil {
+		u.logger.Error(logging.IO, logging.Insert, err.Error(), nil)
+		return dto.UploadResult{}, err
+	}
+
+	file, err := u.base.Create(ctx, create)
+	if err != nil {
+		u.removeBlob(ctx, key)
+		return dto.UploadResult{}, err
+	}
+	return dto.UploadResult{File: file}, nil
+}
+
 // Update
-func (u *FileUsecase) Update(ctx context.Context, id int, req dto.UpdateFile) (dto.File, error) {
-	return u.base.Update(ctx, id, req)
+func (u *FileUsecase) Update(ctx context.Context, id int, version int, req dto.UpdateFile) (dto.File, error) {
+	if err := u.checkOwner(ctx, id); err != nil {
+		return dto.File{}, err
+	}
+	return u.base.Update(ctx, id, version, req)
 }
 
-// Delete
+// Patch changes the fields present in req
+func (u *FileUsecase) Patch(ctx context.Context, id int, version int, req patch.Patch[dto.UpdateFile]) (dto.File, error) {
+	if err := u.checkOwner(ctx, id); err != nil {
+		return dto.File{}, err
+	}
+	return u.base.Patch(ctx, id, version, req)
+}
+
+// Delete hides the file and its image variants, the blobs stay until PurgeDeleted so it can be restored.
+// Variants go first, a purged original must not be referenced by a live variant.
 func (u *FileUsecase) Delete(ctx context.Context, id int) error {
+	if err := u.checkOwner(ctx, id); err != nil {
+		return err
+	}
+	file, err := u.repository.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+	for _, variant := range file.Variants {
+		if err := u.base.Delete(ctx, variant.Id); err != nil {
+			return err
+		}
+	}
 	return u.base.Delete(ctx, id)
 }
 
+// BulkCreate registers every file in one transaction
+func (u *FileUsecase) BulkCreate(ctx context.Context, atomic bool, items []dto.CreateFile) ([]BulkResult[dto.File], error) {
+	return u.base.BulkCreate(ctx, atomic, items)
+}
+
+// BulkPatch edits every file in one transaction, each file only by its uploader or an admin
+func (u *FileUsecase) BulkPatch(ctx context.Context, atomic bool, items []BulkPatchItem[dto.UpdateFile]) ([]BulkResult[dto.File], error) {
+	return bulk(ctx, u.base.unitOfWork, atomic, items, func(ctx context.Context, item BulkPatchItem[dto.UpdateFile]) (dto.File, error) {
+		return u.Patch(ctx, item.Id, item.Version, item.Patch)
+	})
+}
+
+// BulkDelete deletes every file with its variants in one transaction
+func (u *FileUsecase) BulkDelete(ctx context.Context, atomic bool, ids []int) ([]BulkResult[int], error) {
+	return bulk(ctx, u.base.unitOfWork, atomic, ids, func(ctx context.Context, id int) (int, error) {
+		return id, u.Delete(ctx, id)
+	})
+}
+
+// Restore brings back a deleted file with its deleted variants, for administrators
+func (u *FileUsecase) Restore(ctx context.Context, id int) (dto.File, error) {
+	caller, err := currentUser(ctx)
+	if err != nil {
+		return dto.File{}, err
+	}
+	if !caller.IsAdmin() {
+		return dto.File{}, &service_errors.ServiceError{EndUserMessage: service_errors.PermissionDenied}
+	}
+
+	if _, err := u.repository.Restore(ctx, id); err != nil {
+		return dto.File{}, err
+	}
+	file, err := u.repository.GetById(repository.IncludeDeleted(ctx), id)
+	if err != nil {
+		return dto.File{}, err
+	}
+	for _, variant := range file.Variants {
+		if !variant.DeletedAt.Valid {
+			continue
+		}
+		if _, err := u.repository.Restore(ctx, variant.Id); err != nil {
+			return dto.File{}, err
+		}
+	}
+	return u.base.GetById(ctx, id)
+}
+
+// PurgeDeleted removes the files deleted longer than the retention ago for good, then their blobs.
+// A blob left behind by a failed removal is harmless, a record without its blob is not.
+func (u *FileUsecase) PurgeDeleted(ctx context.Context) error {
+	before := time.Now().UTC().Add(-u.softDelete.Retention())
+	for ctx.Err() == nil {
+		files, err := u.repository.Purge(ctx, before, u.softDelete.Batch())
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			u.removeBlob(ctx, fileKey(file.Directory, file.Name))
+		}
+		if len(files) < u.softDelete.Batch() {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
+
 // Get By Id
 func (u *FileUsecase) GetById(ctx context.Context, id int) (dto.File, error) {
+	if err := u.checkOwner(ctx, id); err != nil {
+		return dto.File{}, err
+	}
 	return u.base.GetById(ctx, id)
 }
 
-// Get By Filter
+// GetContent checks access to a file and returns the metadata of its stored content,
+// or of one of its image variants when variant is set
+func (u *FileUsecase) GetContent(ctx context.Context, id int, variant string) (dto.FileContent, error) {
+	file, err := u.GetById(ctx, id)
+	if err != nil {
+		return dto.FileContent{}, err
+	}
+	if variant != "" {
+		if file, err = u.variant(file, variant); err != nil {
+			return dto.FileContent{}, err
+		}
+	}
+	info, err := u.storage.Stat(ctx, fileKey(file.Directory, file.Name))
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return dto.FileContent{}, &service_errors.ServiceError{EndUserMessage: service_errors.RecordNotFound, Err: err}
+	}
+	if err != nil {
+		return dto.FileContent{}, err
+	}
+	return dto.FileContent{File: file, Size: info.Size, ETag: info.ETag, LastModified: info.LastModified}, nil
+}
+
+// OpenContent opens length bytes of the file content starting at offset,
+// access must have been checked with GetContent
+func (u *FileUsecase) OpenContent(ctx context.Context, file dto.File, offset int64, length int64) (io.ReadCloser, error) {
+	return u.storage.GetRange(ctx, fileKey(file.Directory, file.Name), offset, length)
+}
+
+// Get By Filter, non admin users only see their own uploads
 func (u *FileUsecase) GetByFilter(ctx context.Context, req filter.PaginationInputWithFilter) (*filter.PagedList[dto.File], error) {
-	return u.base.GetByFilter(ctx, req)
+	caller, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if caller.IsAdmin() {
+		return u.base.GetByFilter(ctx, req)
+	}
+
+	count, entities, err := u.repository.GetByFilterCreatedBy(ctx, req, caller.UserId)
+	if err != nil {
+		return nil, err
+	}
+	return convertList(count, *entities, req, u.base.response)
+}
+
+// GetByCursor is GetByFilter with keyset cursors
+func (u *FileUsecase) GetByCursor(ctx context.Context, req keyset.Request) (*keyset.Page[dto.File], error) {
+	caller, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if caller.IsAdmin() {
+		return u.base.GetByCursor(ctx, req)
+	}
+
+	page, err := u.repository.GetByCursorCreatedBy(ctx, req, caller.UserId)
+	if err != nil {
+		return nil, err
+	}
+	return convertPage(page, u.base.response)
+}
+
+// checkOwner allows access to a file only for its uploader or an admin
+func (u *FileUsecase) checkOwner(ctx context.Context, id int) error {
+	caller, err := currentUser(ctx)
+	if err != nil {
+		return err
+	}
+	if caller.IsAdmin() {
+		return nil
+	}
+
+	file, err := u.repository.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if file.CreatedBy != caller.UserId {
+		return &service_errors.ServiceError{EndUserMessage: service_errors.PermissionDenied}
+	}
+	return nil
+}
+
+// variant finds a generated variant, telling apart the ones that are still being rendered
+func (u *FileUsecase) variant(file dto.File, name string) (dto.File, error) {
+	for _, variant := range file.Variants {
+		if variant.Variant == name {
+			return variant, nil
+		}
+	}
+	configured := slices.ContainsFunc(u.images.Variants, func(v imaging.Variant) bool { return v.Name == name })
+	if configured && (file.VariantStatus == model.VariantStatusPending || file.VariantStatus == model.VariantStatusProcessing) {
+		return dto.File{}, ErrVariantNotReady
+	}
+	return dto.File{}, ErrVariantNotFound
+}
+
+func (u *FileUsecase) removeBlob(ctx context.Context, key string) {
+	if err := u.storage.Delete(ctx, key); err != nil {
+		u.logger.Error(logging.IO, logging.RemoveFile, err.Error(), nil)
+	}
+}
+
+func fileKey(directory, name string) string {
+	return fmt.Sprintf("%s/%s", directory, name)
 }
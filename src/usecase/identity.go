@@ -0,0 +1,30 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/minisource/go-common/service_errors"
+	"github.com/minisource/template_go/domain/principal"
+	"github.com/minisource/template_go/domain/repository"
+)
+
+// currentUser returns the principal placed in the context by the authentication middleware or a job
+func currentUser(ctx context.Context) (principal.Principal, error) {
+	p, ok := principal.FromContext(ctx)
+	if !ok {
+		return principal.Principal{}, &service_errors.ServiceError{EndUserMessage: service_errors.PermissionDenied}
+	}
+	return p, nil
+}
+
+// IncludeDeleted lets the reads done with ctx return soft-deleted rows, for administrators only
+func IncludeDeleted(ctx context.Context) (context.Context, error) {
+	caller, err := currentUser(ctx)
+	if err != nil {
+		return ctx, err
+	}
+	if !caller.IsAdmin() {
+		return ctx, &service_errors.ServiceError{EndUserMessage: service_errors.PermissionDenied}
+	}
+	return repository.IncludeDeleted(ctx), nil
+}
@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/minisource/go-common/logging"
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/domain/model"
+	"github.com/minisource/template_go/domain/principal"
+	"github.com/minisource/template_go/domain/repository"
+	"github.com/minisource/template_go/domain/storage"
+	"github.com/minisource/template_go/pkg/imaging"
+)
+
+// variantClaimTimeout is how long a file may stay in processing before another run picks it up again
+const variantClaimTimeout time.Duration = 15 * time.Minute
+
+// ImageVariantUsecase renders the configured variants of uploaded images and stores them as child files
+type ImageVariantUsecase struct {
+	logger     logging.Logger
+	config     config.ImageConfig
+	repository repository.FileRepository
+	storage    storage.Storage
+}
+
+func NewImageVariantUsecase(cfg *config.Config, repository repository.FileRepository, storage storage.Storage) *ImageVariantUsecase {
+	return &ImageVariantUsecase{
+		logger:     logging.NewLogger(&cfg.Logger),
+		config:     cfg.Images,
+		repository: repository,
+		storage:    storage,
+	}
+}
+
+// ProcessPending renders the variants of a batch of images waiting for them.
+// A file whose variants cannot be rendered is marked failed and not retried.
+func (u *ImageVariantUsecase) ProcessPending(ctx context.Context) error {
+	files, err := u.repository.ClaimPendingVariants(ctx, u.config.Batch(), time.Now().UTC().Add(-variantClaimTimeout))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		status := model.VariantStatusDone
+		if err := u.render(ctx, file); err != nil {
+			u.logger.Error(logging.IO, logging.Insert, fmt.Sprintf("variants of file %d failed: %s", file.Id, err.Error()), nil)
+			status = model.VariantStatusFailed
+		}
+		if err := u.repository.SetVariantStatus(ctx, file.Id, status); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *ImageVariantUsecase) render(ctx context.Context, file model.File) error {
+	reader, err := u.storage.Get(ctx, fileKey(file.Directory, file.Name))
+	if err != nil {
+		return err
+	}
+	content, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return err
+	}
+	source, format, err := imaging.Decode(bytes.NewReader(content), u.config.MaxPixels)
+	if err != nil {
+		return err
+	}
+
+	// Variants belong to the uploader and the tenant of the original
+	system, _ := principal.FromContext(ctx)
+	ctx = principal.WithPrincipal(ctx, system.For(file.CreatedBy).In(file.TenantId))
+	base := strings.TrimSuffix(file.Name, filepath.Ext(file.Name))
+	for _, variant := range u.config.Variants {
+		// A run interrupted halfway leaves some variants behind
+		existing, err := u.repository.GetVariant(ctx, file.Id, variant.Name)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			continue
+		}
+
+		img, err := imaging.Render(source, format, variant)
+		if err != nil {
+			return err
+		}
+		// 0c4f3c1e-....png -> 0c4f3c1e-..._thumbnail.webp
+		name := base + "_" + variant.Name + img.Extension
+		key := fileKey(file.Directory, name)
+		size := int64(len(img.Content))
+		if err := u.storage.Put(ctx, key, bytes.NewReader(img.Content), size, img.MimeType); err != nil {
+			return err
+		}
+
+		sum := sha256.Sum256(img.Content)
+		parentId := file.Id
+		_, err = u.repository.Create(ctx, model.File{
+			Name:         name,
+			OriginalName: strings.TrimSuffix(file.OriginalName, filepath.Ext(file.OriginalName)) + "_" + variant.Name + img.Extension,
+			Directory:    file.Directory,
+			Description:  file.Description,
+			MimeType:     img.MimeType,
+			Size:         size,
+			Checksum:     hex.EncodeToString(sum[:]),
+			ParentId:     &parentId,
+			Variant:      variant.Name,
+			Width:        img.Width,
+			Height:       img.Height,
+		})
+		if err != nil {
+			u.removeBlob(ctx, key)
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *ImageVariantUsecase) removeBlob(ctx context.Context, key string) {
+	if err := u.storage.Delete(ctx, key); err != nil {
+		u.logger.Error(logging.IO, logging.RemoveFile, err.Error(), nil)
+	}
+}
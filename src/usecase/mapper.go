@@ -0,0 +1,59 @@
+package usecase
+
+import (
+	"github.com/minisource/go-common/common"
+	"github.com/minisource/go-common/service_errors"
+)
+
+//go:generate go run ../cmd/mapgen -o mapper_gen.go
+
+// Mapper converts the DTOs of a usecase to and from its entity
+type Mapper[TEntity any, TCreate any, TUpdate any, TResponse any] interface {
+	ToEntity(req TCreate) (TEntity, error)
+	// ToColumns returns the fields of an update by Go field name
+	ToColumns(req TUpdate) (map[string]interface{}, error)
+	ToResponse(entity TEntity) (TResponse, error)
+}
+
+// FuncMapper is a Mapper of plain functions, the ones cmd/mapgen writes to mapper_gen.go
+type FuncMapper[TEntity any, TCreate any, TUpdate any, TResponse any] struct {
+	Entity   func(req TCreate) TEntity
+	Columns  func(req TUpdate) map[string]interface{}
+	Response func(entity TEntity) TResponse
+}
+
+func (m FuncMapper[TEntity, TCreate, TUpdate, TResponse]) ToEntity(req TCreate) (TEntity, error) {
+	return m.Entity(req), nil
+}
+
+func (m FuncMapper[TEntity, TCreate, TUpdate, TResponse]) ToColumns(req TUpdate) (map[string]interface{}, error) {
+	return m.Columns(req), nil
+}
+
+func (m FuncMapper[TEntity, TCreate, TUpdate, TResponse]) ToResponse(entity TEntity) (TResponse, error) {
+	return m.Response(entity), nil
+}
+
+// JSONMapper converts through JSON with common.TypeConverter, it is the Mapper of usecases
+// that do not plug one in. Fields only match when their JSON names do.
+type JSONMapper[TEntity any, TCreate any, TUpdate any, TResponse any] struct{}
+
+func (JSONMapper[TEntity, TCreate, TUpdate, TResponse]) ToEntity(req TCreate) (TEntity, error) {
+	return common.TypeConverter[TEntity](req)
+}
+
+func (JSONMapper[TEntity, TCreate, TUpdate, TResponse]) ToColumns(req TUpdate) (map[string]interface{}, error) {
+	return common.TypeConverter[map[string]interface{}](req)
+}
+
+func (JSONMapper[TEntity, TCreate, TUpdate, TResponse]) ToResponse(entity TEntity) (TResponse, error) {
+	return common.TypeConverter[TResponse](entity)
+}
+
+// mappingFailed is the message of a failed conversion, it is not the caller's fault
+const mappingFailed string = "the data could not be converted"
+
+// mappingError surfaces a failed conversion as a ServiceError, the cause is kept in Err
+func mappingError(err error) error {
+	return &service_errors.ServiceError{EndUserMessage: mappingFailed, Err: err}
+}
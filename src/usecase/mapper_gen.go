@@ -0,0 +1,124 @@
+// Code generated by cmd/mapgen. DO NOT EDIT.
+
+package usecase
+
+import (
+	"github.com/minisource/template_go/domain/model"
+	"github.com/minisource/template_go/usecase/dto"
+)
+
+// toFileEntity maps dto.CreateFile to model.File, leaving Id, TenantId, Version, CreatedAt, ModifiedAt, DeletedAt, CreatedBy, ModifiedBy, DeletedBy, SearchRank, SearchHighlights, ParentId, Variant, Width, Height, Variants unset
+func toFileEntity(from dto.CreateFile) model.File {
+	var to model.File
+	to.Name = from.Name
+	to.OriginalName = from.OriginalName
+	to.Directory = from.Directory
+	to.Description = from.Description
+	to.MimeType = from.MimeType
+	to.Size = from.Size
+	to.Checksum = from.Checksum
+	to.VariantStatus = from.VariantStatus
+	return to
+}
+
+// fileColumns returns the fields of dto.UpdateFile by name
+func fileColumns(from dto.UpdateFile) map[string]interface{} {
+	return map[string]interface{}{
+		"Description": from.Description,
+	}
+}
+
+// toFileDto maps model.File to dto.File
+func toFileDto(from model.File) dto.File {
+	var to dto.File
+	to.Id = from.Id
+	to.Name = from.Name
+	to.Version = from.Version
+	to.SearchRank = from.SearchRank
+	to.SearchHighlights = map[string]string(from.SearchHighlights)
+	to.OriginalName = from.OriginalName
+	to.Directory = from.Directory
+	to.Description = from.Description
+	to.MimeType = from.MimeType
+	to.Size = from.Size
+	to.Checksum = from.Checksum
+	to.ParentId = from.ParentId
+	to.Variant = from.Variant
+	to.Width = from.Width
+	to.Height = from.Height
+	to.VariantStatus = from.VariantStatus
+	if from.Variants != nil {
+		to.Variants = make([]dto.File, len(from.Variants))
+		for i, item := range from.Variants {
+			to.Variants[i] = toFileDto(item)
+		}
+	}
+	to.DeletedAt = from.DeletedAt
+	return to
+}
+
+// toUserEntity maps dto.UpdateUserProfile to model.User, leaving Id, TenantId, Version, CreatedAt, ModifiedAt, DeletedAt, CreatedBy, ModifiedBy, DeletedBy, UserId, Username, Email, Phone, Avatar, SyncedAt unset
+func toUserEntity(from dto.UpdateUserProfile) model.User {
+	var to model.User
+	if from.DisplayName != nil {
+		to.DisplayName = *from.DisplayName
+	}
+	to.AvatarId = from.AvatarId
+	if from.Locale != nil {
+		to.Locale = *from.Locale
+	}
+	if from.Timezone != nil {
+		to.Timezone = *from.Timezone
+	}
+	to.Preferences = from.Preferences
+	return to
+}
+
+// userProfileColumns returns the fields of dto.UpdateUserProfile by name
+func userProfileColumns(from dto.UpdateUserProfile) map[string]interface{} {
+	return map[string]interface{}{
+		"DisplayName": from.DisplayName,
+		"AvatarId":    from.AvatarId,
+		"Locale":      from.Locale,
+		"Timezone":    from.Timezone,
+		"Preferences": from.Preferences,
+	}
+}
+
+// toUserProfileDto maps model.User to dto.UserProfile
+func toUserProfileDto(from model.User) dto.UserProfile {
+	var to dto.UserProfile
+	to.Version = from.Version
+	to.Id = from.Id
+	to.UserId = from.UserId
+	to.Username = from.Username
+	to.DisplayName = from.DisplayName
+	to.Email = from.Email
+	to.Phone = from.Phone
+	to.AvatarId = from.AvatarId
+	if from.Avatar != nil {
+		value := toFileDto(*from.Avatar)
+		to.Avatar = &value
+	}
+	to.Locale = from.Locale
+	to.Timezone = from.Timezone
+	to.Preferences = from.Preferences
+	to.SyncedAt = from.SyncedAt
+	return to
+}
+
+// toAuditLogDto maps model.AuditLog to dto.AuditLog
+func toAuditLogDto(from model.AuditLog) dto.AuditLog {
+	var to dto.AuditLog
+	to.Id = from.Id
+	to.Action = from.Action
+	to.Entity = from.Entity
+	to.EntityId = from.EntityId
+	to.CreatedBy = from.CreatedBy
+	to.CreatedAt = from.CreatedAt
+	to.Username = from.Username
+	to.Ip = from.Ip
+	to.RequestId = from.RequestId
+	to.Changes = from.Changes
+	return to
+}
@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/constant"
+	"github.com/minisource/template_go/domain/ratelimit"
+)
+
+// OtpLimitError is returned when an OTP request or verification is throttled
+type OtpLimitError struct {
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *OtpLimitError) Error() string {
+	return e.Message
+}
+
+// OtpLimiter throttles OTP sends per phone number and client IP and locks
+// a phone number after too many wrong codes
+type OtpLimiter struct {
+	store  ratelimit.Store
+	config config.OtpLimitConfig
+}
+
+func NewOtpLimiter(cfg *config.Config, store ratelimit.Store) *OtpLimiter {
+	return &OtpLimiter{store: store, config: cfg.OtpLimit.WithDefaults()}
+}
+
+// AllowSend counts an OTP send, a locked phone number does not receive new codes.
+// The phone number is only counted once the IP passes, so a throttled client cannot
+// use up the sends of someone else's number.
+func (l *OtpLimiter) AllowSend(ctx context.Context, phone string, ip string) error {
+	if err := l.CheckLocked(ctx, phone); err != nil {
+		return err
+	}
+	if ip != "" {
+		if err := l.count(ctx, otpKey("send:ip", ip), l.config.SendPerIp); err != nil {
+			return err
+		}
+	}
+	return l.count(ctx, otpKey("send:phone", phone), l.config.SendPerPhone)
+}
+
+func (l *OtpLimiter) CheckLocked(ctx context.Context, phone string) error {
+	ttl, err := l.store.TTL(ctx, otpKey("lock", phone))
+	if err != nil {
+		return err
+	}
+	if ttl > 0 {
+		return &OtpLimitError{Message: "too many wrong codes, try again later", RetryAfter: ttl}
+	}
+	return nil
+}
+
+// RecordFailure counts a wrong code and locks the phone number once MaxAttempts is reached
+func (l *OtpLimiter) RecordFailure(ctx context.Context, phone string) error {
+	failures, _, err := l.store.Increment(ctx, otpKey("fail", phone), l.config.LockDuration)
+	if err != nil {
+		return err
+	}
+	if failures < int64(l.config.MaxAttempts) {
+		return nil
+	}
+	if _, _, err := l.store.Increment(ctx, otpKey("lock", phone), l.config.LockDuration); err != nil {
+		return err
+	}
+	if err := l.store.Delete(ctx, otpKey("fail", phone)); err != nil {
+		return err
+	}
+	return &OtpLimitError{Message: "too many wrong codes, try again later", RetryAfter: l.config.LockDuration}
+}
+
+// Reset forgets the wrong codes of a phone number after a successful login
+func (l *OtpLimiter) Reset(ctx context.Context, phone string) error {
+	return l.store.Delete(ctx, otpKey("fail", phone))
+}
+
+func (l *OtpLimiter) count(ctx context.Context, key string, limit int) error {
+	count, ttl, err := l.store.Increment(ctx, key, l.config.SendWindow)
+	if err != nil {
+		return err
+	}
+	if count > int64(limit) {
+		return &OtpLimitError{Message: "too many OTP requests, try again later", RetryAfter: ttl}
+	}
+	return nil
+}
+
+// otpKey, e.g. otp:send:phone:+989121234567
+func otpKey(kind string, value string) string {
+	return fmt.Sprintf("%s:%s:%s", constant.RedisOtpDefaultKey, kind, value)
+}
@@ -0,0 +1,160 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/domain/model"
+	"github.com/minisource/template_go/domain/repository"
+	"github.com/minisource/template_go/usecase/dto"
+	"github.com/minisource/go-common/filter"
+	"github.com/minisource/go-common/logging"
+	"golang.org/x/text/language"
+)
+
+const (
+	maxDisplayNameLength int = 100
+	maxPreferencesSize   int = 16 << 10
+)
+
+var (
+	ErrInvalidDisplayName = errors.New("display name must be at most 100 characters")
+	ErrInvalidLocale      = errors.New("locale must be a BCP 47 language tag, e.g. en-US")
+	ErrInvalidTimezone    = errors.New("timezone must be an IANA time zone name, e.g. Europe/Berlin")
+	ErrInvalidPreferences = errors.New("preferences must be a JSON object of at most 16KB")
+	ErrInvalidAvatar      = errors.New("avatar must be an image file uploaded by the user")
+)
+
+// profileMapper converts with the functions cmd/mapgen writes, the columns of an update are
+// built by profileColumns instead as every field is optional
+var profileMapper = FuncMapper[model.User, dto.UpdateUserProfile, dto.UpdateUserProfile, dto.UserProfile]{
+	Entity:   toUserEntity,
+	Columns:  userProfileColumns,
+	Response: toUserProfileDto,
+}
+
+// ProfileUsecase reads and edits the local profile of the caller
+type ProfileUsecase struct {
+	logger     logging.Logger
+	base       *BaseUsecase[model.User, dto.UpdateUserProfile, dto.UpdateUserProfile, dto.UserProfile]
+	repository repository.UserRepository
+	files      repository.FileRepository
+}
+
+func NewProfileUsecase(cfg *config.Config, repository repository.UserRepository, unitOfWork repository.UnitOfWork, files repository.FileRepository) *ProfileUsecase {
+	return &ProfileUsecase{
+		logger:     logging.NewLogger(&cfg.Logger),
+		base:       NewBaseUsecase[model.User, dto.UpdateUserProfile, dto.UpdateUserProfile, dto.UserProfile](cfg, repository, unitOfWork, profileMapper),
+		repository: repository,
+		files:      files,
+	}
+}
+
+// GetMe returns the profile of the caller
+func (u *ProfileUsecase) GetMe(ctx context.Context) (dto.UserProfile, error) {
+	caller, err := currentUser(ctx)
+	if err != nil {
+		return dto.UserProfile{}, err
+	}
+	return u.base.GetById(ctx, caller.UserId)
+}
+
+// UpdateMe validates and saves the fields set in req
+func (u *ProfileUsecase) UpdateMe(ctx context.Context, req dto.UpdateUserProfile) (dto.UserProfile, error) {
+	caller, err := currentUser(ctx)
+	if err != nil {
+		return dto.UserProfile{}, err
+	}
+
+	columns, err := u.profileColumns(ctx, caller.UserId, req)
+	if err != nil {
+		return dto.UserProfile{}, err
+	}
+	if len(columns) == 0 {
+		return u.base.GetById(ctx, caller.UserId)
+	}
+	user, err := u.repository.Update(ctx, caller.UserId, 0, columns)
+	if err != nil {
+		return dto.UserProfile{}, err
+	}
+	return toUserProfileDto(user), nil
+}
+
+// GetByFilter lists every profile, for administrators
+func (u *ProfileUsecase) GetByFilter(ctx context.Context, req filter.PaginationInputWithFilter) (*filter.PagedList[dto.UserProfile], error) {
+	return u.base.GetByFilter(ctx, req)
+}
+
+func (u *ProfileUsecase) profileColumns(ctx context.Context, userId int, req dto.UpdateUserProfile) (map[string]interface{}, error) {
+	columns := map[string]interface{}{}
+
+	if req.DisplayName != nil {
+		name := strings.TrimSpace(*req.DisplayName)
+		if utf8.RuneCountInString(name) > maxDisplayNameLength {
+			return nil, ErrInvalidDisplayName
+		}
+		columns["DisplayName"] = name
+	}
+
+	if req.Locale != nil {
+		locale := strings.TrimSpace(*req.Locale)
+		if locale != "" {
+			tag, err := language.Parse(locale)
+			if err != nil {
+				return nil, ErrInvalidLocale
+			}
+			locale = tag.String()
+		}
+		columns["Locale"] = locale
+	}
+
+	if req.Timezone != nil {
+		timezone := strings.TrimSpace(*req.Timezone)
+		// LoadLocation also accepts "Local", which means nothing to the client
+		if timezone != "" {
+			if _, err := time.LoadLocation(timezone); err != nil || timezone == "Local" {
+				return nil, ErrInvalidTimezone
+			}
+		}
+		columns["Timezone"] = timezone
+	}
+
+	if req.Preferences != nil {
+		preferences := bytes.TrimSpace(req.Preferences)
+		var object map[string]json.RawMessage
+		if len(preferences) > maxPreferencesSize || json.Unmarshal(preferences, &object) != nil || object == nil {
+			return nil, ErrInvalidPreferences
+		}
+		columns["Preferences"] = string(preferences)
+	}
+
+	if req.AvatarId != nil {
+		if *req.AvatarId == 0 {
+			columns["AvatarId"] = nil
+		} else {
+			if err := u.checkAvatar(ctx, userId, *req.AvatarId); err != nil {
+				return nil, err
+			}
+			columns["AvatarId"] = *req.AvatarId
+		}
+	}
+	return columns, nil
+}
+
+// checkAvatar accepts uploaded images of the user, variants are picked through their original
+func (u *ProfileUsecase) checkAvatar(ctx context.Context, userId int, fileId int) error {
+	file, err := u.files.GetById(ctx, fileId)
+	if err != nil {
+		return ErrInvalidAvatar
+	}
+	if file.CreatedBy != userId || file.ParentId != nil || !strings.HasPrefix(file.MimeType, "image/") {
+		return ErrInvalidAvatar
+	}
+	return nil
+}
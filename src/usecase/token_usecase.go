@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	auth "github.com/minisource/auth/service"
+	"github.com/minisource/auth/service/models"
+	"github.com/minisource/go-common/logging"
+	"github.com/minisource/go-common/service_errors"
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/domain/model"
+	"github.com/minisource/template_go/domain/repository"
+)
+
+// TokenUsecase rotates refresh tokens and keeps the list of revoked ones
+type TokenUsecase struct {
+	logger      logging.Logger
+	authService *auth.AuthService
+	repository  repository.RevokedTokenRepository
+}
+
+func NewTokenUsecase(cfg *config.Config, repository repository.RevokedTokenRepository) *TokenUsecase {
+	return &TokenUsecase{
+		logger:      logging.NewLogger(&cfg.Logger),
+		authService: auth.GetAuthService(),
+		repository:  repository,
+	}
+}
+
+// Refresh exchanges a refresh token for a new token pair.
+// The presented token is revoked before the exchange, so a refresh token works exactly once
+// and a stolen copy is rejected after its owner used it, or after logout.
+func (u *TokenUsecase) Refresh(ctx context.Context, refreshToken string) (*models.AccessTokenResponse, error) {
+	revoked, err := u.revoke(ctx, refreshToken, model.TokenRevokedRotated)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := u.authService.CasdoorClient.RefreshOAuthToken(refreshToken)
+	if err != nil {
+		// The token was not used, let the client retry once the auth service is back
+		if err := u.repository.Restore(ctx, revoked.TokenHash); err != nil {
+			u.logger.Error(logging.Casdoor, logging.Delete, err.Error(), nil)
+		}
+		u.logger.Error(logging.Casdoor, logging.ExternalService, err.Error(), nil)
+		return nil, &service_errors.ServiceError{EndUserMessage: service_errors.TokenInvalid, Err: err}
+	}
+
+	idToken, _ := token.Extra("id_token").(string)
+	return &models.AccessTokenResponse{
+		AccessToken:  token.AccessToken,
+		IDToken:      idToken,
+		RefreshToken: token.RefreshToken,
+		TokenType:    token.TokenType,
+		ExpiresIn:    int(time.Until(token.Expiry).Seconds()),
+	}, nil
+}
+
+// Logout revokes a refresh token, an invalid or already revoked token is not an error
+func (u *TokenUsecase) Logout(ctx context.Context, refreshToken string) error {
+	_, err := u.revoke(ctx, refreshToken, model.TokenRevokedLogout)
+	var serviceErr *service_errors.ServiceError
+	if errors.As(err, &serviceErr) {
+		return nil
+	}
+	return err
+}
+
+// CleanupExpired forgets revocations of tokens that cannot be used anymore
+func (u *TokenUsecase) CleanupExpired(ctx context.Context) error {
+	_, err := u.repository.DeleteExpired(ctx, time.Now().UTC())
+	return err
+}
+
+// revoke verifies a refresh token and adds it to the revocation list,
+// failing when it is invalid or was already revoked
+func (u *TokenUsecase) revoke(ctx context.Context, refreshToken string, reason string) (model.RevokedToken, error) {
+	if refreshToken == "" {
+		return model.RevokedToken{}, &service_errors.ServiceError{EndUserMessage: service_errors.TokenRequired}
+	}
+	claims, err := u.authService.CasdoorClient.ParseJwtToken(refreshToken)
+	if err != nil || !claims.IsRefreshToken() || claims.ExpiresAt == nil {
+		return model.RevokedToken{}, &service_errors.ServiceError{EndUserMessage: service_errors.TokenInvalid, Err: err}
+	}
+
+	sum := sha256.Sum256([]byte(refreshToken))
+	token := model.RevokedToken{
+		TokenHash: hex.EncodeToString(sum[:]),
+		UserId:    claims.Id,
+		Reason:    reason,
+		ExpiresAt: claims.ExpiresAt.UTC(),
+	}
+	inserted, err := u.repository.Revoke(ctx, token)
+	if err != nil {
+		return token, err
+	}
+	if !inserted {
+		u.logger.Warn(logging.Casdoor, logging.Update, "revoked refresh token presented for user "+claims.Id, nil)
+		return token, &service_errors.ServiceError{EndUserMessage: service_errors.TokenInvalid}
+	}
+	return token, nil
+}
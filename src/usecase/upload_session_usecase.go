@@ -0,0 +1,302 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minisource/go-common/logging"
+	"github.com/minisource/go-common/service_errors"
+	"github.com/minisource/template_go/config"
+	"github.com/minisource/template_go/domain/model"
+	"github.com/minisource/template_go/domain/repository"
+	"github.com/minisource/template_go/domain/storage"
+	"github.com/minisource/template_go/pkg/upload"
+	"github.com/minisource/template_go/usecase/dto"
+)
+
+// cleanupBatchSize bounds how many expired sessions are loaded at once
+const cleanupBatchSize int = 100
+
+var (
+	ErrUploadSessionClosed = errors.New("upload session is not accepting parts anymore")
+	ErrInvalidPartNumber   = errors.New("part number is out of range")
+	ErrInvalidPartSize     = errors.New("part size does not match the session chunk size")
+	ErrUploadIncomplete    = errors.New("some parts have not been uploaded yet")
+)
+
+// UploadSessionUsecase implements resumable uploads: a session is opened with the final size,
+// parts of ChunkSize bytes are sent in any order and may be re-sent, and completing the session
+// assembles the parts and registers the file through FileUsecase like a single request upload.
+type UploadSessionUsecase struct {
+	logger     logging.Logger
+	config     config.ResumableUploadConfig
+	policy     upload.Policy // The one of FilesUploadRoute with the resumable size limit
+	repository repository.UploadSessionRepository
+	storage    storage.Storage
+	files      *FileUsecase
+}
+
+func NewUploadSessionUsecase(cfg *config.Config, repository repository.UploadSessionRepository, files *FileUsecase, storage storage.Storage) *UploadSessionUsecase {
+	return &UploadSessionUsecase{
+		logger:     logging.NewLogger(&cfg.Logger),
+		config:     cfg.Upload.Resumable,
+		policy:     cfg.Upload.ResumablePolicy(FilesUploadRoute),
+		repository: repository,
+		storage:    storage,
+		files:      files,
+	}
+}
+
+// Create opens a session, the file name and size are checked now so clients do not send parts for nothing
+func (u *UploadSessionUsecase) Create(ctx context.Context, req dto.CreateUploadSession) (dto.UploadSession, error) {
+	if _, err := currentUser(ctx); err != nil {
+		return dto.UploadSession{}, err
+	}
+	if req.TotalSize <= 0 {
+		return dto.UploadSession{}, upload.ErrEmpty
+	}
+	if req.TotalSize > u.policy.Limit() {
+		return dto.UploadSession{}, upload.ErrTooLarge
+	}
+	if !u.policy.AllowsExtension(filepath.Ext(req.FileName)) {
+		return dto.UploadSession{}, upload.ErrExtensionNotAllowed
+	}
+
+	session, err := u.repository.Create(ctx, model.UploadSession{
+		Key:         uuid.New().String(),
+		FileName:    upload.FileName(req.FileName),
+		Description: req.Description,
+		TotalSize:   req.TotalSize,
+		ChunkSize:   u.config.PartSize(),
+		Checksum:    req.Checksum,
+		Status:      model.UploadSessionPending,
+		ExpiresAt:   time.Now().UTC().Add(u.config.TTL()),
+	})
+	if err != nil {
+		return dto.UploadSession{}, err
+	}
+	return toUploadSession(session), nil
+}
+
+// Get returns the session state, clients resume by sending the parts missing from ReceivedParts
+func (u *UploadSessionUsecase) Get(ctx context.Context, key string) (dto.UploadSession, error) {
+	session, err := u.getOwned(ctx, key)
+	if err != nil {
+		return dto.UploadSession{}, err
+	}
+	return toUploadSession(session), nil
+}
+
+// PutPart stores a part, sending a part again replaces it
+func (u *UploadSessionUsecase) PutPart(ctx context.Context, req dto.UploadPart) (dto.UploadSession, error) {
+	session, err := u.getPending(ctx, req.Key)
+	if err != nil {
+		return dto.UploadSession{}, err
+	}
+	if req.Number < 1 || req.Number > session.TotalParts() {
+		return dto.UploadSession{}, ErrInvalidPartNumber
+	}
+	if req.Size != session.PartSize(req.Number) {
+		return dto.UploadSession{}, ErrInvalidPartSize
+	}
+
+	if err := u.storage.Put(ctx, partKey(session.Key, req.Number), req.Content, req.Size, "application/octet-stream"); err != nil {
+		u.logger.Error(logging.IO, logging.Insert, err.Error(), nil)
+		return dto.UploadSession{}, err
+	}
+	part := model.UploadPart{UploadSessionId: session.Id, Number: req.Number, Size: req.Size}
+	if err := u.repository.SavePart(ctx, part, time.Now().UTC().Add(u.config.TTL())); err != nil {
+		return dto.UploadSession{}, err
+	}
+	return u.Get(ctx, req.Key)
+}
+
+// Complete assembles the parts and registers the file. The session is claimed first so
+// concurrent completions do not register the file twice, a failure releases the claim and
+// keeps the session open so a corrupted part can be sent again.
+func (u *UploadSessionUsecase) Complete(ctx context.Context, key string) (dto.UploadResult, error) {
+	session, err := u.getPending(ctx, key)
+	if err != nil {
+		return dto.UploadResult{}, err
+	}
+	if len(session.Parts) != session.TotalParts() {
+		return dto.UploadResult{}, ErrUploadIncomplete
+	}
+	claimed, err := u.repository.ChangeStatus(ctx, session.Id, model.UploadSessionPending, model.UploadSessionCompleting, time.Now().UTC().Add(u.config.TTL()))
+	if err != nil {
+		return dto.UploadResult{}, err
+	}
+	if !claimed {
+		return dto.UploadResult{}, ErrUploadSessionClosed
+	}
+	session.Status = model.UploadSessionCompleting
+
+	res, err := u.register(ctx, session)
+	if err != nil {
+		// The request may be canceled, the claim is released anyway
+		if _, releaseErr := u.repository.ChangeStatus(context.WithoutCancel(ctx), session.Id, model.UploadSessionCompleting, model.UploadSessionPending, session.ExpiresAt); releaseErr != nil {
+			u.logger.Error(logging.General, logging.Update, releaseErr.Error(), nil)
+		}
+		return dto.UploadResult{}, err
+	}
+
+	// The file is registered at this point, a failure to close the session
+	// must not make the client upload it again
+	if err := u.close(ctx, session, model.UploadSessionCompleted, &res.File.Id); err != nil {
+		u.logger.Error(logging.General, logging.Update, err.Error(), nil)
+	}
+	return res, nil
+}
+
+// register assembles the parts of a claimed session and uploads the file
+func (u *UploadSessionUsecase) register(ctx context.Context, session model.UploadSession) (dto.UploadResult, error) {
+	content, err := u.assemble(ctx, session)
+	if err != nil {
+		return dto.UploadResult{}, err
+	}
+	defer func() {
+		content.Close()
+		os.Remove(content.Name())
+	}()
+
+	return u.files.Upload(ctx, dto.UploadFile{
+		Content:     content,
+		FileName:    session.FileName,
+		Description: session.Description,
+		Checksum:    session.Checksum,
+		Policy:      u.policy,
+	})
+}
+
+// Abort discards a session and its parts
+func (u *UploadSessionUsecase) Abort(ctx context.Context, key string) error {
+	session, err := u.getPending(ctx, key)
+	if err != nil {
+		return err
+	}
+	return u.close(ctx, session, model.UploadSessionAborted, nil)
+}
+
+// CleanupExpired expires the sessions that did not receive a part within the TTL and removes their parts,
+// including the ones left claimed by a completion that never finished
+func (u *UploadSessionUsecase) CleanupExpired(ctx context.Context) error {
+	for {
+		sessions, err := u.repository.GetExpired(ctx, time.Now().UTC(), cleanupBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, session := range sessions {
+			if err := u.close(ctx, session, model.UploadSessionExpired, nil); err != nil {
+				return err
+			}
+		}
+		if len(sessions) < cleanupBatchSize {
+			return nil
+		}
+	}
+}
+
+// assemble concatenates the parts into a temporary file, the upload policy needs to read the content twice
+func (u *UploadSessionUsecase) assemble(ctx context.Context, session model.UploadSession) (*os.File, error) {
+	file, err := os.CreateTemp("", "upload-*")
+	if err != nil {
+		return nil, err
+	}
+	fail := func(err error) (*os.File, error) {
+		file.Close()
+		os.Remove(file.Name())
+		u.logger.Error(logging.IO, logging.Select, err.Error(), nil)
+		return nil, err
+	}
+
+	for _, part := range session.Parts {
+		reader, err := u.storage.Get(ctx, partKey(session.Key, part.Number))
+		if err != nil {
+			return fail(err)
+		}
+		_, err = io.Copy(file, reader)
+		reader.Close()
+		if err != nil {
+			return fail(err)
+		}
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return fail(err)
+	}
+	return file, nil
+}
+
+// close ends a session in the status it was loaded with, parts are removed after the status
+// change so a failure only leaves orphan blobs
+func (u *UploadSessionUsecase) close(ctx context.Context, session model.UploadSession, status string, fileId *int) error {
+	closed, err := u.repository.Close(ctx, session.Id, session.Status, status, fileId)
+	if err != nil {
+		return err
+	}
+	if !closed {
+		return ErrUploadSessionClosed
+	}
+	for _, part := range session.Parts {
+		if err := u.storage.Delete(ctx, partKey(session.Key, part.Number)); err != nil {
+			u.logger.Error(logging.IO, logging.RemoveFile, err.Error(), nil)
+		}
+	}
+	return nil
+}
+
+func (u *UploadSessionUsecase) getPending(ctx context.Context, key string) (model.UploadSession, error) {
+	session, err := u.getOwned(ctx, key)
+	if err != nil {
+		return session, err
+	}
+	if session.Status != model.UploadSessionPending || time.Now().After(session.ExpiresAt) {
+		return session, ErrUploadSessionClosed
+	}
+	return session, nil
+}
+
+// getOwned only lets the user who opened a session use it
+func (u *UploadSessionUsecase) getOwned(ctx context.Context, key string) (model.UploadSession, error) {
+	caller, err := currentUser(ctx)
+	if err != nil {
+		return model.UploadSession{}, err
+	}
+	session, err := u.repository.GetByKey(ctx, key)
+	if err != nil {
+		return session, err
+	}
+	if session.CreatedBy != caller.UserId {
+		return session, &service_errors.ServiceError{EndUserMessage: service_errors.PermissionDenied}
+	}
+	return session, nil
+}
+
+func toUploadSession(from model.UploadSession) dto.UploadSession {
+	received := make([]int, 0, len(from.Parts))
+	for _, part := range from.Parts {
+		received = append(received, part.Number)
+	}
+	return dto.UploadSession{
+		Key:           from.Key,
+		FileName:      from.FileName,
+		Description:   from.Description,
+		TotalSize:     from.TotalSize,
+		ChunkSize:     from.ChunkSize,
+		TotalParts:    from.TotalParts(),
+		ReceivedParts: received,
+		Status:        from.Status,
+		ExpiresAt:     from.ExpiresAt,
+		FileId:        from.FileId,
+	}
+}
+
+// partKey keeps the parts of a session next to each other, numbers are padded so keys sort in order
+func partKey(sessionKey string, number int) string {
+	return fmt.Sprintf("%s/parts/%s/%05d", uploadDirectory, sessionKey, number)
+}
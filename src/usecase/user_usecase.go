@@ -6,6 +6,7 @@ import (
 
 	"github.com/minisource/template_go/config"
 	"github.com/minisource/template_go/domain/model"
+	"github.com/minisource/template_go/domain/ratelimit"
 	"github.com/minisource/template_go/domain/repository"
 	auth "github.com/minisource/auth/service"
 	"github.com/minisource/auth/service/models"
@@ -17,23 +18,31 @@ type UserUsecase struct {
 	cfg         *config.Config
 	authService *auth.AuthService
 	repository  repository.UserRepository
+	unitOfWork  repository.UnitOfWork
+	otpLimiter  *OtpLimiter
 }
 
-func NewUserUsecase(cfg *config.Config, repository repository.UserRepository) *UserUsecase {
+func NewUserUsecase(cfg *config.Config, repository repository.UserRepository, unitOfWork repository.UnitOfWork, otpStore ratelimit.Store) *UserUsecase {
 	logger := logging.NewLogger(&cfg.Logger)
 	return &UserUsecase{
 		cfg:         cfg,
 		repository:  repository,
+		unitOfWork:  unitOfWork,
 		logger:      logger,
 		authService: auth.GetAuthService(),
+		otpLimiter:  NewOtpLimiter(cfg, otpStore),
 	}
 }
 
-func (u UserUsecase) SendOtpByMobileNumber(countryCode, mobileNumber string) error {
+// SendOtpByMobileNumber sends a login code, limited per phone number and per client IP
+func (u UserUsecase) SendOtpByMobileNumber(ctx context.Context, countryCode, mobileNumber string, clientIp string) error {
 	if countryCode == "" {
 		countryCode = "+98"
 	}
 	phone := countryCode + mobileNumber
+	if err := u.otpLimiter.AllowSend(ctx, phone, clientIp); err != nil {
+		return err
+	}
 	err := u.authService.SendOTP(phone)
 	if err != nil {
 		return err
@@ -47,15 +56,24 @@ func (u *UserUsecase) RegisterAndLoginByMobileNumber(ctx context.Context, countr
 		countryCode = "+98"
 	}
 	phone := countryCode + mobileNumber
-	
-	// verify otp
-	result, err := u.authService.VerifyCode(phone, otp)
-	if err != nil {
+	if err := u.otpLimiter.CheckLocked(ctx, phone); err != nil {
 		return nil, err
 	}
-	if !result {
+
+	// verify otp, the auth service reports a wrong code as an error too
+	result, err := u.authService.VerifyCode(phone, otp)
+	if err != nil || !result {
+		if limitErr := u.otpLimiter.RecordFailure(ctx, phone); limitErr != nil {
+			return nil, limitErr
+		}
+		if err != nil {
+			return nil, err
+		}
 		return nil, errors.New("invalid OTP")
 	}
+	if err := u.otpLimiter.Reset(ctx, phone); err != nil {
+		u.logger.Error(logging.Redis, logging.Delete, err.Error(), nil)
+	}
 
 	// register and get user
 	user, err := u.authService.GetUserInfoByPhone(phone)
@@ -63,17 +81,60 @@ func (u *UserUsecase) RegisterAndLoginByMobileNumber(ctx context.Context, countr
 		return nil, err
 	}
 
-	userDb := model.User{UserId: user.Id}
-	exists, err := u.repository.ExistsUserId(ctx, user.Id)
-	if err != nil {
-		return nil, err
-	}
-	if !exists {
-		_, err = u.repository.CreateUser(ctx, userDb)
+	err = u.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		local, err := u.EnsureUser(ctx, user.Id)
 		if err != nil {
-			return nil, err
+			return err
 		}
+		// A failed sync only rolls back its savepoint, it must not block the login.
+		// The profile is refreshed on the next one.
+		if err := u.unitOfWork.Do(ctx, func(ctx context.Context) error {
+			_, err := u.repository.SyncProfile(ctx, local.Id, model.User{
+				Username:    user.Name,
+				DisplayName: user.DisplayName,
+				Email:       user.Email,
+				Phone:       user.Phone,
+				Locale:      user.Language,
+			})
+			return err
+		}); err != nil {
+			u.logger.Error(logging.Postgres, logging.Update, err.Error(), nil)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return u.authService.GenerateJWT(user.Name)
 }
+
+// EnsureUser returns the local user of an auth service account, registering it on first sight.
+// Registration holds a lock on the account, concurrent first logins wait and find the user created.
+func (u *UserUsecase) EnsureUser(ctx context.Context, userId string) (model.User, error) {
+	exists, err := u.repository.ExistsUserId(ctx, userId)
+	if err != nil {
+		return model.User{}, err
+	}
+	if exists {
+		return u.repository.GetByUserId(ctx, userId)
+	}
+
+	var user model.User
+	err = u.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := u.repository.LockUserId(ctx, userId); err != nil {
+			return err
+		}
+		exists, err := u.repository.ExistsUserId(ctx, userId)
+		if err != nil {
+			return err
+		}
+		if exists {
+			user, err = u.repository.GetByUserId(ctx, userId)
+		} else {
+			user, err = u.repository.CreateUser(ctx, model.User{UserId: userId})
+		}
+		return err
+	})
+	return user, err
+}